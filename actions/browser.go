@@ -2,16 +2,31 @@ package actions
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
 	"github.com/skratchdot/open-golang/open"
 )
 
-// formatURL creates the localhost URL for a given port
-func formatURL(port int) string {
-	return fmt.Sprintf("http://localhost:%d", port)
+// browserHost picks the host to browse to for a socket bound to address
+// Wildcard and unknown addresses use localhost, since they accept local connections
+func browserHost(address string) string {
+	ip := net.ParseIP(strings.SplitN(address, "%", 2)[0])
+	if ip == nil || ip.IsUnspecified() {
+		return "localhost"
+	}
+	// Zones must be percent-encoded inside a URL host, e.g. [fe80::1%25en0]
+	return strings.ReplaceAll(address, "%", "%25")
+}
+
+// formatURL creates the URL for a port bound to the given address
+func formatURL(address string, port int) string {
+	return fmt.Sprintf("http://%s", net.JoinHostPort(browserHost(address), strconv.Itoa(port)))
 }
 
-// OpenBrowser opens the default browser to the given port
-func OpenBrowser(port int) error {
-	url := formatURL(port)
+// OpenBrowser opens the default browser to the given port on its bind address
+func OpenBrowser(address string, port int) error {
+	url := formatURL(address, port)
 	return open.Run(url)
 }
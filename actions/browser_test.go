@@ -14,7 +14,7 @@ func TestOpenBrowser(t *testing.T) {
 	}()
 
 	// Use a high port unlikely to conflict
-	err := OpenBrowser(38291)
+	err := OpenBrowser("", 38291)
 
 	// We expect this might fail (port not actually serving)
 	// but it shouldn't panic or return error from open.Run
@@ -25,18 +25,23 @@ func TestOpenBrowser(t *testing.T) {
 
 func TestFormatURL(t *testing.T) {
 	tests := []struct {
-		port int
-		want string
+		address string
+		port    int
+		want    string
 	}{
-		{3000, "http://localhost:3000"},
-		{8080, "http://localhost:8080"},
-		{80, "http://localhost:80"},
+		{"", 3000, "http://localhost:3000"},
+		{"0.0.0.0", 8080, "http://localhost:8080"},
+		{"::", 80, "http://localhost:80"},
+		{"127.0.0.1", 3000, "http://127.0.0.1:3000"},
+		{"::1", 3000, "http://[::1]:3000"},
+		{"192.168.1.23", 8000, "http://192.168.1.23:8000"},
+		{"fe80::1%en0", 5000, "http://[fe80::1%25en0]:5000"},
 	}
 
 	for _, tt := range tests {
-		got := formatURL(tt.port)
+		got := formatURL(tt.address, tt.port)
 		if got != tt.want {
-			t.Errorf("formatURL(%d) = %v, want %v", tt.port, got, tt.want)
+			t.Errorf("formatURL(%q, %d) = %v, want %v", tt.address, tt.port, got, tt.want)
 		}
 	}
 }
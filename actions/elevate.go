@@ -0,0 +1,193 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"syscall"
+)
+
+// ErrNotConfirmed is returned when the confirmation hook declined elevation
+var ErrNotConfirmed = errors.New("privileged kill not confirmed")
+
+// ErrPermission is returned when a process belongs to another user and the
+// kill was not allowed to use administrator rights
+var ErrPermission = errors.New("permission denied")
+
+// Elevator delivers a signal with administrator rights
+type Elevator interface {
+	// Name identifies the mechanism in logs and errors, e.g. "sudo"
+	Name() string
+	// Available reports whether the mechanism can be used on this system
+	Available() bool
+	// Kill sends sig to pids as root
+	Kill(sig syscall.Signal, pids []int) error
+}
+
+// ConfirmFunc asks the user before signalling pids as root; false cancels
+type ConfirmFunc func(sig syscall.Signal, pids []int) bool
+
+// runFunc executes a command, replaceable in tests
+type runFunc func(name string, args ...string) error
+
+func runCommand(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	// sudo -n and pkexec report why they refused on stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// killArgs returns the arguments of kill(1) for sig and pids, e.g.
+// ["-15", "812", "813"]. Every argument is formatted from an integer, so
+// nothing a process controls (like its name) ever reaches a command line
+func killArgs(sig syscall.Signal, pids []int) ([]string, error) {
+	if len(pids) == 0 {
+		return nil, errors.New("no PIDs to signal")
+	}
+	if !knownSignal(sig) {
+		return nil, fmt.Errorf("refusing to send %s as root", SignalName(sig))
+	}
+	args := []string{"-" + strconv.Itoa(int(sig))}
+	for _, pid := range pids {
+		// 0 and negative PIDs address process groups, 1 is init
+		if pid <= 1 {
+			return nil, fmt.Errorf("refusing to signal PID %d", pid)
+		}
+		args = append(args, strconv.Itoa(pid))
+	}
+	return args, nil
+}
+
+// killPaths are the places kill(1) is looked for. pkexec and sudo need a
+// path rather than the shell builtin, and $PATH is not searched because the
+// binary runs as root
+var killPaths = []string{"/bin/kill", "/usr/bin/kill"}
+
+// killPath returns the first existing entry of killPaths
+func killPath() string {
+	for _, path := range killPaths {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return killPaths[0]
+}
+
+// osascriptKillScript quotes each argument with "quoted form of" inside
+// AppleScript, so the arguments are never spliced into the script source
+const osascriptKillScript = `on run argv
+set command to "/bin/kill"
+repeat with arg in argv
+set command to command & " " & quoted form of (arg as text)
+end repeat
+do shell script command with administrator privileges
+end run`
+
+// OSAScriptElevator asks for the administrator password with the macOS dialog
+type OSAScriptElevator struct {
+	run runFunc
+}
+
+// NewOSAScriptElevator returns the macOS elevator
+func NewOSAScriptElevator() *OSAScriptElevator {
+	return &OSAScriptElevator{run: runCommand}
+}
+
+func (e *OSAScriptElevator) Name() string { return "osascript" }
+
+func (e *OSAScriptElevator) Available() bool { return runtime.GOOS == "darwin" }
+
+func (e *OSAScriptElevator) Kill(sig syscall.Signal, pids []int) error {
+	args, err := killArgs(sig, pids)
+	if err != nil {
+		return err
+	}
+	if err := e.run("osascript", append([]string{"-e", osascriptKillScript}, args...)...); err != nil {
+		return fmt.Errorf("osascript failed: %w", err)
+	}
+	return nil
+}
+
+// PkexecElevator asks for the password through the polkit agent of the desktop
+type PkexecElevator struct {
+	run      runFunc
+	lookPath func(file string) (string, error)
+	getenv   func(key string) string
+}
+
+// NewPkexecElevator returns the polkit elevator
+func NewPkexecElevator() *PkexecElevator {
+	return &PkexecElevator{run: runCommand, lookPath: exec.LookPath, getenv: os.Getenv}
+}
+
+func (e *PkexecElevator) Name() string { return "pkexec" }
+
+// Available requires pkexec and a graphical session for the password dialog
+func (e *PkexecElevator) Available() bool {
+	if _, err := e.lookPath("pkexec"); err != nil {
+		return false
+	}
+	return e.getenv("DISPLAY") != "" || e.getenv("WAYLAND_DISPLAY") != ""
+}
+
+func (e *PkexecElevator) Kill(sig syscall.Signal, pids []int) error {
+	args, err := killArgs(sig, pids)
+	if err != nil {
+		return err
+	}
+	if err := e.run("pkexec", append([]string{killPath()}, args...)...); err != nil {
+		return fmt.Errorf("pkexec failed: %w", err)
+	}
+	return nil
+}
+
+// SudoElevator runs sudo without prompting (-n), so it only works with cached
+// credentials or a NOPASSWD rule and never hangs waiting for input
+type SudoElevator struct {
+	run      runFunc
+	lookPath func(file string) (string, error)
+}
+
+// NewSudoElevator returns the non-interactive sudo elevator
+func NewSudoElevator() *SudoElevator {
+	return &SudoElevator{run: runCommand, lookPath: exec.LookPath}
+}
+
+func (e *SudoElevator) Name() string { return "sudo" }
+
+func (e *SudoElevator) Available() bool {
+	_, err := e.lookPath("sudo")
+	return err == nil
+}
+
+func (e *SudoElevator) Kill(sig syscall.Signal, pids []int) error {
+	args, err := killArgs(sig, pids)
+	if err != nil {
+		return err
+	}
+	if err := e.run("sudo", append([]string{"-n", "--", killPath()}, args...)...); err != nil {
+		return fmt.Errorf("sudo -n failed (run 'sudo -v' first to cache credentials): %w", err)
+	}
+	return nil
+}
+
+// DefaultElevator returns the first available elevator for this platform,
+// or nil if there is none
+func DefaultElevator() Elevator {
+	var candidates []Elevator
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = []Elevator{NewOSAScriptElevator()}
+	case "linux":
+		candidates = []Elevator{NewPkexecElevator(), NewSudoElevator()}
+	}
+	for _, e := range candidates {
+		if e.Available() {
+			return e
+		}
+	}
+	return nil
+}
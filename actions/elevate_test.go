@@ -0,0 +1,155 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// fakeElevator records invocations instead of running anything as root
+type fakeElevator struct {
+	calls   []string                          // "SIGTERM [100 101]"
+	deliver func(pid int, sig syscall.Signal) // Simulates the signal arriving
+	err     error
+}
+
+func (f *fakeElevator) Name() string    { return "fake" }
+func (f *fakeElevator) Available() bool { return true }
+
+func (f *fakeElevator) Kill(sig syscall.Signal, pids []int) error {
+	f.calls = append(f.calls, fmt.Sprintf("%s %v", SignalName(sig), pids))
+	if f.err != nil {
+		return f.err
+	}
+	for _, pid := range pids {
+		if f.deliver != nil {
+			f.deliver(pid, sig)
+		}
+	}
+	return nil
+}
+
+// recordRun returns a runFunc that records command lines
+func recordRun(commands *[][]string, err error) runFunc {
+	return func(name string, args ...string) error {
+		*commands = append(*commands, append([]string{name}, args...))
+		return err
+	}
+}
+
+func TestKillArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		sig     syscall.Signal
+		pids    []int
+		want    string
+		wantErr bool
+	}{
+		{"term", syscall.SIGTERM, []int{812, 813}, "-15 812 813", false},
+		{"kill", syscall.SIGKILL, []int{4242}, "-9 4242", false},
+		{"no pids", syscall.SIGTERM, nil, "", true},
+		{"init", syscall.SIGKILL, []int{1}, "", true},
+		{"process group", syscall.SIGKILL, []int{-812}, "", true},
+		{"all processes", syscall.SIGKILL, []int{0}, "", true},
+		{"unsupported signal", syscall.SIGSTOP, []int{812}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := killArgs(tt.sig, tt.pids)
+			if (err != nil) != tt.wantErr || strings.Join(args, " ") != tt.want {
+				t.Errorf("killArgs() = %q, %v, want %q, error %v", args, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestElevators_CommandLines(t *testing.T) {
+	lookPath := func(file string) (string, error) { return "/usr/bin/" + file, nil }
+	kill := killPath()
+
+	tests := []struct {
+		name     string
+		elevator func(run runFunc) Elevator
+		want     []string
+	}{
+		{
+			name:     "osascript",
+			elevator: func(run runFunc) Elevator { return &OSAScriptElevator{run: run} },
+			want:     []string{"osascript", "-e", osascriptKillScript, "-15", "812", "813"},
+		},
+		{
+			name:     "pkexec",
+			elevator: func(run runFunc) Elevator { return &PkexecElevator{run: run, lookPath: lookPath} },
+			want:     []string{"pkexec", kill, "-15", "812", "813"},
+		},
+		{
+			name:     "sudo",
+			elevator: func(run runFunc) Elevator { return &SudoElevator{run: run, lookPath: lookPath} },
+			want:     []string{"sudo", "-n", "--", kill, "-15", "812", "813"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var commands [][]string
+			e := tt.elevator(recordRun(&commands, nil))
+			if err := e.Kill(syscall.SIGTERM, []int{812, 813}); err != nil {
+				t.Fatalf("Kill() error = %v", err)
+			}
+			if len(commands) != 1 || strings.Join(commands[0], "\x00") != strings.Join(tt.want, "\x00") {
+				t.Errorf("commands = %q, want %q", commands, tt.want)
+			}
+
+			// Invalid input never reaches the command
+			commands = nil
+			if err := e.Kill(syscall.SIGTERM, []int{1}); err == nil || len(commands) != 0 {
+				t.Errorf("Kill(PID 1) error = %v, commands = %q, want refusal without running anything", err, commands)
+			}
+		})
+	}
+}
+
+func TestElevators_WrapErrors(t *testing.T) {
+	failure := errors.New("exit status 1")
+	var commands [][]string
+	e := &SudoElevator{run: recordRun(&commands, failure), lookPath: func(string) (string, error) { return "/usr/bin/sudo", nil }}
+
+	err := e.Kill(syscall.SIGTERM, []int{812})
+	if !errors.Is(err, failure) || !strings.Contains(err.Error(), "sudo -v") {
+		t.Errorf("Kill() error = %v, want the wrapped failure with a hint", err)
+	}
+}
+
+func TestPkexecElevator_Available(t *testing.T) {
+	tests := []struct {
+		name  string
+		found bool
+		env   map[string]string
+		want  bool
+	}{
+		{"x11", true, map[string]string{"DISPLAY": ":0"}, true},
+		{"wayland", true, map[string]string{"WAYLAND_DISPLAY": "wayland-0"}, true},
+		{"no session", true, nil, false},
+		{"not installed", false, map[string]string{"DISPLAY": ":0"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := &PkexecElevator{
+				lookPath: func(file string) (string, error) {
+					if !tt.found {
+						return "", errors.New("not found")
+					}
+					return "/usr/bin/" + file, nil
+				},
+				getenv: func(key string) string { return tt.env[key] },
+			}
+			if got := e.Available(); got != tt.want {
+				t.Errorf("Available() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
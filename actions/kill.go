@@ -1,28 +1,485 @@
 package actions
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"port-digger/scanner"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
-// KillProcess attempts to terminate a process by PID
-// First tries graceful SIGTERM, then falls back to SIGKILL with sudo
+// DefaultGrace is how long KillProcess waits for a process to exit before
+// escalating to SIGKILL
+const DefaultGrace = 3 * time.Second
+
+// killPollInterval is how often exits are checked while waiting
+const killPollInterval = 100 * time.Millisecond
+
+// defaultKillWait is how long to wait for the kernel to tear processes down
+// after SIGKILL
+const defaultKillWait = 2 * time.Second
+
+// Scope selects which processes besides the target are signalled
+type Scope string
+
+const (
+	ScopeProcess Scope = "process" // Only the target
+	ScopeGroup   Scope = "group"   // Every process in the target's process group
+	ScopeTree    Scope = "tree"    // The target and all of its descendants
+)
+
+// signals are the signals a kill may start with, by name
+var signals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"INT":  syscall.SIGINT,
+	"HUP":  syscall.SIGHUP,
+	"KILL": syscall.SIGKILL,
+}
+
+// ParseSignal accepts TERM, INT, HUP or KILL, with or without the SIG prefix
+func ParseSignal(name string) (syscall.Signal, error) {
+	sig, ok := signals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return 0, fmt.Errorf("unsupported signal %q: use TERM, INT, HUP or KILL", name)
+	}
+	return sig, nil
+}
+
+// SignalName returns "SIGTERM" for syscall.SIGTERM
+func SignalName(sig syscall.Signal) string {
+	for name, s := range signals {
+		if s == sig {
+			return "SIG" + name
+		}
+	}
+	return "signal " + strconv.Itoa(int(sig))
+}
+
+// knownSignal reports whether sig is one of the signals ParseSignal accepts
+func knownSignal(sig syscall.Signal) bool {
+	for _, s := range signals {
+		if s == sig {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseScope accepts process, group or tree
+func ParseScope(name string) (Scope, error) {
+	switch scope := Scope(strings.ToLower(name)); scope {
+	case ScopeProcess, ScopeGroup, ScopeTree:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown scope %q: use process, group or tree", name)
+}
+
+// KillOptions configures Kill
+type KillOptions struct {
+	Signal         syscall.Signal // First signal to send; 0 means SIGTERM
+	Grace          time.Duration  // How long to wait for the processes to exit
+	Escalate       bool           // Send SIGKILL to processes still running after Grace
+	Scope          Scope          // "" means ScopeProcess
+	DryRun         bool           // Only report which processes would be signalled
+	Privileged     bool           // Retry with administrator rights when permission is denied
+	KillPrivileged bool           // With Privileged, send SIGKILL in the first privileged call so the password is asked once
+	Elevator       Elevator       // Gets administrator rights; nil uses DefaultElevator
+	Confirm        ConfirmFunc    // Asked before each privileged signal; nil means yes
+	Port           int            // When set, also wait until nothing listens on Port
+	Protocol       string         // Of Port: "tcp" (default) or "udp"
+	KillWait       time.Duration  // How long to wait after SIGKILL; 0 uses defaultKillWait
+}
+
+// ProcessResult is the outcome for one signalled process
+type ProcessResult struct {
+	PID    int
+	Name   string         // Command name, "" if unknown
+	Signal syscall.Signal // Last signal sent, 0 for a dry run
+	Exited bool
+}
+
+// SentSignal records one signal delivery
+type SentSignal struct {
+	PID    int
+	Signal syscall.Signal
+	At     time.Duration // Since the kill started
+}
+
+// KillResult reports what Kill did
+type KillResult struct {
+	Processes []ProcessResult
+	Sent      []SentSignal
+	Escalated bool          // SIGKILL was sent after the grace period
+	Elapsed   time.Duration // From the first signal until Kill returned
+	PortFreed bool          // Nothing listens on KillOptions.Port any more
+}
+
+// Survivors returns the PIDs that were still running when Kill returned
+func (r KillResult) Survivors() []int {
+	var pids []int
+	for _, p := range r.Processes {
+		if !p.Exited {
+			pids = append(pids, p.PID)
+		}
+	}
+	return pids
+}
+
+// killer holds the system calls used by Kill so tests can replace them
+type killer struct {
+	signal    func(pid int, sig syscall.Signal) error
+	alive     func(pid int) bool
+	processes func() ([]procEntry, error)
+	elevator  func() Elevator
+	portOpen  func(port int, protocol string) (bool, error)
+	sleep     func(d time.Duration)
+	now       func() time.Time
+	self      func() int // PID of port-digger, never signalled
+}
+
+var systemKiller = &killer{
+	signal:    syscall.Kill,
+	alive:     processAlive,
+	processes: listProcesses,
+	elevator:  DefaultElevator,
+	portOpen:  portOpen,
+	sleep:     time.Sleep,
+	now:       time.Now,
+	self:      os.Getpid,
+}
+
+// processAlive reports whether pid exists; EPERM means it exists but belongs
+// to another user. Zombies have exited and only wait to be reaped by their
+// parent, so they count as dead
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	if err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	return !isZombie(pid)
+}
+
+// isZombie reads the process state from /proc, or from ps where there is no procfs
+func isZombie(pid int) bool {
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid)); err == nil {
+		return procState(string(data)) == "Z"
+	}
+	output, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimSpace(string(output)), "Z")
+}
+
+// procState returns the state field of /proc/<pid>/stat, e.g. "S" or "Z"
+// The command name may contain spaces and parentheses, so fields are counted
+// from the last ')'
+func procState(stat string) string {
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return ""
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// portOpen reports whether anything still listens on port with protocol
+func portOpen(port int, protocol string) (bool, error) {
+	ports, err := scanner.ScanPorts()
+	if err != nil {
+		return false, err
+	}
+	return len(PIDsOnPort(ports, port, protocol)) > 0, nil
+}
+
+// Kill signals pids (expanded according to opts.Scope) and waits up to
+// opts.Grace for them to exit and, if opts.Port is set, for the port to be
+// released. Processes still running then get SIGKILL if opts.Escalate is set
+func Kill(pids []int, opts KillOptions) (KillResult, error) {
+	return systemKiller.kill(pids, opts)
+}
+
+// PIDsOnPort returns the distinct PIDs with a socket on port, in scan order.
+// protocol is "tcp" or "udp"; "" means tcp
+func PIDsOnPort(ports []scanner.PortInfo, port int, protocol string) []int {
+	udp := strings.EqualFold(protocol, "udp")
+	var pids []int
+	seen := make(map[int]bool)
+	for _, p := range ports {
+		if p.Port == port && p.IsUDP() == udp && !seen[p.PID] {
+			seen[p.PID] = true
+			pids = append(pids, p.PID)
+		}
+	}
+	return pids
+}
+
+// KillProcess terminates a process with SIGTERM, waits DefaultGrace for it to
+// exit and then sends SIGKILL, asking for administrator rights if needed
 func KillProcess(pid int) error {
-	pidStr := fmt.Sprintf("%d", pid)
+	result, err := Kill([]int{pid}, KillOptions{Grace: DefaultGrace, Escalate: true, Privileged: true})
+	if err != nil {
+		return err
+	}
+	if survivors := result.Survivors(); len(survivors) > 0 {
+		return fmt.Errorf("process %d is still running", pid)
+	}
+	return nil
+}
 
-	// Try graceful kill first (SIGTERM)
-	cmd := exec.Command("kill", "-15", pidStr)
-	err := cmd.Run()
+// protected reports whether pid must never be signalled: 0 and negative PIDs
+// address process groups, 1 is init, and port-digger must not kill itself
+func (k *killer) protected(pid int) bool {
+	return pid <= 1 || pid == k.self()
+}
+
+// targets expands pids according to scope, keeping the requested PIDs first
+// Protected processes that share a group or tree with them are left out; in
+// containers the group is often init's
+func (k *killer) targets(pids []int, scope Scope) ([]int, map[int]string, error) {
+	table, err := k.processes()
+	if err != nil && scope != ScopeProcess {
+		return nil, nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	names := make(map[int]string, len(table))
+	for _, p := range table {
+		names[p.pid] = p.name
+	}
 
-	if err == nil {
-		return nil
+	var result []int
+	seen := make(map[int]bool)
+	add := func(pid int) {
+		if !seen[pid] && !k.protected(pid) {
+			seen[pid] = true
+			result = append(result, pid)
+		}
+	}
+	for _, pid := range pids {
+		add(pid)
 	}
+	for _, pid := range pids {
+		switch scope {
+		case ScopeGroup:
+			for _, member := range groupMembers(table, pid) {
+				add(member)
+			}
+		case ScopeTree:
+			for _, child := range descendants(table, pid) {
+				add(child)
+			}
+		}
+	}
+	return result, names, nil
+}
+
+// killState is a step of the kill state machine
+type killState int
+
+const (
+	stateSignal   killState = iota // Send the current signal to the survivors
+	stateWaitExit                  // Poll until the processes exited or the deadline passed
+	stateWaitPort                  // Poll until the port is released or the deadline passed
+	stateEscalate                  // Switch to SIGKILL if allowed
+	stateDone
+)
+
+func (k *killer) kill(pids []int, opts KillOptions) (KillResult, error) {
+	if opts.Signal == 0 {
+		opts.Signal = syscall.SIGTERM
+	}
+	if opts.Scope == "" {
+		opts.Scope = ScopeProcess
+	}
+	if opts.KillWait <= 0 {
+		opts.KillWait = defaultKillWait
+	}
+	for _, pid := range pids {
+		if k.protected(pid) {
+			return KillResult{}, fmt.Errorf("refusing to signal PID %d", pid)
+		}
+	}
+
+	targets, names, err := k.targets(pids, opts.Scope)
+	if err != nil {
+		return KillResult{}, err
+	}
+	var result KillResult
+	for _, pid := range targets {
+		result.Processes = append(result.Processes, ProcessResult{PID: pid, Name: names[pid]})
+	}
+	if opts.DryRun {
+		return result, nil
+	}
+
+	start := k.now()
+	err = k.run(&result, opts, start)
+	result.Elapsed = k.now().Sub(start)
+	return result, err
+}
+
+// run drives the state machine from the first signal until the processes
+// exited and the port was released, or every deadline passed
+func (k *killer) run(result *KillResult, opts KillOptions, start time.Time) error {
+	sig := opts.Signal
+	var deadline time.Time
+	for state := stateSignal; state != stateDone; {
+		switch state {
+		case stateSignal:
+			if err := k.send(result, sig, opts, start); err != nil {
+				return err
+			}
+			wait := opts.Grace
+			if sig == syscall.SIGKILL || opts.KillPrivileged {
+				wait = max(wait, opts.KillWait)
+			}
+			deadline = k.now().Add(wait)
+			state = stateWaitExit
+
+		case stateWaitExit:
+			k.poll(result)
+			switch {
+			case len(result.Survivors()) == 0:
+				state = stateWaitPort
+			case !k.now().Before(deadline):
+				state = stateEscalate
+			default:
+				k.sleep(killPollInterval)
+			}
 
-	// If graceful kill failed, try force kill with sudo via osascript
-	// This will prompt user for password via native macOS dialog
-	script := fmt.Sprintf("kill -9 %d", pid)
-	cmd = exec.Command("osascript", "-e",
-		fmt.Sprintf(`do shell script "%s" with administrator privileges`, script))
+		case stateWaitPort:
+			if opts.Port == 0 {
+				state = stateDone
+				break
+			}
+			open, err := k.portOpen(opts.Port, opts.Protocol)
+			switch {
+			case err == nil && !open:
+				result.PortFreed = true
+				state = stateDone
+			case !k.now().Before(deadline):
+				// The port is held by a process outside the targets, e.g. a
+				// child that inherited the socket
+				state = stateDone
+			default:
+				k.sleep(killPollInterval)
+			}
 
-	return cmd.Run()
+		case stateEscalate:
+			state = stateDone
+			if opts.Escalate && sig != syscall.SIGKILL {
+				sig = syscall.SIGKILL
+				result.Escalated = true
+				state = stateSignal
+			}
+		}
+	}
+	return nil
+}
+
+// send signals every process that has not exited yet. Processes that already
+// got SIGKILL are not signalled again
+func (k *killer) send(result *KillResult, sig syscall.Signal, opts KillOptions, start time.Time) error {
+	sent := func(p *ProcessResult, sig syscall.Signal) {
+		p.Signal = sig
+		result.Sent = append(result.Sent, SentSignal{PID: p.PID, Signal: sig, At: k.now().Sub(start)})
+	}
+
+	var denied []int
+	for i := range result.Processes {
+		p := &result.Processes[i]
+		if p.Exited || p.Signal == syscall.SIGKILL {
+			continue
+		}
+		err := k.signal(p.PID, sig)
+		switch {
+		case err == nil:
+			sent(p, sig)
+		case errors.Is(err, syscall.ESRCH):
+			p.Exited = true
+		case errors.Is(err, syscall.EPERM):
+			denied = append(denied, p.PID)
+		default:
+			return fmt.Errorf("failed to send %s to PID %d: %w", SignalName(sig), p.PID, err)
+		}
+	}
+
+	if len(denied) > 0 {
+		// Every elevation asks for the password; KillPrivileged trades the
+		// graceful shutdown for asking only once
+		elevated := sig
+		if opts.KillPrivileged {
+			elevated = syscall.SIGKILL
+		}
+		if err := k.elevate(denied, elevated, opts); err != nil {
+			return err
+		}
+		for i := range result.Processes {
+			for _, pid := range denied {
+				if result.Processes[i].PID == pid {
+					sent(&result.Processes[i], elevated)
+				}
+			}
+		}
+	}
+
+	if len(result.Sent) == 0 {
+		// Every target was already gone
+		return fmt.Errorf("no such process: %s", joinInts(pidsOf(result.Processes)))
+	}
+	return nil
+}
+
+// elevate signals pids with administrator rights if opts allow it
+func (k *killer) elevate(pids []int, sig syscall.Signal, opts KillOptions) error {
+	if !opts.Privileged {
+		return fmt.Errorf("%w for PID %s: retry with administrator rights", ErrPermission, joinInts(pids))
+	}
+	elevator := opts.Elevator
+	if elevator == nil {
+		elevator = k.elevator()
+	}
+	if elevator == nil {
+		return fmt.Errorf("permission denied for PID %s and no way to get administrator rights on this system", joinInts(pids))
+	}
+	if opts.Confirm != nil && !opts.Confirm(sig, pids) {
+		return ErrNotConfirmed
+	}
+	if err := elevator.Kill(sig, pids); err != nil {
+		return fmt.Errorf("privileged %s via %s failed: %w", SignalName(sig), elevator.Name(), err)
+	}
+	return nil
+}
+
+// poll marks the processes that have exited
+func (k *killer) poll(result *KillResult) {
+	for i := range result.Processes {
+		p := &result.Processes[i]
+		if !p.Exited && !k.alive(p.PID) {
+			p.Exited = true
+		}
+	}
+}
+
+func pidsOf(processes []ProcessResult) []int {
+	pids := make([]int, len(processes))
+	for i, p := range processes {
+		pids[i] = p.PID
+	}
+	return pids
+}
+
+// joinInts returns "1, 2, 3"
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ", ")
 }
@@ -1,9 +1,18 @@
 package actions
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
 	"os"
 	"os/exec"
+	"os/signal"
+	"port-digger/scanner"
 	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -50,3 +59,677 @@ func TestKillProcess_InvalidPID(t *testing.T) {
 		t.Error("KillProcess(999999) expected error for invalid PID, got nil")
 	}
 }
+
+// fakeSelf is the PID of port-digger in a fakeSystem
+const fakeSelf = 999
+
+// fakeSystem simulates processes that exit on some signals and ignore others
+type fakeSystem struct {
+	running   map[int]bool
+	ignore    map[int]syscall.Signal // PID ignores this signal
+	foreign   map[int]bool           // Signalling returns EPERM
+	sent      []string
+	elevator  *fakeElevator
+	now       time.Time
+	table     []procEntry
+	exitDelay time.Duration // Processes exit this long after a signal they honour
+	exitAt    map[int]time.Time
+	portUntil time.Time // The port stays open until then
+}
+
+func newFakeSystem(pids ...int) *fakeSystem {
+	f := &fakeSystem{
+		running: make(map[int]bool),
+		ignore:  make(map[int]syscall.Signal),
+		foreign: make(map[int]bool),
+		exitAt:  make(map[int]time.Time),
+		now:     time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	f.elevator = &fakeElevator{deliver: f.deliver}
+	for _, pid := range pids {
+		f.running[pid] = true
+		f.table = append(f.table, procEntry{pid: pid, ppid: 1, pgid: pid, name: fmt.Sprintf("proc%d", pid)})
+	}
+	return f
+}
+
+func (f *fakeSystem) deliver(pid int, sig syscall.Signal) {
+	if f.ignore[pid] != sig {
+		f.exitAt[pid] = f.now.Add(f.exitDelay)
+	}
+}
+
+func (f *fakeSystem) killer() *killer {
+	return &killer{
+		signal: func(pid int, sig syscall.Signal) error {
+			f.sent = append(f.sent, fmt.Sprintf("%d:%s", pid, SignalName(sig)))
+			if !f.running[pid] {
+				return syscall.ESRCH
+			}
+			if f.foreign[pid] {
+				return syscall.EPERM
+			}
+			f.deliver(pid, sig)
+			return nil
+		},
+		alive: func(pid int) bool {
+			if at, ok := f.exitAt[pid]; ok && !f.now.Before(at) {
+				f.running[pid] = false
+			}
+			return f.running[pid]
+		},
+		processes: func() ([]procEntry, error) { return f.table, nil },
+		elevator: func() Elevator {
+			if f.elevator == nil {
+				return nil
+			}
+			return f.elevator
+		},
+		portOpen: func(int, string) (bool, error) { return f.now.Before(f.portUntil), nil },
+		sleep:    func(d time.Duration) { f.now = f.now.Add(d) },
+		now:      func() time.Time { return f.now },
+		self:     func() int { return fakeSelf },
+	}
+}
+
+func TestKill_Escalation(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          KillOptions
+		ignoreTerm    bool
+		wantSent      []string
+		wantExited    bool
+		wantEscalated bool
+	}{
+		{
+			name:       "exits on SIGTERM",
+			opts:       KillOptions{Grace: time.Second, Escalate: true},
+			wantSent:   []string{"100:SIGTERM"},
+			wantExited: true,
+		},
+		{
+			name:          "ignores SIGTERM, escalates",
+			opts:          KillOptions{Grace: time.Second, Escalate: true},
+			ignoreTerm:    true,
+			wantSent:      []string{"100:SIGTERM", "100:SIGKILL"},
+			wantExited:    true,
+			wantEscalated: true,
+		},
+		{
+			name:       "ignores SIGTERM, no escalation",
+			opts:       KillOptions{Grace: time.Second},
+			ignoreTerm: true,
+			wantSent:   []string{"100:SIGTERM"},
+		},
+		{
+			name:       "starts with SIGHUP",
+			opts:       KillOptions{Signal: syscall.SIGHUP, Grace: time.Second, Escalate: true},
+			wantSent:   []string{"100:SIGHUP"},
+			wantExited: true,
+		},
+		{
+			name:     "dry run",
+			opts:     KillOptions{DryRun: true, Escalate: true},
+			wantSent: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeSystem(100)
+			f.exitDelay = 300 * time.Millisecond
+			if tt.ignoreTerm {
+				f.ignore[100] = syscall.SIGTERM
+			}
+
+			result, err := f.killer().kill([]int{100}, tt.opts)
+			if err != nil {
+				t.Fatalf("kill() error = %v", err)
+			}
+			if strings.Join(f.sent, " ") != strings.Join(tt.wantSent, " ") {
+				t.Errorf("sent = %v, want %v", f.sent, tt.wantSent)
+			}
+			if len(result.Processes) != 1 || result.Processes[0].Exited != tt.wantExited || result.Processes[0].Name != "proc100" {
+				t.Errorf("processes = %+v, want exited = %v", result.Processes, tt.wantExited)
+			}
+			if result.Escalated != tt.wantEscalated {
+				t.Errorf("escalated = %v, want %v", result.Escalated, tt.wantEscalated)
+			}
+		})
+	}
+}
+
+func TestKill_GraceIsHonoured(t *testing.T) {
+	f := newFakeSystem(100)
+	f.ignore[100] = syscall.SIGTERM
+	start := f.now
+
+	if _, err := f.killer().kill([]int{100}, KillOptions{Grace: 2 * time.Second, Escalate: true}); err != nil {
+		t.Fatalf("kill() error = %v", err)
+	}
+	// SIGKILL must not be sent before the grace period is over
+	if waited := f.exitAt[100].Sub(start); waited < 2*time.Second {
+		t.Errorf("SIGKILL sent after %v, want at least the 2s grace", waited)
+	}
+}
+
+func TestKill_Scope(t *testing.T) {
+	f := newFakeSystem(100, 101, 102, 103, 200)
+	f.table = []procEntry{
+		{pid: 100, ppid: 1, pgid: 100, name: "npm"},
+		{pid: 101, ppid: 100, pgid: 100, name: "node"},
+		{pid: 102, ppid: 101, pgid: 102, name: "esbuild"},
+		{pid: 103, ppid: 50, pgid: 100, name: "tail"},
+		{pid: 200, ppid: 1, pgid: 200, name: "postgres"},
+	}
+
+	tests := []struct {
+		scope Scope
+		want  []int
+	}{
+		{ScopeProcess, []int{101}},
+		{ScopeTree, []int{101, 102}},
+		{ScopeGroup, []int{101, 100, 103}},
+	}
+	for _, tt := range tests {
+		result, err := f.killer().kill([]int{101}, KillOptions{Scope: tt.scope, DryRun: true})
+		if err != nil {
+			t.Fatalf("kill(%s) error = %v", tt.scope, err)
+		}
+		if got := pidsOf(result.Processes); joinInts(got) != joinInts(tt.want) {
+			t.Errorf("scope %s targets = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestKill_Permission(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         KillOptions
+		noElevator   bool
+		wantErr      error
+		wantErrText  string
+		wantElevated string
+		wantAsked    string
+	}{
+		{
+			name:    "denied without opt-in",
+			opts:    KillOptions{Grace: time.Second},
+			wantErr: ErrPermission,
+		},
+		{
+			name:         "privileged opt-in",
+			opts:         KillOptions{Grace: time.Second, Privileged: true},
+			wantElevated: "SIGTERM [100]",
+		},
+		{
+			name:         "confirmed",
+			opts:         KillOptions{Grace: time.Second, Privileged: true, Confirm: func(syscall.Signal, []int) bool { return true }},
+			wantElevated: "SIGTERM [100]",
+		},
+		{
+			name:    "declined",
+			opts:    KillOptions{Grace: time.Second, Privileged: true, Confirm: func(syscall.Signal, []int) bool { return false }},
+			wantErr: ErrNotConfirmed,
+		},
+		{
+			name:        "no elevator on this system",
+			opts:        KillOptions{Grace: time.Second, Privileged: true},
+			noElevator:  true,
+			wantErrText: "no way to get administrator rights",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeSystem(100)
+			f.foreign[100] = true
+			if tt.noElevator {
+				f.elevator = nil
+			}
+
+			result, err := f.killer().kill([]int{100}, tt.opts)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("kill() error = %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantErrText != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Errorf("kill() error = %v, want it to mention %q", err, tt.wantErrText)
+				}
+			case err != nil:
+				t.Fatalf("kill() error = %v", err)
+			default:
+				if !result.Processes[0].Exited {
+					t.Errorf("result = %+v, want the process to exit", result)
+				}
+			}
+			if f.elevator != nil {
+				if got := strings.Join(f.elevator.calls, "; "); got != tt.wantElevated {
+					t.Errorf("elevated = %q, want %q", got, tt.wantElevated)
+				}
+			}
+		})
+	}
+}
+
+func TestKill_ExplicitElevator(t *testing.T) {
+	f := newFakeSystem(100)
+	f.foreign[100] = true
+	f.ignore[100] = syscall.SIGTERM
+	explicit := &fakeElevator{deliver: f.deliver}
+
+	var asked []string
+	confirm := func(sig syscall.Signal, pids []int) bool {
+		asked = append(asked, fmt.Sprintf("%s %v", SignalName(sig), pids))
+		return true
+	}
+	opts := KillOptions{Grace: time.Second, Escalate: true, Privileged: true, Elevator: explicit, Confirm: confirm}
+	if _, err := f.killer().kill([]int{100}, opts); err != nil {
+		t.Fatalf("kill() error = %v", err)
+	}
+
+	if got := strings.Join(explicit.calls, "; "); got != "SIGTERM [100]; SIGKILL [100]" {
+		t.Errorf("explicit elevator calls = %q", got)
+	}
+	if len(f.elevator.calls) != 0 {
+		t.Errorf("default elevator calls = %v, want none", f.elevator.calls)
+	}
+	if got := strings.Join(asked, "; "); got != "SIGTERM [100]; SIGKILL [100]" {
+		t.Errorf("confirmations = %q, want one per signal", got)
+	}
+}
+
+func TestKill_KillPrivileged(t *testing.T) {
+	// 100 belongs to root, 101 is ours; both ignore SIGTERM
+	f := newFakeSystem(100, 101)
+	f.foreign[100] = true
+	f.ignore[100] = syscall.SIGTERM
+	f.ignore[101] = syscall.SIGTERM
+
+	opts := KillOptions{Grace: time.Second, Escalate: true, Privileged: true, KillPrivileged: true}
+	result, err := f.killer().kill([]int{100, 101}, opts)
+	if err != nil {
+		t.Fatalf("kill() error = %v", err)
+	}
+
+	if got := strings.Join(f.elevator.calls, "; "); got != "SIGKILL [100]" {
+		t.Errorf("elevated = %q, want a single SIGKILL", got)
+	}
+	if got := strings.Join(f.sent, " "); got != "100:SIGTERM 101:SIGTERM 101:SIGKILL" {
+		t.Errorf("sent = %q", got)
+	}
+	if !result.Escalated || len(result.Survivors()) != 0 {
+		t.Errorf("result = %+v, want both killed", result)
+	}
+}
+
+func TestKill_ScopeSkipsProtected(t *testing.T) {
+	tests := []struct {
+		scope Scope
+		want  []int
+	}{
+		{ScopeGroup, []int{101, 102}},
+		{ScopeTree, []int{101, 102}},
+	}
+	for _, tt := range tests {
+		// A container where everything, port-digger included, is in init's group
+		f := newFakeSystem(1, 101, 102, fakeSelf)
+		f.table = []procEntry{
+			{pid: 1, ppid: 0, pgid: 1, name: "tini"},
+			{pid: 101, ppid: 1, pgid: 1, name: "node"},
+			{pid: 102, ppid: 101, pgid: 1, name: "esbuild"},
+			{pid: fakeSelf, ppid: 101, pgid: 1, name: "port-digger"},
+		}
+		result, err := f.killer().kill([]int{101}, KillOptions{Scope: tt.scope})
+		if err != nil {
+			t.Fatalf("kill(%s) error = %v", tt.scope, err)
+		}
+		if got := pidsOf(result.Processes); joinInts(got) != joinInts(tt.want) {
+			t.Errorf("scope %s targets = %v, want %v", tt.scope, got, tt.want)
+		}
+		for _, sent := range result.Sent {
+			if sent.PID == 1 || sent.PID == fakeSelf {
+				t.Errorf("scope %s signalled protected PID %d", tt.scope, sent.PID)
+			}
+		}
+	}
+}
+
+func TestKill_Errors(t *testing.T) {
+	f := newFakeSystem()
+	if _, err := f.killer().kill([]int{424242}, KillOptions{}); err == nil {
+		t.Error("kill() of a missing process: error = nil")
+	}
+	for _, pid := range []int{0, 1, -5, fakeSelf} {
+		if _, err := f.killer().kill([]int{pid}, KillOptions{}); err == nil {
+			t.Errorf("kill(%d) error = nil, want refusal", pid)
+		}
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{"TERM", syscall.SIGTERM, false},
+		{"sigint", syscall.SIGINT, false},
+		{"SIGHUP", syscall.SIGHUP, false},
+		{"kill", syscall.SIGKILL, false},
+		{"USR1", 0, true},
+		{"9", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSignal(tt.name)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSignal(%q) = %v, %v, want %v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestPIDsOnPort(t *testing.T) {
+	ports := []scanner.PortInfo{
+		{Port: 3000, PID: 42, Protocol: "TCP"},
+		{Port: 3000, PID: 42, Protocol: "TCP6"},
+		{Port: 3000, PID: 43, Protocol: "TCP"},
+		{Port: 3000, PID: 44, Protocol: "UDP"},
+		{Port: 5432, PID: 99, Protocol: "TCP"},
+	}
+	tests := []struct {
+		port     int
+		protocol string
+		want     string
+	}{
+		{3000, "", "42, 43"},
+		{3000, "tcp", "42, 43"},
+		{3000, "UDP", "44"},
+		{5432, "udp", ""},
+		{8080, "", ""},
+	}
+	for _, tt := range tests {
+		if got := PIDsOnPort(ports, tt.port, tt.protocol); joinInts(got) != tt.want {
+			t.Errorf("PIDsOnPort(%d, %q) = %v, want [%s]", tt.port, tt.protocol, got, tt.want)
+		}
+	}
+}
+
+func TestKill_WaitsForPortRelease(t *testing.T) {
+	tests := []struct {
+		name          string
+		portHeldFor   time.Duration // After the kill started
+		ignoreTerm    bool
+		wantFreed     bool
+		wantEscalated bool
+		wantElapsed   time.Duration
+	}{
+		{"released with the process", 0, false, true, false, 300 * time.Millisecond},
+		{"released a bit later", 800 * time.Millisecond, false, true, false, 800 * time.Millisecond},
+		{"held by someone else", time.Hour, false, false, false, time.Second},
+		{"released after SIGKILL", 0, true, true, true, 1300 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeSystem(100)
+			f.exitDelay = 300 * time.Millisecond
+			f.portUntil = f.now.Add(tt.portHeldFor)
+			if tt.ignoreTerm {
+				f.ignore[100] = syscall.SIGTERM
+			}
+
+			result, err := f.killer().kill([]int{100}, KillOptions{Grace: time.Second, Escalate: true, Port: 3000})
+			if err != nil {
+				t.Fatalf("kill() error = %v", err)
+			}
+			if result.PortFreed != tt.wantFreed || result.Escalated != tt.wantEscalated {
+				t.Errorf("freed = %v, escalated = %v, want %v, %v", result.PortFreed, result.Escalated, tt.wantFreed, tt.wantEscalated)
+			}
+			if result.Elapsed != tt.wantElapsed {
+				t.Errorf("elapsed = %v, want %v", result.Elapsed, tt.wantElapsed)
+			}
+		})
+	}
+}
+
+func TestKill_RecordsSentSignals(t *testing.T) {
+	f := newFakeSystem(100, 101)
+	f.table[1].ppid = 100
+	f.ignore[100] = syscall.SIGTERM
+	f.ignore[101] = syscall.SIGTERM
+
+	result, err := f.killer().kill([]int{100}, KillOptions{Grace: 500 * time.Millisecond, Escalate: true, Scope: ScopeTree})
+	if err != nil {
+		t.Fatalf("kill() error = %v", err)
+	}
+	want := []SentSignal{
+		{100, syscall.SIGTERM, 0},
+		{101, syscall.SIGTERM, 0},
+		{100, syscall.SIGKILL, 500 * time.Millisecond},
+		{101, syscall.SIGKILL, 500 * time.Millisecond},
+	}
+	if fmt.Sprint(result.Sent) != fmt.Sprint(want) {
+		t.Errorf("sent = %v, want %v", result.Sent, want)
+	}
+}
+
+func TestProcState(t *testing.T) {
+	tests := []struct {
+		stat string
+		want string
+	}{
+		{"4242 (node) S 1 4242 4242 0 -1", "S"},
+		{"4243 (weird) name) Z 4242 4242", "Z"},
+		{"garbage", ""},
+		{"1 (init)", ""},
+	}
+	for _, tt := range tests {
+		if got := procState(tt.stat); got != tt.want {
+			t.Errorf("procState(%q) = %q, want %q", tt.stat, got, tt.want)
+		}
+	}
+}
+
+// helperEnv selects the behaviour of TestHelperProcess
+const helperEnv = "PORT_DIGGER_KILL_HELPER"
+
+// TestHelperProcess is not a real test: startHelper runs the test binary with
+// it to get a child that listens on a port and reacts to SIGTERM as told
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperEnv)
+	if mode == "" {
+		return
+	}
+
+	var listener net.Listener
+	var err error
+	if mode == "inherit" {
+		// The socket was passed down by the parent as fd 3
+		listener, err = net.FileListener(os.NewFile(3, "listener"))
+	} else {
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	terms := make(chan os.Signal, 1)
+	signal.Notify(terms, syscall.SIGTERM)
+	switch mode {
+	case "graceful":
+		// Shut down like a server draining connections
+		go func() {
+			<-terms
+			time.Sleep(200 * time.Millisecond)
+			listener.Close()
+			os.Exit(0)
+		}()
+	case "stubborn", "inherit":
+		signal.Ignore(syscall.SIGTERM)
+	case "parent":
+		// Hand the socket to a stubborn child, then exit on SIGTERM
+		file, err := listener.(*net.TCPListener).File()
+		if err != nil {
+			os.Exit(2)
+		}
+		child := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+		child.Env = append(os.Environ(), helperEnv+"=inherit")
+		child.ExtraFiles = []*os.File{file}
+		ready, err := child.StdoutPipe()
+		if err != nil {
+			os.Exit(2)
+		}
+		if err := child.Start(); err != nil {
+			os.Exit(2)
+		}
+		// Wait until the child ignores SIGTERM, which takes a while under -race
+		if !bufio.NewScanner(ready).Scan() {
+			os.Exit(2)
+		}
+		fmt.Printf("child %d\n", child.Process.Pid)
+		go func() {
+			<-terms
+			os.Exit(0)
+		}()
+	}
+
+	fmt.Println(listener.Addr().(*net.TCPAddr).Port)
+	for {
+		time.Sleep(time.Hour)
+	}
+}
+
+// helper is a running TestHelperProcess
+type helper struct {
+	cmd   *exec.Cmd
+	port  int
+	child int // PID of the socket-inheriting child in "parent" mode
+}
+
+// startHelper starts a helper in mode and waits until it listens. The helper
+// is not reaped until cleanup, so it stays a zombie after it exits
+func startHelper(t *testing.T, mode string) *helper {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("kill tests with real processes only run on Linux")
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=TestHelperProcess")
+	// A race-enabled helper sleeps atexit_sleep_ms (1s) before exiting, longer
+	// than the grace periods below
+	cmd.Env = append(os.Environ(), helperEnv+"="+mode, "GORACE=atexit_sleep_ms=0")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("failed to start helper: %v", err)
+	}
+	h := &helper{cmd: cmd}
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+		if h.child > 0 {
+			syscall.Kill(h.child, syscall.SIGKILL)
+		}
+	})
+
+	lines := bufio.NewScanner(stdout)
+	for lines.Scan() {
+		if _, err := fmt.Sscanf(lines.Text(), "child %d", &h.child); err == nil {
+			continue
+		}
+		if h.port, err = strconv.Atoi(lines.Text()); err != nil {
+			t.Fatalf("unexpected helper output %q", lines.Text())
+		}
+		return h
+	}
+	t.Fatalf("helper exited before listening: %v", lines.Err())
+	return nil
+}
+
+func TestKill_RealProcesses(t *testing.T) {
+	tests := []struct {
+		name          string
+		mode          string
+		opts          KillOptions
+		wantSignals   string
+		wantExited    bool
+		wantFreed     bool
+		wantEscalated bool
+	}{
+		{
+			name:        "exits on SIGTERM",
+			mode:        "graceful",
+			opts:        KillOptions{Grace: 5 * time.Second, Escalate: true},
+			wantSignals: "SIGTERM",
+			wantExited:  true,
+			wantFreed:   true,
+		},
+		{
+			name:          "ignores SIGTERM, escalated",
+			mode:          "stubborn",
+			opts:          KillOptions{Grace: 500 * time.Millisecond, Escalate: true},
+			wantSignals:   "SIGTERM SIGKILL",
+			wantExited:    true,
+			wantFreed:     true,
+			wantEscalated: true,
+		},
+		{
+			name:        "ignores SIGTERM, no escalation",
+			mode:        "stubborn",
+			opts:        KillOptions{Grace: 500 * time.Millisecond},
+			wantSignals: "SIGTERM",
+		},
+		{
+			// The parent exits but its child keeps the socket open
+			name:        "child keeps the port",
+			mode:        "parent",
+			opts:        KillOptions{Grace: 500 * time.Millisecond, Escalate: true},
+			wantSignals: "SIGTERM",
+			wantExited:  true,
+		},
+		{
+			name:          "tree scope frees the port",
+			mode:          "parent",
+			opts:          KillOptions{Grace: 500 * time.Millisecond, Escalate: true, Scope: ScopeTree},
+			wantSignals:   "SIGTERM SIGTERM SIGKILL",
+			wantExited:    true,
+			wantFreed:     true,
+			wantEscalated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := startHelper(t, tt.mode)
+			tt.opts.Port = h.port
+
+			result, err := Kill([]int{h.cmd.Process.Pid}, tt.opts)
+			if err != nil {
+				t.Fatalf("Kill() error = %v", err)
+			}
+
+			var signals []string
+			for _, s := range result.Sent {
+				signals = append(signals, SignalName(s.Signal))
+			}
+			if got := strings.Join(signals, " "); got != tt.wantSignals {
+				t.Errorf("signals = %q, want %q", got, tt.wantSignals)
+			}
+			if exited := result.Processes[0].Exited; exited != tt.wantExited {
+				t.Errorf("helper exited = %v, want %v", exited, tt.wantExited)
+			}
+			if result.PortFreed != tt.wantFreed || result.Escalated != tt.wantEscalated {
+				t.Errorf("freed = %v, escalated = %v, want %v, %v", result.PortFreed, result.Escalated, tt.wantFreed, tt.wantEscalated)
+			}
+			if result.Elapsed <= 0 || result.Elapsed > 10*time.Second {
+				t.Errorf("elapsed = %v", result.Elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package actions
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// procEntry is one row of the process table
+type procEntry struct {
+	pid  int
+	ppid int
+	pgid int
+	name string
+}
+
+// listProcesses reads the process table with one ps call
+func listProcesses() ([]procEntry, error) {
+	output, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,pgid=,comm=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseProcessTable(output), nil
+}
+
+// parseProcessTable parses "pid ppid pgid comm" lines, skipping malformed ones
+// macOS prints comm as a full path, which is shortened to the base name
+func parseProcessTable(output []byte) []procEntry {
+	var table []procEntry
+	lines := bufio.NewScanner(bytes.NewReader(output))
+	for lines.Scan() {
+		fields := strings.Fields(lines.Text())
+		if len(fields) < 4 {
+			continue
+		}
+		var ids [3]int
+		valid := true
+		for i := range ids {
+			id, err := strconv.Atoi(fields[i])
+			if err != nil {
+				valid = false
+				break
+			}
+			ids[i] = id
+		}
+		if !valid {
+			continue
+		}
+		name := strings.Join(fields[3:], " ")
+		table = append(table, procEntry{pid: ids[0], ppid: ids[1], pgid: ids[2], name: filepath.Base(name)})
+	}
+	return table
+}
+
+// descendants returns the children of root, their children and so on,
+// breadth first
+func descendants(table []procEntry, root int) []int {
+	children := make(map[int][]int)
+	for _, p := range table {
+		if p.pid != p.ppid {
+			children[p.ppid] = append(children[p.ppid], p.pid)
+		}
+	}
+
+	var result []int
+	seen := map[int]bool{root: true}
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		for _, child := range children[pid] {
+			if !seen[child] {
+				seen[child] = true
+				result = append(result, child)
+				queue = append(queue, child)
+			}
+		}
+	}
+	return result
+}
+
+// groupMembers returns the processes in the same process group as pid,
+// or nil if pid is not in the table
+func groupMembers(table []procEntry, pid int) []int {
+	pgid := -1
+	for _, p := range table {
+		if p.pid == pid {
+			pgid = p.pgid
+			break
+		}
+	}
+	if pgid <= 0 {
+		return nil
+	}
+
+	var members []int
+	for _, p := range table {
+		if p.pgid == pgid {
+			members = append(members, p.pid)
+		}
+	}
+	return members
+}
@@ -0,0 +1,70 @@
+package actions
+
+import (
+	"testing"
+)
+
+func TestParseProcessTable(t *testing.T) {
+	output := []byte(`    1     0     1 /sbin/launchd
+  812     1   812 /Applications/Postgres.app/Contents/Versions/16/bin/postgres
+ 4242   900  4242 node
+ bogus line
+ 4250  4242  4242 Google Chrome Helper
+`)
+	table := parseProcessTable(output)
+
+	want := []procEntry{
+		{1, 0, 1, "launchd"},
+		{812, 1, 812, "postgres"},
+		{4242, 900, 4242, "node"},
+		{4250, 4242, 4242, "Google Chrome Helper"},
+	}
+	if len(table) != len(want) {
+		t.Fatalf("parseProcessTable() = %+v, want %+v", table, want)
+	}
+	for i := range want {
+		if table[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, table[i], want[i])
+		}
+	}
+}
+
+func TestDescendants(t *testing.T) {
+	table := []procEntry{
+		{pid: 1, ppid: 0},
+		{pid: 10, ppid: 1},
+		{pid: 11, ppid: 10},
+		{pid: 12, ppid: 10},
+		{pid: 13, ppid: 11},
+		{pid: 20, ppid: 1},
+	}
+
+	tests := []struct {
+		root int
+		want string
+	}{
+		{10, "11, 12, 13"},
+		{11, "13"},
+		{20, ""},
+		{99, ""},
+	}
+	for _, tt := range tests {
+		if got := joinInts(descendants(table, tt.root)); got != tt.want {
+			t.Errorf("descendants(%d) = %q, want %q", tt.root, got, tt.want)
+		}
+	}
+}
+
+func TestGroupMembers(t *testing.T) {
+	table := []procEntry{
+		{pid: 10, pgid: 10},
+		{pid: 11, pgid: 10},
+		{pid: 12, pgid: 12},
+	}
+	if got := joinInts(groupMembers(table, 11)); got != "10, 11" {
+		t.Errorf("groupMembers(11) = %q, want \"10, 11\"", got)
+	}
+	if got := groupMembers(table, 99); got != nil {
+		t.Errorf("groupMembers(99) = %v, want nil", got)
+	}
+}
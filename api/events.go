@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// keepaliveInterval is how often an idle event stream gets a comment line,
+// so proxies and clients do not time it out
+const keepaliveInterval = 15 * time.Second
+
+// subscriberBuffer is how many events a slow client may lag behind before
+// events are dropped for it
+const subscriberBuffer = 64
+
+// Event is one listener change sent on /events
+type Event struct {
+	Kind     string `json:"kind"` // "open", "close" or "replace"
+	Port     Port   `json:"port"`
+	Previous *Port  `json:"previous,omitempty"` // Former owner for "replace"
+}
+
+// broker fans events out to the connected /events clients
+type broker struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+func newBroker() *broker {
+	return &broker{subscribers: make(map[chan Event]struct{})}
+}
+
+func (b *broker) subscribe() chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	ch := make(chan Event, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (b *broker) unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, ch)
+}
+
+// publish never blocks; a client whose buffer is full misses the event
+func (b *broker) publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// handleEvents streams listener changes as Server-Sent Events:
+//
+//	event: open
+//	data: {"kind":"open","port":{"port":3000,...}}
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming not supported")
+		return
+	}
+
+	events := s.events.subscribe()
+	defer s.events.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	// Tells the client the stream is live before the first change
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepalive := time.NewTicker(keepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Kind, data)
+		}
+		flusher.Flush()
+	}
+}
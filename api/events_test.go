@@ -0,0 +1,104 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"port-digger/actions"
+	"port-digger/monitor"
+	"port-digger/scanner"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvent returns the next "event:" and "data:" pair of an SSE stream,
+// skipping comments
+func readEvent(t *testing.T, lines *bufio.Scanner) (string, string) {
+	t.Helper()
+	var name, data string
+	for lines.Scan() {
+		line := lines.Text()
+		switch {
+		case strings.HasPrefix(line, "event: "):
+			name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		case line == "" && name != "":
+			return name, data
+		}
+	}
+	t.Fatalf("stream ended: %v", lines.Err())
+	return "", ""
+}
+
+func TestEvents(t *testing.T) {
+	var killed []actions.KillOptions
+	api := NewServer(testDeps(&killed), "")
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL+"/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	// Wait for the subscription before publishing
+	if !lines.Scan() || lines.Text() != ": connected" {
+		t.Fatalf("first line = %q, want the connected comment", lines.Text())
+	}
+
+	listeners := scanner.GroupListeners(testPorts)
+	node, postgres := listeners[0], listeners[1]
+	node.Command = "node vite"
+	ruby := node
+	ruby.PID, ruby.ProcessName, ruby.Command = 5000, "ruby", "ruby"
+
+	api.Publish(monitor.Diff{Added: []scanner.Listener{postgres}})
+	api.Publish(monitor.Diff{Removed: []scanner.Listener{node}, Added: []scanner.Listener{ruby}})
+
+	name, data := readEvent(t, lines)
+	var opened Event
+	if err := json.Unmarshal([]byte(data), &opened); err != nil {
+		t.Fatalf("invalid event data %q: %v", data, err)
+	}
+	if name != "open" || opened.Kind != "open" || opened.Port.Port != 5432 || opened.Previous != nil {
+		t.Errorf("first event = %s %+v", name, opened)
+	}
+
+	name, data = readEvent(t, lines)
+	var replaced Event
+	if err := json.Unmarshal([]byte(data), &replaced); err != nil {
+		t.Fatalf("invalid event data %q: %v", data, err)
+	}
+	if name != "replace" || replaced.Port.Process != "ruby" || replaced.Previous == nil || replaced.Previous.Service != "Vite" {
+		t.Errorf("second event = %s %+v", name, replaced)
+	}
+}
+
+func TestBroker_DropsForSlowSubscribers(t *testing.T) {
+	b := newBroker()
+	ch := b.subscribe()
+	for i := 0; i < subscriberBuffer+10; i++ {
+		b.publish(Event{Kind: "open"})
+	}
+	if len(ch) != subscriberBuffer {
+		t.Errorf("buffered = %d, want %d", len(ch), subscriberBuffer)
+	}
+
+	b.unsubscribe(ch)
+	b.publish(Event{Kind: "close"})
+	if len(ch) != subscriberBuffer {
+		t.Error("unsubscribed channel still receives events")
+	}
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"port-digger/actions"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestListenUnix(t *testing.T) {
+	// Without a umask the socket would be created world-writable
+	defer syscall.Umask(syscall.Umask(0))
+	path := filepath.Join(t.TempDir(), "api.sock")
+	listener, err := ListenUnix(path)
+	if err != nil {
+		t.Fatalf("ListenUnix() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket permissions = %o, want 600", perm)
+	}
+
+	// A second server must not steal a live socket
+	if _, err := ListenUnix(path); err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Errorf("second ListenUnix() error = %v, want already listening", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var killed []actions.KillOptions
+	done := make(chan error, 1)
+	go func() { done <- Serve(ctx, listener, NewServer(testDeps(&killed), "")) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/ports/5432")
+	if err != nil {
+		t.Fatalf("GET over the socket: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "postgres") {
+		t.Errorf("GET /ports/5432 = %d %s", resp.StatusCode, body)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v", err)
+	}
+}
+
+func TestListenUnix_StaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	// Leave a socket file behind without anyone listening, like after a crash
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := ListenUnix(path)
+	if err != nil {
+		t.Fatalf("ListenUnix() over a stale socket: %v", err)
+	}
+	listener.Close()
+}
+
+func TestListenUnix_NotASocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.sock")
+	if err := os.WriteFile(path, []byte("important"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListenUnix(path); err == nil {
+		t.Error("ListenUnix() replaced a regular file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "important" {
+		t.Error("regular file was modified")
+	}
+}
+
+func TestListenLoopback(t *testing.T) {
+	tests := []struct {
+		address string
+		wantErr bool
+	}{
+		{"127.0.0.1:0", false},
+		{"localhost:0", false},
+		{"0.0.0.0:0", true},
+		{":0", true},
+		{"192.168.1.10:0", true},
+		{"nonsense", true},
+	}
+
+	for _, tt := range tests {
+		listener, err := ListenLoopback(tt.address)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ListenLoopback(%q) error = %v, want error %v", tt.address, err, tt.wantErr)
+		}
+		if listener != nil {
+			listener.Close()
+		}
+	}
+}
+
+func TestGenerateToken(t *testing.T) {
+	a, err := GenerateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateToken()
+	if len(a) != 48 || a == b {
+		t.Errorf("tokens %q and %q, want two different 48 character tokens", a, b)
+	}
+}
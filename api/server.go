@@ -0,0 +1,357 @@
+// Package api serves live port data as JSON over a Unix socket or loopback
+// TCP for editor plugins, dashboards and scripts
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"port-digger/actions"
+	"port-digger/logger"
+	"port-digger/metrics"
+	"port-digger/monitor"
+	"port-digger/notify"
+	"port-digger/scanner"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Port is one listener as served by the API
+type Port struct {
+	Port      int      `json:"port"`
+	Protocol  string   `json:"protocol"`
+	PID       int      `json:"pid"`
+	Process   string   `json:"process"`
+	Service   string   `json:"service,omitempty"`
+	User      string   `json:"user,omitempty"`
+	Addresses []string `json:"addresses"`
+	Exposed   bool     `json:"exposed"`
+	Command   string   `json:"command"`
+}
+
+// Deps are the data sources and actions behind the API, replaced in tests
+type Deps struct {
+	Scan        func() ([]scanner.PortInfo, error)
+	Processes   func(pids []int) (map[int]scanner.ProcessInfo, error)
+	ServiceName func(command string) string // Cached LLM name, "" if unknown
+	Kill        func(pids []int, opts actions.KillOptions) (actions.KillResult, error)
+	// Metrics are served on GET /metrics in the Prometheus text format when set
+	Metrics []*metrics.Registry
+}
+
+// Server handles API requests
+type Server struct {
+	deps   Deps
+	token  string // Required as a bearer token when set
+	events *broker
+	mux    *http.ServeMux
+}
+
+// NewServer returns a Server; token is required from clients when not empty
+func NewServer(deps Deps, token string) *Server {
+	s := &Server{deps: deps, token: token, events: newBroker(), mux: http.NewServeMux()}
+	s.mux.HandleFunc("GET /ports", s.handlePorts)
+	s.mux.HandleFunc("GET /ports/{port}", s.handlePort)
+	s.mux.HandleFunc("POST /ports/{port}/kill", s.handleKill)
+	s.mux.HandleFunc("GET /events", s.handleEvents)
+	s.mux.HandleFunc("GET /names", s.handleNames)
+	if len(deps.Metrics) > 0 {
+		s.mux.Handle("GET /metrics", metrics.Handler(deps.Metrics...))
+	}
+	return s
+}
+
+// Publish sends the changes in diff to /events subscribers; call it from the
+// callback of a monitor.Poller
+func (s *Server) Publish(diff monitor.Diff) {
+	for _, e := range notify.Events(diff) {
+		event := Event{Kind: string(e.Kind), Port: s.portFromListener(e.Listener, scanner.ProcessInfo{})}
+		if e.Kind == notify.Replaced {
+			previous := s.portFromListener(e.Previous, scanner.ProcessInfo{})
+			event.Previous = &previous
+		}
+		s.events.publish(event)
+	}
+}
+
+// ServeHTTP checks the token and Host header, then dispatches the request
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token != "" {
+		if !loopbackHost(r.Host) {
+			// Defends against DNS rebinding from web pages
+			writeError(w, http.StatusForbidden, "host %q not allowed", r.Host)
+			return
+		}
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// authorized accepts "Authorization: Bearer <token>", or ?token= for
+// EventSource clients that cannot set headers
+func (s *Server) authorized(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// loopbackHost reports whether a Host header names this machine
+func loopbackHost(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(strings.Trim(host, "[]"))
+	return ip != nil && ip.IsLoopback()
+}
+
+// ports scans and joins the listeners with process details and service names
+func (s *Server) ports() ([]Port, error) {
+	ports, err := s.deps.Scan()
+	if err != nil {
+		return nil, err
+	}
+	listeners := scanner.GroupListeners(ports)
+
+	pids := make([]int, len(listeners))
+	for i, l := range listeners {
+		pids[i] = l.PID
+	}
+	processes, err := s.deps.Processes(pids)
+	if err != nil {
+		logger.Error("API process lookup failed: %v", err)
+	}
+
+	result := make([]Port, len(listeners))
+	for i, l := range listeners {
+		result[i] = s.portFromListener(l, processes[l.PID])
+	}
+	return result, nil
+}
+
+func (s *Server) portFromListener(l scanner.Listener, proc scanner.ProcessInfo) Port {
+	command := proc.Command
+	if command == "" {
+		command = l.Command
+	}
+	if command == "" {
+		command = l.ProcessName
+	}
+	service := s.deps.ServiceName(command)
+	if service == "未知" {
+		service = ""
+	}
+	return Port{
+		Port:      l.Port,
+		Protocol:  l.Protocol,
+		PID:       l.PID,
+		Process:   l.ProcessName,
+		Service:   service,
+		User:      proc.User,
+		Addresses: l.BindAddresses(),
+		Exposed:   l.Exposed(),
+		Command:   command,
+	}
+}
+
+// portsOn returns the listeners on the port in the request path
+func (s *Server) portsOn(w http.ResponseWriter, r *http.Request) ([]Port, int, bool) {
+	port, err := strconv.Atoi(r.PathValue("port"))
+	if err != nil || port < 1 || port > 65535 {
+		writeError(w, http.StatusBadRequest, "invalid port %q", r.PathValue("port"))
+		return nil, 0, false
+	}
+	all, err := s.ports()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "port scan failed: %v", err)
+		return nil, 0, false
+	}
+	var found []Port
+	for _, p := range all {
+		if p.Port == port {
+			found = append(found, p)
+		}
+	}
+	if len(found) == 0 {
+		writeError(w, http.StatusNotFound, "nothing is listening on port %d", port)
+		return nil, 0, false
+	}
+	return found, port, true
+}
+
+func (s *Server) handlePorts(w http.ResponseWriter, r *http.Request) {
+	ports, err := s.ports()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "port scan failed: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ports)
+}
+
+func (s *Server) handlePort(w http.ResponseWriter, r *http.Request) {
+	if found, _, ok := s.portsOn(w, r); ok {
+		writeJSON(w, http.StatusOK, found)
+	}
+}
+
+// KillRequest is the optional JSON body of POST /ports/{port}/kill
+// Privileged kills are never done through the API
+type KillRequest struct {
+	Signal   string `json:"signal"`   // TERM (default), INT, HUP or KILL
+	Grace    string `json:"grace"`    // Go duration, default 3s
+	Escalate *bool  `json:"escalate"` // Default true
+	Scope    string `json:"scope"`    // process (default), group or tree
+	Protocol string `json:"protocol"` // tcp (default) or udp
+	DryRun   bool   `json:"dry_run"`
+}
+
+// KillResponse reports the outcome of a kill
+type KillResponse struct {
+	Processes []KilledProcess `json:"processes"`
+	Escalated bool            `json:"escalated"`
+	ElapsedMS int64           `json:"elapsed_ms"`
+	PortFreed bool            `json:"port_freed"`
+	DryRun    bool            `json:"dry_run,omitempty"`
+}
+
+// KilledProcess is the outcome for one process
+type KilledProcess struct {
+	PID    int    `json:"pid"`
+	Name   string `json:"name,omitempty"`
+	Signal string `json:"signal,omitempty"` // Last signal sent
+	Exited bool   `json:"exited"`
+}
+
+// parseKillRequest turns a request body into kill options
+func parseKillRequest(r *http.Request) (actions.KillOptions, error) {
+	var req KillRequest
+	if r.ContentLength != 0 {
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&req); err != nil {
+			return actions.KillOptions{}, fmt.Errorf("invalid request body: %w", err)
+		}
+	}
+
+	opts := actions.KillOptions{Grace: actions.DefaultGrace, Escalate: true, DryRun: req.DryRun, Protocol: "TCP"}
+	switch strings.ToLower(req.Protocol) {
+	case "", "tcp":
+	case "udp":
+		opts.Protocol = "UDP"
+	default:
+		return opts, fmt.Errorf("unknown protocol %q: use tcp or udp", req.Protocol)
+	}
+	var err error
+	if req.Signal != "" {
+		if opts.Signal, err = actions.ParseSignal(req.Signal); err != nil {
+			return opts, err
+		}
+	}
+	if req.Grace != "" {
+		if opts.Grace, err = time.ParseDuration(req.Grace); err != nil || opts.Grace < 0 {
+			return opts, fmt.Errorf("invalid grace %q", req.Grace)
+		}
+	}
+	if req.Escalate != nil {
+		opts.Escalate = *req.Escalate
+	}
+	if req.Scope != "" {
+		if opts.Scope, err = actions.ParseScope(req.Scope); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func (s *Server) handleKill(w http.ResponseWriter, r *http.Request) {
+	opts, err := parseKillRequest(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "%v", err)
+		return
+	}
+	found, port, ok := s.portsOn(w, r)
+	if !ok {
+		return
+	}
+
+	var pids []int
+	for _, p := range found {
+		if p.Protocol == opts.Protocol {
+			pids = append(pids, p.PID)
+		}
+	}
+	if len(pids) == 0 {
+		writeError(w, http.StatusNotFound, "nothing is listening on %s port %d", opts.Protocol, port)
+		return
+	}
+	opts.Port = port
+	logger.Info("API kill request for port %d (PIDs %v, dry run %v)", port, pids, opts.DryRun)
+
+	result, err := s.deps.Kill(pids, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "%v", err)
+		return
+	}
+
+	response := KillResponse{
+		Processes: make([]KilledProcess, len(result.Processes)),
+		Escalated: result.Escalated,
+		ElapsedMS: result.Elapsed.Milliseconds(),
+		PortFreed: result.PortFreed,
+		DryRun:    opts.DryRun,
+	}
+	for i, p := range result.Processes {
+		response.Processes[i] = KilledProcess{PID: p.PID, Name: p.Name, Exited: p.Exited}
+		if p.Signal != 0 {
+			response.Processes[i].Signal = actions.SignalName(p.Signal)
+		}
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// handleNames looks up cached service names: GET /names?command=...&command=...
+func (s *Server) handleNames(w http.ResponseWriter, r *http.Request) {
+	commands := r.URL.Query()["command"]
+	if len(commands) == 0 {
+		writeError(w, http.StatusBadRequest, "missing command parameter")
+		return
+	}
+	names := make(map[string]string, len(commands))
+	for _, command := range commands {
+		name := s.deps.ServiceName(command)
+		if name == "未知" {
+			name = ""
+		}
+		names[command] = name
+	}
+	writeJSON(w, http.StatusOK, names)
+}
+
+// errorResponse is the body of every non-2xx response
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Debug("API response write failed: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, errorResponse{Error: fmt.Sprintf(format, args...)})
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"port-digger/actions"
+	"port-digger/metrics"
+	"port-digger/scanner"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+var testPorts = []scanner.PortInfo{
+	{Port: 3000, ProcessName: "node", PID: 4242, Protocol: "TCP", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4},
+	{Port: 3000, ProcessName: "node", PID: 4242, Protocol: "TCP6", BindAddress: "::", Family: scanner.FamilyIPv6},
+	{Port: 5432, ProcessName: "postgres", PID: 812, Protocol: "TCP", BindAddress: "127.0.0.1", Family: scanner.FamilyIPv4},
+}
+
+// testDeps serves testPorts and records kill requests in *killed
+func testDeps(killed *[]actions.KillOptions) Deps {
+	return Deps{
+		Scan: func() ([]scanner.PortInfo, error) { return testPorts, nil },
+		Processes: func([]int) (map[int]scanner.ProcessInfo, error) {
+			return map[int]scanner.ProcessInfo{
+				4242: {PID: 4242, User: "alice", Command: "node vite"},
+				812:  {PID: 812, User: "postgres", Command: "postgres -D /data"},
+			}, nil
+		},
+		ServiceName: func(command string) string {
+			return map[string]string{"node vite": "Vite", "postgres -D /data": "未知"}[command]
+		},
+		Kill: func(pids []int, opts actions.KillOptions) (actions.KillResult, error) {
+			*killed = append(*killed, opts)
+			result := actions.KillResult{Elapsed: 1500 * time.Millisecond, PortFreed: !opts.DryRun}
+			for _, pid := range pids {
+				p := actions.ProcessResult{PID: pid, Name: "node"}
+				if !opts.DryRun {
+					p.Signal, p.Exited = syscall.SIGTERM, true
+				}
+				result.Processes = append(result.Processes, p)
+			}
+			return result, nil
+		},
+	}
+}
+
+// do sends a request to handler and returns the status and body
+func do(t *testing.T, handler http.Handler, method, target, body string) (int, string) {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	req, err := http.NewRequest(method, server.URL+target, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestGetPorts(t *testing.T) {
+	var killed []actions.KillOptions
+	status, body := do(t, NewServer(testDeps(&killed), ""), "GET", "/ports", "")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body %s", status, body)
+	}
+
+	var ports []Port
+	if err := json.Unmarshal([]byte(body), &ports); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(ports) != 2 {
+		t.Fatalf("ports = %+v, want 2 listeners", ports)
+	}
+	node := ports[0]
+	if node.Port != 3000 || node.Service != "Vite" || node.User != "alice" || !node.Exposed || len(node.Addresses) != 2 {
+		t.Errorf("node = %+v", node)
+	}
+	if pg := ports[1]; pg.Service != "" || pg.Command != "postgres -D /data" {
+		t.Errorf("postgres = %+v, want no service for an unknown name", pg)
+	}
+}
+
+func TestGetPort(t *testing.T) {
+	tests := []struct {
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{"/ports/5432", http.StatusOK, `"process":"postgres"`},
+		{"/ports/8080", http.StatusNotFound, "nothing is listening on port 8080"},
+		{"/ports/http", http.StatusBadRequest, "invalid port"},
+		{"/ports/70000", http.StatusBadRequest, "invalid port"},
+	}
+
+	for _, tt := range tests {
+		var killed []actions.KillOptions
+		status, body := do(t, NewServer(testDeps(&killed), ""), "GET", tt.target, "")
+		if status != tt.wantStatus || !strings.Contains(body, tt.wantBody) {
+			t.Errorf("GET %s = %d %s, want %d containing %q", tt.target, status, body, tt.wantStatus, tt.wantBody)
+		}
+	}
+}
+
+func TestScanFailure(t *testing.T) {
+	var killed []actions.KillOptions
+	deps := testDeps(&killed)
+	deps.Scan = func() ([]scanner.PortInfo, error) { return nil, errors.New("lsof: not found") }
+
+	status, body := do(t, NewServer(deps, ""), "GET", "/ports", "")
+	if status != http.StatusInternalServerError || !strings.Contains(body, `"error":"port scan failed: lsof: not found"`) {
+		t.Errorf("status = %d, body = %s", status, body)
+	}
+}
+
+func TestKillPort(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantOpts   *actions.KillOptions
+		wantBody   string
+	}{
+		{
+			name:       "defaults",
+			method:     "POST",
+			target:     "/ports/3000/kill",
+			wantStatus: http.StatusOK,
+			wantOpts:   &actions.KillOptions{Grace: actions.DefaultGrace, Escalate: true, Port: 3000, Protocol: "TCP"},
+			wantBody:   `{"processes":[{"pid":4242,"name":"node","signal":"SIGTERM","exited":true}],"escalated":false,"elapsed_ms":1500,"port_freed":true}`,
+		},
+		{
+			name:       "options",
+			method:     "POST",
+			target:     "/ports/3000/kill",
+			body:       `{"signal":"INT","grace":"10s","escalate":false,"scope":"tree","dry_run":true}`,
+			wantStatus: http.StatusOK,
+			wantOpts:   &actions.KillOptions{Signal: syscall.SIGINT, Grace: 10 * time.Second, Scope: actions.ScopeTree, DryRun: true, Port: 3000, Protocol: "TCP"},
+			wantBody:   `"dry_run":true`,
+		},
+		{
+			name:       "privileged is not accepted",
+			method:     "POST",
+			target:     "/ports/3000/kill",
+			body:       `{"privileged":true}`,
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "unknown field",
+		},
+		{name: "bad signal", method: "POST", target: "/ports/3000/kill", body: `{"signal":"USR1"}`, wantStatus: http.StatusBadRequest},
+		{name: "bad grace", method: "POST", target: "/ports/3000/kill", body: `{"grace":"soon"}`, wantStatus: http.StatusBadRequest},
+		{name: "bad protocol", method: "POST", target: "/ports/3000/kill", body: `{"protocol":"sctp"}`, wantStatus: http.StatusBadRequest},
+		{name: "nothing listening", method: "POST", target: "/ports/8080/kill", wantStatus: http.StatusNotFound},
+		{name: "no udp socket", method: "POST", target: "/ports/3000/kill", body: `{"protocol":"udp"}`, wantStatus: http.StatusNotFound, wantBody: "UDP port 3000"},
+		{name: "GET is not allowed", method: "GET", target: "/ports/3000/kill", wantStatus: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var killed []actions.KillOptions
+			status, body := do(t, NewServer(testDeps(&killed), ""), tt.method, tt.target, tt.body)
+			if status != tt.wantStatus || !strings.Contains(body, tt.wantBody) {
+				t.Errorf("status = %d, body = %s, want %d containing %q", status, body, tt.wantStatus, tt.wantBody)
+			}
+			switch {
+			case tt.wantOpts == nil && len(killed) > 0:
+				t.Errorf("killed with %+v, want no kill", killed)
+			case tt.wantOpts != nil && (len(killed) != 1 || !sameOptions(killed[0], *tt.wantOpts)):
+				t.Errorf("kills = %+v, want %+v", killed, *tt.wantOpts)
+			}
+		})
+	}
+}
+
+// sameOptions compares the fields the API sets
+func sameOptions(a, b actions.KillOptions) bool {
+	return a.Signal == b.Signal && a.Grace == b.Grace && a.Escalate == b.Escalate &&
+		a.Scope == b.Scope && a.DryRun == b.DryRun && a.Port == b.Port && a.Protocol == b.Protocol &&
+		!a.Privileged && a.Elevator == nil
+}
+
+func TestGetNames(t *testing.T) {
+	var killed []actions.KillOptions
+	handler := NewServer(testDeps(&killed), "")
+
+	status, body := do(t, handler, "GET", "/names?command=node+vite&command=postgres+-D+/data&command=unknown", "")
+	if status != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", status, body)
+	}
+	var names map[string]string
+	if err := json.Unmarshal([]byte(body), &names); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := map[string]string{"node vite": "Vite", "postgres -D /data": "", "unknown": ""}
+	if len(names) != len(want) || names["node vite"] != "Vite" || names["postgres -D /data"] != "" {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+
+	if status, _ := do(t, handler, "GET", "/names", ""); status != http.StatusBadRequest {
+		t.Errorf("GET /names without command = %d, want 400", status)
+	}
+}
+
+func TestGetMetrics(t *testing.T) {
+	var killed []actions.KillOptions
+	if status, _ := do(t, NewServer(testDeps(&killed), ""), "GET", "/metrics", ""); status != http.StatusNotFound {
+		t.Errorf("GET /metrics without registries = %d, want 404", status)
+	}
+
+	registry := metrics.NewRegistry()
+	registry.NewCounter("test_requests_total", "Requests.").Inc()
+	deps := testDeps(&killed)
+	deps.Metrics = []*metrics.Registry{registry}
+	status, body := do(t, NewServer(deps, ""), "GET", "/metrics", "")
+	if status != http.StatusOK || !strings.Contains(body, "test_requests_total 1\n") {
+		t.Errorf("GET /metrics = %d:\n%s", status, body)
+	}
+}
+
+func TestToken(t *testing.T) {
+	var killed []actions.KillOptions
+	server := httptest.NewServer(NewServer(testDeps(&killed), "s3cret"))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		target     string
+		header     string
+		host       string
+		wantStatus int
+	}{
+		{"bearer", "/ports", "Bearer s3cret", "", http.StatusOK},
+		{"query", "/ports?token=s3cret", "", "", http.StatusOK},
+		{"missing", "/ports", "", "", http.StatusUnauthorized},
+		{"wrong", "/ports", "Bearer guess", "", http.StatusUnauthorized},
+		{"localhost host", "/ports", "Bearer s3cret", "localhost:7777", http.StatusOK},
+		{"rebound host", "/ports", "Bearer s3cret", "evil.example:7777", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", server.URL+tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if tt.host != "" {
+				req.Host = tt.host
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
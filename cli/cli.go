@@ -0,0 +1,199 @@
+// Package cli implements the headless subcommands of port-digger, which run
+// without a display or the system tray
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"port-digger/actions"
+	"port-digger/freeport"
+	"port-digger/llm"
+	"port-digger/scanner"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Exit codes shared by all subcommands
+const (
+	ExitOK    = 0
+	ExitError = 1 // Scan or I/O failure
+	ExitUsage = 2 // Bad arguments
+
+	ExitNotFound = 3 // who, kill: nothing listens on the port; free: no free port
+	ExitTimeout  = 4 // wait: the port did not reach the state in time
+)
+
+// Env holds the dependencies of a subcommand, replaced in tests
+type Env struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	SetBackend  func(name string) error // "" means the config.yaml preference
+	Scan        func() ([]scanner.PortInfo, error)
+	Processes   func(pids []int) (map[int]scanner.ProcessInfo, error)
+	ServiceName func(command string) string // Cached LLM name, "" if unknown
+	FullCommand func(pid int) string
+
+	Kill         func(pids []int, opts actions.KillOptions) (actions.KillResult, error)
+	Bind         func(protocol string, port int) error
+	Reservations func() (freeport.Reservations, error)
+
+	Now   func() time.Time
+	Sleep func(d time.Duration)
+	// Context is cancelled when a long-running command like serve should stop
+	Context func() (context.Context, context.CancelFunc)
+}
+
+// defaultEnv uses the real scanner and the LLM name cache, without network calls
+func defaultEnv() *Env {
+	env := &Env{
+		Stdin:       os.Stdin,
+		Stdout:      os.Stdout,
+		Stderr:      os.Stderr,
+		SetBackend:  setBackend,
+		Scan:        scanner.ScanPorts,
+		Processes:   scanner.LookupProcesses,
+		ServiceName: func(string) string { return "" },
+		FullCommand: scanner.GetFullCommand,
+
+		Kill:         actions.Kill,
+		Bind:         freeport.TryBind,
+		Reservations: freeport.LoadReservations,
+
+		Now:   time.Now,
+		Sleep: time.Sleep,
+		Context: func() (context.Context, context.CancelFunc) {
+			return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		},
+	}
+	if names, err := llm.CachedNames(); err == nil {
+		env.ServiceName = names
+	}
+	return env
+}
+
+// command is one subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(env *Env, args []string) int
+}
+
+// commands lists the subcommands in help order
+var commands []command
+
+func init() {
+	commands = []command{
+		{"list", "List listening ports", runList},
+		{"who", "Show the process holding a port", runWho},
+		{"wait", "Wait until a port is listening or free", runWait},
+		{"free", "Print a port nothing listens on", runFree},
+		{"kill", "Stop the processes holding a port", runKill},
+		{"tui", "Browse and manage ports in the terminal", runTUI},
+		{"serve", "Serve the local HTTP API", runServe},
+		{"exporter", "Serve Prometheus metrics", runExporter},
+		{"help", "Show this help", runHelp},
+	}
+}
+
+// IsCommand reports whether name is a subcommand rather than the tray app's flags
+func IsCommand(name string) bool {
+	for _, c := range commands {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes the subcommand args[0] and returns the process exit code
+func Run(args []string) int {
+	return run(defaultEnv(), args)
+}
+
+func run(env *Env, args []string) int {
+	if len(args) == 0 {
+		return runHelp(env, nil)
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(env, args[1:])
+		}
+	}
+	fmt.Fprintf(env.Stderr, "Unknown command %q\n", args[0])
+	runHelp(env, nil)
+	return ExitUsage
+}
+
+func runHelp(env *Env, _ []string) int {
+	fmt.Fprintln(env.Stderr, "Usage: port-digger [command] [flags]")
+	fmt.Fprintln(env.Stderr, "\nWithout a command, the menu bar app is started.\n\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(env.Stderr, "  %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(env.Stderr, "\nRun 'port-digger <command> -h' for the flags of a command.")
+	return ExitOK
+}
+
+// setBackend forces the scanner backend, falling back to the config.yaml preference
+func setBackend(name string) error {
+	if name == "" {
+		config, err := llm.LoadConfig()
+		if err != nil {
+			return nil // Auto-detection still works
+		}
+		name = config.Scanner.Backend
+	}
+	return scanner.SetBackend(name)
+}
+
+// backendUsage documents the -backend flag
+func backendUsage() string {
+	return "Force a scanner backend: " + strings.Join(append([]string{scanner.AutoBackend}, scanner.BackendNames()...), ", ")
+}
+
+// parseArgs parses flags that may appear before or after positional arguments,
+// e.g. "wait 3000 --timeout 10s", and returns the positional ones
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// parsePort parses a port argument in the range 1-65535
+func parsePort(arg string) (int, error) {
+	port, err := strconv.Atoi(arg)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", arg)
+	}
+	return port, nil
+}
+
+// portArg parses the single port argument of who and wait, reporting usage errors
+func portArg(env *Env, name string, positional []string) (int, bool) {
+	if len(positional) != 1 {
+		fmt.Fprintf(env.Stderr, "Usage: port-digger %s <port> [flags]\n", name)
+		return 0, false
+	}
+	port, err := parsePort(positional[0])
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return 0, false
+	}
+	return port, true
+}
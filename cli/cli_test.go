@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"port-digger/actions"
+	"port-digger/freeport"
+	"port-digger/scanner"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPorts are the sockets returned by the fake scanner
+var testPorts = []scanner.PortInfo{
+	{Port: 5432, ProcessName: "postgres", PID: 812, Protocol: "TCP", BindAddress: "127.0.0.1", Family: scanner.FamilyIPv4},
+	{Port: 3000, ProcessName: "node", PID: 4242, Protocol: "TCP", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4},
+	{Port: 3000, ProcessName: "node", PID: 4242, Protocol: "TCP6", BindAddress: "::", Family: scanner.FamilyIPv6},
+	{Port: 5353, ProcessName: "mDNSResponder", PID: 312, Protocol: "UDP", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4},
+}
+
+// testProcesses are the process details returned by the fake lookup
+var testProcesses = map[int]scanner.ProcessInfo{
+	812:  {PID: 812, User: "postgres", Command: "/usr/lib/postgresql/16/bin/postgres -D /var/lib/postgresql"},
+	4242: {PID: 4242, User: "alice", Command: "node /home/alice/app/node_modules/.bin/vite"},
+	312:  {PID: 312, User: "_mdnsresponder", Command: "/usr/sbin/mDNSResponder"},
+}
+
+// testNow is the fixed time seen by commands under test
+var testNow = time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+
+// newTestEnv returns an Env backed by testPorts and testProcesses
+func newTestEnv() (*Env, *bytes.Buffer, *bytes.Buffer) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	names := map[string]string{
+		"node /home/alice/app/node_modules/.bin/vite": "Vite",
+		"/usr/sbin/mDNSResponder":                     "未知",
+	}
+	env := &Env{
+		Stdin:       strings.NewReader(""),
+		Stdout:      stdout,
+		Stderr:      stderr,
+		SetBackend:  func(string) error { return nil },
+		Scan:        func() ([]scanner.PortInfo, error) { return testPorts, nil },
+		Processes:   func([]int) (map[int]scanner.ProcessInfo, error) { return testProcesses, nil },
+		ServiceName: func(command string) string { return names[command] },
+		FullCommand: func(int) string { return "" },
+
+		Kill: func(pids []int, opts actions.KillOptions) (actions.KillResult, error) {
+			return actions.KillResult{}, errors.New("unexpected kill")
+		},
+		Bind:         func(string, int) error { return nil },
+		Reservations: func() (freeport.Reservations, error) { return nil, nil },
+
+		Now:   func() time.Time { return testNow },
+		Sleep: func(time.Duration) {},
+		Context: func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(context.Background())
+		},
+	}
+	return env, stdout, stderr
+}
+
+func TestRun_Dispatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"no command shows help", nil, ExitOK, "Commands:"},
+		{"help", []string{"help"}, ExitOK, "list"},
+		{"unknown command", []string{"frobnicate"}, ExitUsage, `Unknown command "frobnicate"`},
+		{"bad flag", []string{"list", "--nope"}, ExitUsage, "flag provided but not defined"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _, stderr := newTestEnv()
+			if code := run(env, tt.args); code != tt.wantCode {
+				t.Errorf("run(%v) = %d, want %d", tt.args, code, tt.wantCode)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIsCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"list", true},
+		{"help", true},
+		{"who", true},
+		{"wait", true},
+		{"free", true},
+		{"kill", true},
+		{"-backend", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCommand(tt.name); got != tt.want {
+			t.Errorf("IsCommand(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRun_BackendError(t *testing.T) {
+	env, _, stderr := newTestEnv()
+	env.SetBackend = func(name string) error { return errors.New("unknown scanner backend " + name) }
+
+	if code := run(env, []string{"list", "--backend", "bogus"}); code != ExitUsage {
+		t.Errorf("run() = %d, want %d", code, ExitUsage)
+	}
+	if !strings.Contains(stderr.String(), "unknown scanner backend bogus") {
+		t.Errorf("stderr = %q, want the backend error", stderr.String())
+	}
+}
+
+func TestParseArgs_FlagsAfterPositional(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 0, "")
+	free := fs.Bool("free", false, "")
+
+	positional, err := parseArgs(fs, []string{"--free", "3000", "--timeout", "5s", "extra"})
+	if err != nil {
+		t.Fatalf("parseArgs() error = %v", err)
+	}
+	if strings.Join(positional, " ") != "3000 extra" || *timeout != 5*time.Second || !*free {
+		t.Errorf("positional = %v, timeout = %v, free = %v", positional, *timeout, *free)
+	}
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    int
+		wantErr bool
+	}{
+		{"3000", 3000, false},
+		{"65535", 65535, false},
+		{"0", 0, true},
+		{"65536", 0, true},
+		{"http", 0, true},
+		{"-1", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePort(tt.arg)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePort(%q) = %d, %v, want %d, error %v", tt.arg, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
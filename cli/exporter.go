@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"port-digger/api"
+	"port-digger/logger"
+	"port-digger/metrics"
+	"strconv"
+	"strings"
+)
+
+func runExporter(env *Env, args []string) int {
+	fs := flag.NewFlagSet("exporter", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	listen := fs.String("listen", "127.0.0.1:9464", "Address to serve /metrics on; use :9464 for remote Prometheus servers")
+	backend := fs.String("backend", "", backendUsage())
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(env.Stderr, "Unexpected argument %q\n", positional[0])
+		return ExitUsage
+	}
+	if err := env.SetBackend(*backend); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+	fmt.Fprintf(env.Stderr, "Serving metrics on http://%s/metrics\n", listener.Addr())
+
+	ctx, cancel := env.Context()
+	defer cancel()
+	if err := api.Serve(ctx, listener, exporterHandler(env)); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// exporterHandler serves the process-wide metrics plus the listening sockets,
+// which are scanned on every scrape
+func exporterHandler(env *Env) http.Handler {
+	listening := metrics.NewRegistry()
+	listening.NewGaugeFunc("port_digger_listening",
+		"Sockets listening per port, process and bind address.",
+		func() []metrics.Sample { return listeningSamples(env) },
+		"port", "protocol", "process", "service", "family", "bind")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler(metrics.Default, listening))
+	return mux
+}
+
+// listeningSamples counts the scanned sockets by their label values
+func listeningSamples(env *Env) []metrics.Sample {
+	ports, err := env.Scan()
+	if err != nil {
+		// Recorded by port_digger_scan_errors_total
+		logger.Error("Metrics scan failed: %v", err)
+		return nil
+	}
+
+	pids := make([]int, len(ports))
+	for i, p := range ports {
+		pids[i] = p.PID
+	}
+	processes, err := env.Processes(pids)
+	if err != nil {
+		logger.Error("Metrics process lookup failed: %v", err)
+	}
+
+	counts := make(map[string]*metrics.Sample)
+	var samples []*metrics.Sample
+	for _, p := range ports {
+		command := processes[p.PID].Command
+		if command == "" {
+			command = p.ProcessName
+		}
+		service := env.ServiceName(command)
+		if service == "未知" {
+			service = ""
+		}
+		protocol := "tcp"
+		if p.IsUDP() {
+			protocol = "udp"
+		}
+		labels := []string{strconv.Itoa(p.Port), protocol, p.ProcessName, service, p.Family, p.BindAddress}
+
+		key := strings.Join(labels, "\x00")
+		if s, ok := counts[key]; ok {
+			s.Value++
+			continue
+		}
+		s := &metrics.Sample{LabelValues: labels, Value: 1}
+		counts[key] = s
+		samples = append(samples, s)
+	}
+
+	result := make([]metrics.Sample, len(samples))
+	for i, s := range samples {
+		result[i] = *s
+	}
+	return result
+}
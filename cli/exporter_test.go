@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"errors"
+	"net/http/httptest"
+	"port-digger/scanner"
+	"strings"
+	"testing"
+)
+
+func TestExporter_Metrics(t *testing.T) {
+	env, _, _ := newTestEnv()
+	// SO_REUSEPORT workers share one series
+	env.Scan = func() ([]scanner.PortInfo, error) { return append(testPorts, testPorts[0]), nil }
+
+	rec := httptest.NewRecorder()
+	exporterHandler(env).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`port_digger_listening{port="3000",protocol="tcp",process="node",service="Vite",family="IPv4",bind="0.0.0.0"} 1`,
+		`port_digger_listening{port="3000",protocol="tcp",process="node",service="Vite",family="IPv6",bind="::"} 1`,
+		`port_digger_listening{port="5353",protocol="udp",process="mDNSResponder",service="",family="IPv4",bind="0.0.0.0"} 1`,
+		`port_digger_listening{port="5432",protocol="tcp",process="postgres",service="",family="IPv4",bind="127.0.0.1"} 2`,
+		"# TYPE port_digger_scan_duration_seconds histogram",
+	} {
+		if !strings.Contains(body, want+"\n") {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+	// The exporter never calls the LLM, so its LLM metrics would always be zero
+	if strings.Contains(body, "port_digger_llm_") {
+		t.Errorf("exporter serves LLM metrics:\n%s", body)
+	}
+}
+
+func TestExporter_ScanFailure(t *testing.T) {
+	env, _, _ := newTestEnv()
+	env.Scan = func() ([]scanner.PortInfo, error) { return nil, errors.New("boom") }
+
+	rec := httptest.NewRecorder()
+	exporterHandler(env).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 || strings.Contains(rec.Body.String(), "port_digger_listening{") {
+		t.Errorf("status = %d, body =\n%s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestExporter_Usage(t *testing.T) {
+	env, _, stderr := newTestEnv()
+	if code := runExporter(env, []string{"extra"}); code != ExitUsage {
+		t.Errorf("exit code = %d, want %d", code, ExitUsage)
+	}
+	if code := runExporter(env, []string{"--listen", "not an address"}); code != ExitError {
+		t.Errorf("exit code = %d, want %d; stderr %q", code, ExitError, stderr.String())
+	}
+}
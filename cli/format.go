@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+)
+
+// writers maps --format values to their writers
+var writers = map[string]func(w io.Writer, entries []entry) error{
+	"table": writeTable,
+	"json":  writeJSON,
+	"csv":   writeCSV,
+	"yaml":  writeYAML,
+}
+
+// orDash shows empty table cells as "-"
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// writeTable prints aligned columns for people
+func writeTable(w io.Writer, entries []entry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PORT\tPROTO\tPID\tUSER\tPROCESS\tSERVICE\tADDRESS")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\t%s\n",
+			e.Port, e.Protocol, e.PID, orDash(e.User), e.Process, orDash(e.Service), strings.Join(e.Addresses, ", "))
+	}
+	return tw.Flush()
+}
+
+func writeJSON(w io.Writer, entries []entry) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(entries)
+}
+
+// writeCSV prints one row per entry; multiple addresses are separated by ";"
+func writeCSV(w io.Writer, entries []entry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"port", "protocol", "pid", "process", "service", "user", "addresses", "exposed", "command"})
+	for _, e := range entries {
+		cw.Write([]string{
+			strconv.Itoa(e.Port),
+			e.Protocol,
+			strconv.Itoa(e.PID),
+			e.Process,
+			e.Service,
+			e.User,
+			strings.Join(e.Addresses, ";"),
+			strconv.FormatBool(e.Exposed),
+			e.Command,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeYAML(w io.Writer, entries []entry) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(entries); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
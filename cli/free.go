@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"port-digger/freeport"
+)
+
+func runFree(env *Env, args []string) int {
+	fs := flag.NewFlagSet("free", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	rangeText := fs.String("range", freeport.DefaultRange.String(), "Ports to search, e.g. 3000-3999")
+	project := fs.String("project", "", "Allow ports reserved for this project in reservations.yaml")
+	protocolName := fs.String("protocol", "tcp", "Socket type: tcp or udp")
+	export := fs.Bool("export", false, "Print NAME=PORT for use with export or env")
+	name := fs.String("name", "PORT", "Variable name printed with --export")
+	backend := fs.String("backend", "", backendUsage())
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(env.Stderr, "Unexpected argument %q\n", positional[0])
+		return ExitUsage
+	}
+	portRange, err := freeport.ParseRange(*rangeText)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+	protocol, err := protocolFlag(*protocolName)
+	if err != nil || protocol == "" {
+		fmt.Fprintf(env.Stderr, "Error: unknown protocol %q: use tcp or udp\n", *protocolName)
+		return ExitUsage
+	}
+	if err := env.SetBackend(*backend); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+
+	reservations, err := env.Reservations()
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+
+	finder := &freeport.Finder{Scan: env.Scan, Bind: env.Bind}
+	port, err := finder.Find(freeport.Options{
+		Range:        portRange,
+		Protocol:     protocol,
+		Project:      *project,
+		Reservations: reservations,
+	})
+	if errors.Is(err, freeport.ErrNoFreePort) {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitNotFound
+	}
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+
+	if *export {
+		fmt.Fprintf(env.Stdout, "%s=%d\n", *name, port)
+	} else {
+		fmt.Fprintln(env.Stdout, port)
+	}
+	return ExitOK
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"port-digger/freeport"
+	"port-digger/scanner"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestRunFree(t *testing.T) {
+	scanned := []scanner.PortInfo{
+		{Port: 3000, ProcessName: "node", PID: 4242, Protocol: "TCP", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4},
+		{Port: 3001, ProcessName: "ruby", PID: 5000, Protocol: "TCP", BindAddress: "127.0.0.1", Family: scanner.FamilyIPv4},
+	}
+	reservations := freeport.Reservations{"billing-api": {{Low: 3002, High: 3004}}}
+
+	tests := []struct {
+		name       string
+		args       []string
+		bound      int // Port the bind test refuses
+		wantCode   int
+		wantOutput string
+	}{
+		{"default range", nil, 0, ExitOK, "3005\n"},
+		{"export", []string{"--export"}, 0, ExitOK, "PORT=3005\n"},
+		{"export with name", []string{"--export", "--name", "API_PORT"}, 0, ExitOK, "API_PORT=3005\n"},
+		{"own project", []string{"--project", "billing-api"}, 0, ExitOK, "3002\n"},
+		{"bind test", []string{"--range", "3005-3010"}, 3005, ExitOK, "3006\n"},
+		{"udp ignores tcp listeners", []string{"--protocol", "udp"}, 0, ExitOK, "3000\n"},
+		{"range exhausted", []string{"--range", "3000-3004"}, 0, ExitNotFound, ""},
+		{"bad range", []string{"--range", "4000-3000"}, 0, ExitUsage, ""},
+		{"bad protocol", []string{"--protocol", "sctp"}, 0, ExitUsage, ""},
+		{"extra argument", []string{"3000"}, 0, ExitUsage, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, stdout, _ := newTestEnv()
+			env.Scan = func() ([]scanner.PortInfo, error) { return scanned, nil }
+			env.Reservations = func() (freeport.Reservations, error) { return reservations, nil }
+			env.Bind = func(protocol string, port int) error {
+				if port == tt.bound {
+					return syscall.EADDRINUSE
+				}
+				return nil
+			}
+
+			if code := runFree(env, tt.args); code != tt.wantCode {
+				t.Errorf("runFree(%v) = %d, want %d", tt.args, code, tt.wantCode)
+			}
+			if stdout.String() != tt.wantOutput {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunFree_ReservationError(t *testing.T) {
+	env, _, stderr := newTestEnv()
+	env.Reservations = func() (freeport.Reservations, error) { return nil, errors.New("invalid port range") }
+
+	if code := runFree(env, nil); code != ExitError {
+		t.Errorf("runFree() = %d, want %d", code, ExitError)
+	}
+	if !strings.Contains(stderr.String(), "invalid port range") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
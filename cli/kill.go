@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"port-digger/actions"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func runKill(env *Env, args []string) int {
+	fs := flag.NewFlagSet("kill", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	pid := fs.Int("pid", 0, "Kill this PID instead of the owners of a port")
+	signalName := fs.String("signal", "TERM", "First signal to send: TERM, INT, HUP or KILL")
+	grace := fs.Duration("grace", actions.DefaultGrace, "How long to wait for the processes to exit")
+	escalate := fs.Bool("escalate", true, "Send SIGKILL to processes still running after the grace period")
+	group := fs.Bool("group", false, "Also kill the rest of each process group")
+	tree := fs.Bool("tree", false, "Also kill all child processes")
+	dryRun := fs.Bool("dry-run", false, "Only show which processes would be signalled")
+	protocolName := fs.String("protocol", "tcp", "Socket type of the port: tcp or udp")
+	sudo := fs.Bool("sudo", false, "Use administrator rights when permission is denied")
+	sudoKill := fs.Bool("sudo-kill", false, "With --sudo, send SIGKILL right away to processes that need administrator rights, so the password is asked once")
+	yes := fs.Bool("yes", false, "Do not ask before using administrator rights")
+	backend := fs.String("backend", "", backendUsage())
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+
+	var port int
+	switch {
+	case *pid != 0 && len(positional) == 0:
+	case *pid == 0 && len(positional) == 1:
+		if port, err = parsePort(positional[0]); err != nil {
+			fmt.Fprintln(env.Stderr, "Error:", err)
+			return ExitUsage
+		}
+	default:
+		fmt.Fprintln(env.Stderr, "Usage: port-digger kill <port> [flags] or port-digger kill --pid <pid> [flags]")
+		return ExitUsage
+	}
+
+	if *sudoKill && !*sudo {
+		fmt.Fprintln(env.Stderr, "Error: --sudo-kill requires --sudo")
+		return ExitUsage
+	}
+	opts := actions.KillOptions{Grace: *grace, Escalate: *escalate, DryRun: *dryRun, Privileged: *sudo, KillPrivileged: *sudoKill}
+	if *sudo && !*yes {
+		opts.Confirm = confirmPrompt(env)
+	}
+	if opts.Signal, err = actions.ParseSignal(*signalName); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+	switch {
+	case *group && *tree:
+		fmt.Fprintln(env.Stderr, "Error: --group and --tree are mutually exclusive")
+		return ExitUsage
+	case *group:
+		opts.Scope = actions.ScopeGroup
+	case *tree:
+		opts.Scope = actions.ScopeTree
+	}
+
+	pids := []int{*pid}
+	if port != 0 {
+		protocol, err := protocolFlag(*protocolName)
+		if err != nil || protocol == "" {
+			fmt.Fprintf(env.Stderr, "Error: unknown protocol %q: use tcp or udp\n", *protocolName)
+			return ExitUsage
+		}
+		if err := env.SetBackend(*backend); err != nil {
+			fmt.Fprintln(env.Stderr, "Error:", err)
+			return ExitUsage
+		}
+		ports, err := env.Scan()
+		if err != nil {
+			fmt.Fprintln(env.Stderr, "Error: port scan failed:", err)
+			return ExitError
+		}
+		if pids = actions.PIDsOnPort(ports, port, protocol); len(pids) == 0 {
+			fmt.Fprintf(env.Stderr, "Nothing is listening on %s port %d\n", protocol, port)
+			return ExitNotFound
+		}
+		opts.Port, opts.Protocol = port, protocol
+	}
+
+	result, err := env.Kill(pids, opts)
+	reportKill(env, result, opts)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+	if !opts.DryRun && (len(result.Survivors()) > 0 || (opts.Port != 0 && !result.PortFreed)) {
+		return ExitError
+	}
+	return ExitOK
+}
+
+// reportKill prints one line per process, e.g. "PID 4242 (node): exited after SIGTERM",
+// then whether the port was released
+func reportKill(env *Env, result actions.KillResult, opts actions.KillOptions) {
+	for _, p := range result.Processes {
+		label := "PID " + strconv.Itoa(p.PID)
+		if p.Name != "" {
+			label += " (" + p.Name + ")"
+		}
+		switch {
+		case opts.DryRun:
+			fmt.Fprintf(env.Stdout, "Would send %s to %s\n", actions.SignalName(opts.Signal), label)
+		case p.Exited && p.Signal == 0:
+			fmt.Fprintf(env.Stdout, "%s: already gone\n", label)
+		case p.Exited:
+			fmt.Fprintf(env.Stdout, "%s: exited after %s\n", label, actions.SignalName(p.Signal))
+		case p.Signal != 0:
+			fmt.Fprintf(env.Stdout, "%s: still running after %s\n", label, actions.SignalName(p.Signal))
+		}
+	}
+	if opts.DryRun || opts.Port == 0 || len(result.Sent) == 0 {
+		return
+	}
+
+	elapsed := result.Elapsed.Round(10 * time.Millisecond)
+	if result.PortFreed {
+		fmt.Fprintf(env.Stdout, "Port %d is free after %s\n", opts.Port, elapsed)
+	} else {
+		fmt.Fprintf(env.Stdout, "Port %d is still in use after %s\n", opts.Port, elapsed)
+	}
+}
+
+// confirmPrompt asks on the terminal before signalling processes as root
+func confirmPrompt(env *Env) actions.ConfirmFunc {
+	input := bufio.NewReader(env.Stdin)
+	return func(sig syscall.Signal, pids []int) bool {
+		fmt.Fprintf(env.Stderr, "Send %s to PID %s as root? [y/N] ", actions.SignalName(sig), joinPIDs(pids))
+		answer, _ := input.ReadString('\n')
+		answer = strings.ToLower(strings.TrimSpace(answer))
+		return answer == "y" || answer == "yes"
+	}
+}
+
+// joinPIDs returns "812, 813"
+func joinPIDs(pids []int) string {
+	parts := make([]string, len(pids))
+	for i, pid := range pids {
+		parts[i] = strconv.Itoa(pid)
+	}
+	return strings.Join(parts, ", ")
+}
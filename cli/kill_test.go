@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"port-digger/actions"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunKill(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		result      actions.KillResult
+		killErr     error
+		wantPIDs    string
+		wantOpts    actions.KillOptions
+		wantConfirm bool
+		wantCode    int
+		wantOutput  string
+	}{
+		{
+			name: "port",
+			args: []string{"3000"},
+			result: actions.KillResult{
+				Processes: []actions.ProcessResult{{PID: 4242, Name: "node", Signal: syscall.SIGTERM, Exited: true}},
+				Sent:      []actions.SentSignal{{PID: 4242, Signal: syscall.SIGTERM}},
+				Elapsed:   1234 * time.Millisecond,
+				PortFreed: true,
+			},
+			wantPIDs:   "[4242]",
+			wantOpts:   actions.KillOptions{Signal: syscall.SIGTERM, Grace: actions.DefaultGrace, Escalate: true, Port: 3000, Protocol: "TCP"},
+			wantCode:   ExitOK,
+			wantOutput: "PID 4242 (node): exited after SIGTERM\nPort 3000 is free after 1.23s\n",
+		},
+		{
+			name: "port still in use",
+			args: []string{"3000"},
+			result: actions.KillResult{
+				Processes: []actions.ProcessResult{{PID: 4242, Name: "node", Signal: syscall.SIGTERM, Exited: true}},
+				Sent:      []actions.SentSignal{{PID: 4242, Signal: syscall.SIGTERM}},
+				Elapsed:   3 * time.Second,
+			},
+			wantPIDs:   "[4242]",
+			wantOpts:   actions.KillOptions{Signal: syscall.SIGTERM, Grace: actions.DefaultGrace, Escalate: true, Port: 3000, Protocol: "TCP"},
+			wantCode:   ExitError,
+			wantOutput: "PID 4242 (node): exited after SIGTERM\nPort 3000 is still in use after 3s\n",
+		},
+		{
+			name: "pid with options",
+			args: []string{"--pid", "812", "--signal", "int", "--grace", "10s", "--escalate=false", "--tree", "--sudo"},
+			result: actions.KillResult{Processes: []actions.ProcessResult{
+				{PID: 812, Name: "postgres", Signal: syscall.SIGINT, Exited: true},
+				{PID: 813, Signal: syscall.SIGINT},
+				{PID: 814, Name: "postgres", Exited: true},
+			}},
+			wantPIDs:    "[812]",
+			wantOpts:    actions.KillOptions{Signal: syscall.SIGINT, Grace: 10 * time.Second, Scope: actions.ScopeTree, Privileged: true},
+			wantConfirm: true,
+			wantCode:    ExitError,
+			wantOutput: "PID 812 (postgres): exited after SIGINT\n" +
+				"PID 813: still running after SIGINT\n" +
+				"PID 814 (postgres): already gone\n",
+		},
+		{
+			name:        "privileged kill asks once",
+			args:        []string{"--pid", "812", "--sudo", "--sudo-kill"},
+			result:      actions.KillResult{Processes: []actions.ProcessResult{{PID: 812, Name: "postgres", Signal: syscall.SIGKILL, Exited: true}}},
+			wantPIDs:    "[812]",
+			wantOpts:    actions.KillOptions{Signal: syscall.SIGTERM, Grace: actions.DefaultGrace, Escalate: true, Privileged: true, KillPrivileged: true},
+			wantConfirm: true,
+			wantCode:    ExitOK,
+			wantOutput:  "PID 812 (postgres): exited after SIGKILL\n",
+		},
+		{
+			name:       "dry run",
+			args:       []string{"--dry-run", "--group", "3000"},
+			result:     actions.KillResult{Processes: []actions.ProcessResult{{PID: 4242, Name: "node"}, {PID: 4200, Name: "npm"}}},
+			wantPIDs:   "[4242]",
+			wantOpts:   actions.KillOptions{Signal: syscall.SIGTERM, Grace: actions.DefaultGrace, Escalate: true, Scope: actions.ScopeGroup, DryRun: true, Port: 3000, Protocol: "TCP"},
+			wantCode:   ExitOK,
+			wantOutput: "Would send SIGTERM to PID 4242 (node)\nWould send SIGTERM to PID 4200 (npm)\n",
+		},
+		{
+			name: "udp",
+			args: []string{"5353", "--protocol", "udp"},
+			result: actions.KillResult{
+				Processes: []actions.ProcessResult{{PID: 312, Name: "mDNSResponder", Signal: syscall.SIGTERM, Exited: true}},
+				Sent:      []actions.SentSignal{{PID: 312, Signal: syscall.SIGTERM}},
+				Elapsed:   200 * time.Millisecond,
+				PortFreed: true,
+			},
+			wantPIDs:   "[312]",
+			wantOpts:   actions.KillOptions{Signal: syscall.SIGTERM, Grace: actions.DefaultGrace, Escalate: true, Port: 5353, Protocol: "UDP"},
+			wantCode:   ExitOK,
+			wantOutput: "PID 312 (mDNSResponder): exited after SIGTERM\nPort 5353 is free after 200ms\n",
+		},
+		{
+			name:     "kill error",
+			args:     []string{"5432"},
+			killErr:  errors.New("permission denied for PID 812"),
+			wantPIDs: "[812]",
+			wantOpts: actions.KillOptions{Signal: syscall.SIGTERM, Grace: actions.DefaultGrace, Escalate: true, Port: 5432, Protocol: "TCP"},
+			wantCode: ExitError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, stdout, _ := newTestEnv()
+			var gotPIDs []int
+			var gotOpts actions.KillOptions
+			env.Kill = func(pids []int, opts actions.KillOptions) (actions.KillResult, error) {
+				gotPIDs, gotOpts = pids, opts
+				return tt.result, tt.killErr
+			}
+
+			if code := runKill(env, tt.args); code != tt.wantCode {
+				t.Errorf("runKill(%v) = %d, want %d", tt.args, code, tt.wantCode)
+			}
+			if fmt.Sprint(gotPIDs) != tt.wantPIDs {
+				t.Errorf("pids = %v, want %s", gotPIDs, tt.wantPIDs)
+			}
+			if (gotOpts.Confirm != nil) != tt.wantConfirm {
+				t.Errorf("confirm hook set = %v, want %v", gotOpts.Confirm != nil, tt.wantConfirm)
+			}
+			gotOpts.Confirm = nil
+			if !reflect.DeepEqual(gotOpts, tt.wantOpts) {
+				t.Errorf("opts = %+v, want %+v", gotOpts, tt.wantOpts)
+			}
+			if stdout.String() != tt.wantOutput {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunKill_Usage(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{"nothing listening", []string{"8080"}, ExitNotFound},
+		{"no target", nil, ExitUsage},
+		{"port and pid", []string{"--pid", "42", "3000"}, ExitUsage},
+		{"two ports", []string{"3000", "5432"}, ExitUsage},
+		{"invalid port", []string{"http"}, ExitUsage},
+		{"unsupported signal", []string{"3000", "--signal", "USR1"}, ExitUsage},
+		{"group and tree", []string{"3000", "--group", "--tree"}, ExitUsage},
+		{"sudo-kill without sudo", []string{"3000", "--sudo-kill"}, ExitUsage},
+		{"udp only port", []string{"5353"}, ExitNotFound},
+		{"unknown protocol", []string{"3000", "--protocol", "sctp"}, ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _, _ := newTestEnv()
+			if code := runKill(env, tt.args); code != tt.wantCode {
+				t.Errorf("runKill(%v) = %d, want %d", tt.args, code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestRunKill_SudoYes(t *testing.T) {
+	env, _, _ := newTestEnv()
+	var gotOpts actions.KillOptions
+	env.Kill = func(pids []int, opts actions.KillOptions) (actions.KillResult, error) {
+		gotOpts = opts
+		return actions.KillResult{}, nil
+	}
+
+	runKill(env, []string{"--pid", "812", "--sudo", "--yes"})
+	if !gotOpts.Privileged || gotOpts.Confirm != nil {
+		t.Errorf("opts = %+v, want privileged without confirmation", gotOpts)
+	}
+}
+
+func TestConfirmPrompt(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		env, _, stderr := newTestEnv()
+		env.Stdin = strings.NewReader(tt.input)
+		if got := confirmPrompt(env)(syscall.SIGKILL, []int{812, 813}); got != tt.want {
+			t.Errorf("answer %q = %v, want %v", tt.input, got, tt.want)
+		}
+		if stderr.String() != "Send SIGKILL to PID 812, 813 as root? [y/N] " {
+			t.Errorf("prompt = %q", stderr.String())
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"port-digger/scanner"
+	"sort"
+	"strings"
+)
+
+// entry is one listener as printed by the CLI
+type entry struct {
+	Port      int      `json:"port" yaml:"port"`
+	Protocol  string   `json:"protocol" yaml:"protocol"`
+	PID       int      `json:"pid" yaml:"pid"`
+	Process   string   `json:"process" yaml:"process"`
+	Service   string   `json:"service,omitempty" yaml:"service,omitempty"`
+	User      string   `json:"user,omitempty" yaml:"user,omitempty"`
+	Addresses []string `json:"addresses" yaml:"addresses"`
+	Exposed   bool     `json:"exposed" yaml:"exposed"`
+	Command   string   `json:"command" yaml:"command"`
+
+	details scanner.ProcessInfo // Shown by who, not printed by list
+}
+
+// collectEntries scans ports and joins them with process details and cached service names
+func collectEntries(env *Env) ([]entry, error) {
+	ports, err := env.Scan()
+	if err != nil {
+		return nil, err
+	}
+	listeners := scanner.GroupListeners(ports)
+
+	pids := make([]int, len(listeners))
+	for i, l := range listeners {
+		pids[i] = l.PID
+	}
+	// Missing details only leave columns empty
+	processes, _ := env.Processes(pids)
+
+	entries := make([]entry, 0, len(listeners))
+	for _, l := range listeners {
+		proc := processes[l.PID]
+		command := proc.Command
+		if command == "" {
+			command = l.Command
+		}
+		if command == "" {
+			command = l.ProcessName
+		}
+		service := env.ServiceName(command)
+		if service == "未知" {
+			service = ""
+		}
+		entries = append(entries, entry{
+			Port:      l.Port,
+			Protocol:  l.Protocol,
+			PID:       l.PID,
+			Process:   l.ProcessName,
+			Service:   service,
+			User:      proc.User,
+			Addresses: l.BindAddresses(),
+			Exposed:   l.Exposed(),
+			Command:   command,
+			details:   proc,
+		})
+	}
+	return entries, nil
+}
+
+// filter selects entries; zero values match everything
+type filter struct {
+	port    int
+	pid     int
+	process string // Case-insensitive substring of the process or service name
+	user    string
+	exposed bool
+}
+
+func (f filter) match(e entry) bool {
+	if f.port != 0 && e.Port != f.port {
+		return false
+	}
+	if f.pid != 0 && e.PID != f.pid {
+		return false
+	}
+	if f.process != "" {
+		needle := strings.ToLower(f.process)
+		if !strings.Contains(strings.ToLower(e.Process), needle) && !strings.Contains(strings.ToLower(e.Service), needle) {
+			return false
+		}
+	}
+	if f.user != "" && e.User != f.user {
+		return false
+	}
+	if f.exposed && !e.Exposed {
+		return false
+	}
+	return true
+}
+
+// sortKeys are the accepted --sort values; ties are broken by port, then PID
+var sortKeys = map[string]func(a, b entry) int{
+	"port":    func(a, b entry) int { return 0 },
+	"pid":     func(a, b entry) int { return a.PID - b.PID },
+	"process": func(a, b entry) int { return strings.Compare(strings.ToLower(a.Process), strings.ToLower(b.Process)) },
+	"user":    func(a, b entry) int { return strings.Compare(a.User, b.User) },
+}
+
+// sortEntries orders entries by key, then port and PID
+func sortEntries(entries []entry, key string, reverse bool) {
+	compare := sortKeys[key]
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if reverse {
+			a, b = b, a
+		}
+		if c := compare(a, b); c != 0 {
+			return c < 0
+		}
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		return a.PID < b.PID
+	})
+}
+
+func runList(env *Env, args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	format := fs.String("format", "table", "Output format: table, json, csv or yaml")
+	backend := fs.String("backend", "", backendUsage())
+	var f filter
+	fs.IntVar(&f.port, "port", 0, "Only show this port")
+	fs.IntVar(&f.pid, "pid", 0, "Only show ports of this PID")
+	fs.StringVar(&f.process, "process", "", "Only show processes whose name or service name contains this text")
+	fs.StringVar(&f.user, "user", "", "Only show processes owned by this user")
+	fs.BoolVar(&f.exposed, "exposed", false, "Only show ports reachable from other machines")
+	sortKey := fs.String("sort", "port", "Sort by port, pid, process or user")
+	reverse := fs.Bool("reverse", false, "Reverse the sort order")
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(env.Stderr, "Unexpected argument %q\n", fs.Arg(0))
+		return ExitUsage
+	}
+
+	write, ok := writers[*format]
+	if !ok {
+		fmt.Fprintf(env.Stderr, "Unknown format %q: use table, json, csv or yaml\n", *format)
+		return ExitUsage
+	}
+	if _, ok := sortKeys[*sortKey]; !ok {
+		fmt.Fprintf(env.Stderr, "Unknown sort key %q: use port, pid, process or user\n", *sortKey)
+		return ExitUsage
+	}
+	if err := env.SetBackend(*backend); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+
+	all, err := collectEntries(env)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error: port scan failed:", err)
+		return ExitError
+	}
+
+	entries := make([]entry, 0, len(all))
+	for _, e := range all {
+		if f.match(e) {
+			entries = append(entries, e)
+		}
+	}
+	sortEntries(entries, *sortKey, *reverse)
+
+	if err := write(env.Stdout, entries); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+	return ExitOK
+}
@@ -0,0 +1,247 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"port-digger/scanner"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ports returns the port column of entries
+func ports(entries []entry) []int {
+	var result []int
+	for _, e := range entries {
+		result = append(result, e.Port)
+	}
+	return result
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// listJSON runs list with args plus --format json and decodes the output
+func listJSON(t *testing.T, args ...string) []entry {
+	t.Helper()
+	env, stdout, stderr := newTestEnv()
+	if code := runList(env, append(args, "--format", "json")); code != ExitOK {
+		t.Fatalf("runList(%v) = %d, stderr: %s", args, code, stderr.String())
+	}
+	var entries []entry
+	if err := json.Unmarshal(stdout.Bytes(), &entries); err != nil {
+		t.Fatalf("invalid JSON output %q: %v", stdout.String(), err)
+	}
+	return entries
+}
+
+func TestCollectEntries(t *testing.T) {
+	env, _, _ := newTestEnv()
+	entries, err := collectEntries(env)
+	if err != nil {
+		t.Fatalf("collectEntries() error = %v", err)
+	}
+	if got, want := ports(entries), []int{3000, 5353, 5432}; !equalInts(got, want) {
+		t.Fatalf("ports = %v, want %v", got, want)
+	}
+
+	node := entries[0]
+	if node.Service != "Vite" || node.User != "alice" || !node.Exposed || node.Protocol != "TCP" {
+		t.Errorf("node entry = %+v", node)
+	}
+	if got := strings.Join(node.Addresses, ","); got != "0.0.0.0,::" {
+		t.Errorf("node addresses = %q, want IPv4 and IPv6 merged", got)
+	}
+	if mdns := entries[1]; mdns.Service != "" || mdns.Protocol != "UDP" {
+		t.Errorf("mDNSResponder entry = %+v, want no service and UDP", mdns)
+	}
+	if pg := entries[2]; pg.Exposed || pg.Command != testProcesses[812].Command {
+		t.Errorf("postgres entry = %+v", pg)
+	}
+}
+
+func TestCollectEntries_WithoutProcessDetails(t *testing.T) {
+	env, _, _ := newTestEnv()
+	env.Processes = func([]int) (map[int]scanner.ProcessInfo, error) { return nil, errors.New("ps failed") }
+
+	entries, err := collectEntries(env)
+	if err != nil {
+		t.Fatalf("collectEntries() error = %v", err)
+	}
+	if e := entries[0]; e.User != "" || e.Command != "node" {
+		t.Errorf("entry = %+v, want empty user and the process name as command", e)
+	}
+}
+
+func TestRunList_Filters(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []int
+	}{
+		{"all", nil, []int{3000, 5353, 5432}},
+		{"port", []string{"--port", "5432"}, []int{5432}},
+		{"pid", []string{"--pid", "4242"}, []int{3000}},
+		{"process substring", []string{"--process", "POST"}, []int{5432}},
+		{"process matches service", []string{"--process", "vite"}, []int{3000}},
+		{"user", []string{"--user", "alice"}, []int{3000}},
+		{"exposed", []string{"--exposed"}, []int{3000, 5353}},
+		{"combined", []string{"--exposed", "--user", "postgres"}, nil},
+		{"sort by process", []string{"--sort", "process"}, []int{5353, 3000, 5432}},
+		{"sort by user reversed", []string{"--sort", "user", "--reverse"}, []int{5432, 3000, 5353}},
+		{"sort by pid", []string{"--sort", "pid"}, []int{5353, 5432, 3000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ports(listJSON(t, tt.args...)); !equalInts(got, tt.want) {
+				t.Errorf("ports = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunList_Formats(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		env, stdout, _ := newTestEnv()
+		if code := runList(env, nil); code != ExitOK {
+			t.Fatalf("runList() = %d", code)
+		}
+		lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("table has %d lines, want header + 3:\n%s", len(lines), stdout.String())
+		}
+		if fields := strings.Fields(lines[0]); strings.Join(fields, " ") != "PORT PROTO PID USER PROCESS SERVICE ADDRESS" {
+			t.Errorf("header = %q", lines[0])
+		}
+		if fields := strings.Fields(lines[2]); fields[5] != "-" {
+			t.Errorf("mDNSResponder row = %q, want \"-\" for the missing service", lines[2])
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		env, stdout, _ := newTestEnv()
+		if code := runList(env, []string{"--format", "csv"}); code != ExitOK {
+			t.Fatalf("runList() = %d", code)
+		}
+		records, err := csv.NewReader(stdout).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v", err)
+		}
+		if len(records) != 4 || records[0][0] != "port" {
+			t.Fatalf("records = %v", records)
+		}
+		if records[1][6] != "0.0.0.0;::" || records[1][7] != "true" {
+			t.Errorf("node record = %v", records[1])
+		}
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		env, stdout, _ := newTestEnv()
+		if code := runList(env, []string{"--format", "yaml"}); code != ExitOK {
+			t.Fatalf("runList() = %d", code)
+		}
+		var entries []entry
+		if err := yaml.Unmarshal(stdout.Bytes(), &entries); err != nil {
+			t.Fatalf("invalid YAML: %v", err)
+		}
+		if got := ports(entries); !equalInts(got, []int{3000, 5353, 5432}) {
+			t.Errorf("ports = %v", got)
+		}
+	})
+
+	t.Run("empty json is an array", func(t *testing.T) {
+		env, stdout, _ := newTestEnv()
+		if code := runList(env, []string{"--format", "json", "--port", "1"}); code != ExitOK {
+			t.Fatalf("runList() = %d", code)
+		}
+		if got := strings.TrimSpace(stdout.String()); got != "[]" {
+			t.Errorf("output = %q, want []", got)
+		}
+	})
+}
+
+func TestRunList_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		scanErr  error
+		wantCode int
+	}{
+		{"unknown format", []string{"--format", "xml"}, nil, ExitUsage},
+		{"unknown sort key", []string{"--sort", "memory"}, nil, ExitUsage},
+		{"extra argument", []string{"3000"}, nil, ExitUsage},
+		{"scan failure", nil, errors.New("lsof: not found"), ExitError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, stdout, stderr := newTestEnv()
+			if tt.scanErr != nil {
+				env.Scan = func() ([]scanner.PortInfo, error) { return nil, tt.scanErr }
+			}
+			if code := runList(env, tt.args); code != tt.wantCode {
+				t.Errorf("runList(%v) = %d, want %d", tt.args, code, tt.wantCode)
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("stdout = %q, want nothing on error", stdout.String())
+			}
+			if stderr.Len() == 0 {
+				t.Error("stderr is empty, want an error message")
+			}
+		})
+	}
+}
+
+func TestCollectEntries_CanonicalCacheKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "port-digger")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	// Keys as the LLM client stores them: home directory, version and port replaced
+	cache := `{
+  "node ~/app/server.js --port <port>": "Express",
+  "/opt/homebrew/Cellar/postgresql@16/<version>/bin/postgres -D /var/lib/postgresql": "PostgreSQL"
+}`
+	if err := os.WriteFile(filepath.Join(dir, "cache.json"), []byte(cache), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	env, _, _ := newTestEnv()
+	env.ServiceName = defaultEnv().ServiceName
+	env.Processes = func([]int) (map[int]scanner.ProcessInfo, error) {
+		return map[int]scanner.ProcessInfo{
+			812:  {PID: 812, Command: "/opt/homebrew/Cellar/postgresql@16/16.4_1/bin/postgres -D /var/lib/postgresql"},
+			4242: {PID: 4242, Command: "node /Users/alice/app/server.js --port 51234"},
+		}, nil
+	}
+	entries, err := collectEntries(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services := make(map[int]string)
+	for _, e := range entries {
+		services[e.Port] = e.Service
+	}
+	want := map[int]string{3000: "Express", 5432: "PostgreSQL", 5353: ""}
+	if !reflect.DeepEqual(services, want) {
+		t.Errorf("services = %v, want %v", services, want)
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"port-digger/api"
+	"port-digger/metrics"
+	"port-digger/monitor"
+	"port-digger/scanner"
+	"time"
+)
+
+func runServe(env *Env, args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	socket := fs.String("socket", "", "Unix socket to listen on (default ~/.config/port-digger/api.sock)")
+	listen := fs.String("listen", "", "Listen on a loopback TCP address instead, e.g. 127.0.0.1:7878")
+	token := fs.String("token", "", "Token TCP clients must send; generated and printed when empty")
+	interval := fs.Duration("interval", 2*time.Second, "Time between scans for /events")
+	backend := fs.String("backend", "", backendUsage())
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(env.Stderr, "Unexpected argument %q\n", positional[0])
+		return ExitUsage
+	}
+	if *socket != "" && *listen != "" {
+		fmt.Fprintln(env.Stderr, "Error: --socket and --listen are mutually exclusive")
+		return ExitUsage
+	}
+	if *token != "" && *listen == "" {
+		fmt.Fprintln(env.Stderr, "Error: --token needs --listen; the socket is protected by file permissions")
+		return ExitUsage
+	}
+	if *interval <= 0 {
+		fmt.Fprintln(env.Stderr, "Error: --interval must be positive")
+		return ExitUsage
+	}
+	if err := env.SetBackend(*backend); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+
+	var listener net.Listener
+	if *listen != "" {
+		if *token == "" {
+			if *token, err = api.GenerateToken(); err != nil {
+				fmt.Fprintln(env.Stderr, "Error:", err)
+				return ExitError
+			}
+			fmt.Fprintf(env.Stderr, "Token: %s\n", *token)
+		}
+		listener, err = api.ListenLoopback(*listen)
+	} else {
+		if *socket == "" {
+			if *socket, err = api.SocketPath(); err != nil {
+				fmt.Fprintln(env.Stderr, "Error:", err)
+				return ExitError
+			}
+		}
+		listener, err = api.ListenUnix(*socket)
+	}
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+	fmt.Fprintf(env.Stderr, "Serving on %s\n", listener.Addr())
+
+	server := api.NewServer(api.Deps{
+		Scan:        env.Scan,
+		Processes:   env.Processes,
+		ServiceName: env.ServiceName,
+		Kill:        env.Kill,
+		// Scan metrics only; serve never calls the LLM
+		Metrics: []*metrics.Registry{metrics.Default},
+	}, *token)
+
+	ctx, cancel := env.Context()
+	defer cancel()
+	poller := monitor.NewPoller(env.Scan, monitor.Options{Interval: *interval})
+	go poller.Run(ctx, func(_ []scanner.Listener, diff monitor.Diff) {
+		server.Publish(diff)
+	})
+
+	if err := api.Serve(ctx, listener, server); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+	return ExitOK
+}
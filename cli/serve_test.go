@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServe_Usage(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{"socket and listen", []string{"--socket", "/tmp/a.sock", "--listen", "127.0.0.1:7878"}, "mutually exclusive"},
+		{"token without listen", []string{"--token", "abc"}, "--token needs --listen"},
+		{"bad interval", []string{"--interval", "0s"}, "--interval must be positive"},
+		{"positional", []string{"3000"}, `Unexpected argument "3000"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _, stderr := newTestEnv()
+			if code := runServe(env, tt.args); code != ExitUsage {
+				t.Errorf("exit code = %d, want %d", code, ExitUsage)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("stderr = %q, want %q", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestServe_RejectsPublicAddress(t *testing.T) {
+	env, _, stderr := newTestEnv()
+	if code := runServe(env, []string{"--listen", "0.0.0.0:0"}); code != ExitError {
+		t.Errorf("exit code = %d, want %d", code, ExitError)
+	}
+	if !strings.Contains(stderr.String(), "only loopback addresses") {
+		t.Errorf("stderr = %q", stderr.String())
+	}
+}
+
+func TestServe_Socket(t *testing.T) {
+	env, _, stderr := newTestEnv()
+	ctx, cancel := context.WithCancel(context.Background())
+	env.Context = func() (context.Context, context.CancelFunc) { return ctx, cancel }
+
+	path := filepath.Join(t.TempDir(), "api.sock")
+	done := make(chan int, 1)
+	go func() { done <- runServe(env, []string{"--socket", path}) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("socket never appeared; stderr %q", stderr.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://unix/ports/3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"service":"Vite"`) {
+		t.Errorf("GET /ports/3000 = %d %s", resp.StatusCode, body)
+	}
+
+	cancel()
+	if code := <-done; code != ExitOK {
+		t.Errorf("exit code = %d, want %d; stderr %q", code, ExitOK, stderr.String())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("socket was not removed on shutdown")
+	}
+}
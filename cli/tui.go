@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"port-digger/actions"
+	"port-digger/llm"
+	"port-digger/tui"
+	"time"
+)
+
+func runTUI(env *Env, args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	interval := fs.Duration("interval", 2*time.Second, "Time between scans")
+	sortBy := fs.String("sort", "port", "Initial sort column: port, process, service, pid, user or uptime")
+	filterText := fs.String("filter", "", "Initial filter")
+	backend := fs.String("backend", "", backendUsage())
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	if len(positional) > 0 {
+		fmt.Fprintf(env.Stderr, "Unexpected argument %q\n", positional[0])
+		return ExitUsage
+	}
+	if *interval <= 0 {
+		fmt.Fprintln(env.Stderr, "Error: --interval must be positive")
+		return ExitUsage
+	}
+	if _, err := tui.NewModel(*sortBy, ""); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+	if err := env.SetBackend(*backend); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+
+	deps := tui.Deps{
+		Scan:        env.Scan,
+		Processes:   env.Processes,
+		ServiceName: env.ServiceName,
+		Kill:        env.Kill,
+		Open:        actions.OpenBrowser,
+		Now:         env.Now,
+	}
+	// Unlike the other commands, the TUI asks the LLM for missing names
+	if rewriter, err := llm.NewRewriter(); err == nil && rewriter.IsEnabled() {
+		deps.ServiceName = rewriter.GetServiceName
+		deps.Rewrite = rewriter.TriggerRewrite
+	}
+
+	// LLM requests and errors are still logged, but would draw over the table
+	console := llm.Console
+	llm.Console = io.Discard
+	defer func() { llm.Console = console }()
+
+	ctx, cancel := env.Context()
+	defer cancel()
+	err = tui.Run(ctx, deps, tui.Options{Interval: *interval, Sort: *sortBy, Filter: *filterText})
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+	return ExitOK
+}
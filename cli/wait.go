@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// waitScanFailures is how many scans in a row may fail before wait gives up
+const waitScanFailures = 5
+
+func runWait(env *Env, args []string) int {
+	fs := flag.NewFlagSet("wait", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	listening := fs.Bool("listening", false, "Wait until something listens on the port (default)")
+	free := fs.Bool("free", false, "Wait until nothing listens on the port")
+	timeout := fs.Duration("timeout", 60*time.Second, "Give up after this long; 0 waits forever")
+	interval := fs.Duration("interval", 500*time.Millisecond, "Time between scans")
+	protocolName := fs.String("protocol", "tcp", "Socket type to wait for: tcp or udp")
+	quiet := fs.Bool("quiet", false, "Only report through the exit code")
+	backend := fs.String("backend", "", backendUsage())
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	port, ok := portArg(env, "wait", positional)
+	if !ok {
+		return ExitUsage
+	}
+	if *listening && *free {
+		fmt.Fprintln(env.Stderr, "Error: --listening and --free are mutually exclusive")
+		return ExitUsage
+	}
+	if *timeout < 0 || *interval <= 0 {
+		fmt.Fprintln(env.Stderr, "Error: --timeout must not be negative and --interval must be positive")
+		return ExitUsage
+	}
+	protocol, err := protocolFlag(*protocolName)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+	if err := env.SetBackend(*backend); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+
+	state := "listening"
+	if *free {
+		state = "free"
+	}
+
+	start := env.Now()
+	failures := 0
+	for {
+		// A backend may fail once while the port comes up, so only a run of
+		// failed scans ends the wait
+		found, err := findPort(env, port, protocol)
+		switch {
+		case err != nil:
+			failures++
+			if failures >= waitScanFailures {
+				fmt.Fprintf(env.Stderr, "Error: port scan failed %d times in a row: %v\n", failures, err)
+				return ExitError
+			}
+			if !*quiet {
+				fmt.Fprintln(env.Stderr, "Warning: port scan failed, retrying:", err)
+			}
+		case (len(found) == 0) == *free:
+			if !*quiet {
+				reportWaited(env, port, state, found, env.Now().Sub(start))
+			}
+			return ExitOK
+		default:
+			failures = 0
+		}
+
+		wait := *interval
+		if *timeout > 0 {
+			remaining := *timeout - env.Now().Sub(start)
+			if remaining <= 0 {
+				if !*quiet {
+					fmt.Fprintf(env.Stderr, "Timed out after %s waiting for port %d to be %s\n", *timeout, port, state)
+				}
+				return ExitTimeout
+			}
+			wait = min(wait, remaining)
+		}
+		env.Sleep(wait)
+	}
+}
+
+// reportWaited prints the state the port reached, e.g.
+// "Port 3000 is listening (node, PID 4242) after 1.5s"
+func reportWaited(env *Env, port int, state string, found []entry, waited time.Duration) {
+	message := fmt.Sprintf("Port %d is %s", port, state)
+	if len(found) > 0 {
+		message += fmt.Sprintf(" (%s, PID %d)", found[0].Process, found[0].PID)
+	}
+	if waited > 0 {
+		message += " after " + waited.Round(100*time.Millisecond).String()
+	}
+	fmt.Fprintln(env.Stdout, message)
+}
@@ -0,0 +1,173 @@
+package cli
+
+import (
+	"errors"
+	"port-digger/scanner"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock advances only when the command sleeps
+type fakeClock struct {
+	now    time.Time
+	sleeps []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.sleeps = append(c.sleeps, d)
+	c.now = c.now.Add(d)
+}
+
+// scanSequence returns a Scan func that yields results[i] on call i, then the last one
+func scanSequence(results ...[]scanner.PortInfo) (func() ([]scanner.PortInfo, error), *int) {
+	calls := 0
+	return func() ([]scanner.PortInfo, error) {
+		result := results[min(calls, len(results)-1)]
+		calls++
+		return result, nil
+	}, &calls
+}
+
+func TestRunWait(t *testing.T) {
+	node := testPorts[1:3]
+	tests := []struct {
+		name       string
+		args       []string
+		scans      [][]scanner.PortInfo
+		wantCode   int
+		wantScans  int
+		wantOutput string
+	}{
+		{
+			name:       "already listening",
+			args:       []string{"3000"},
+			scans:      [][]scanner.PortInfo{node},
+			wantCode:   ExitOK,
+			wantScans:  1,
+			wantOutput: "Port 3000 is listening (node, PID 4242)\n",
+		},
+		{
+			name:       "starts listening",
+			args:       []string{"3000", "--interval", "1s"},
+			scans:      [][]scanner.PortInfo{nil, nil, node},
+			wantCode:   ExitOK,
+			wantScans:  3,
+			wantOutput: "Port 3000 is listening (node, PID 4242) after 2s\n",
+		},
+		{
+			name:       "becomes free",
+			args:       []string{"--free", "3000"},
+			scans:      [][]scanner.PortInfo{node, nil},
+			wantCode:   ExitOK,
+			wantScans:  2,
+			wantOutput: "Port 3000 is free after 500ms\n",
+		},
+		{
+			name:      "times out",
+			args:      []string{"3000", "--timeout", "2s", "--interval", "750ms"},
+			scans:     [][]scanner.PortInfo{nil},
+			wantCode:  ExitTimeout,
+			wantScans: 4, // At 0, 750ms, 1.5s and 2s
+		},
+		{
+			name:      "quiet",
+			args:      []string{"3000", "-quiet"},
+			scans:     [][]scanner.PortInfo{node},
+			wantCode:  ExitOK,
+			wantScans: 1,
+		},
+		{
+			name:      "udp socket does not count as tcp",
+			args:      []string{"5353", "--timeout", "1s"},
+			scans:     [][]scanner.PortInfo{testPorts},
+			wantCode:  ExitTimeout,
+			wantScans: 3,
+		},
+		{
+			name:       "udp",
+			args:       []string{"5353", "--protocol", "udp"},
+			scans:      [][]scanner.PortInfo{testPorts},
+			wantCode:   ExitOK,
+			wantScans:  1,
+			wantOutput: "Port 5353 is listening (mDNSResponder, PID 312)\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, stdout, _ := newTestEnv()
+			clock := &fakeClock{now: testNow}
+			env.Now, env.Sleep = clock.Now, clock.Sleep
+			scan, calls := scanSequence(tt.scans...)
+			env.Scan = scan
+
+			if code := runWait(env, tt.args); code != tt.wantCode {
+				t.Errorf("runWait(%v) = %d, want %d", tt.args, code, tt.wantCode)
+			}
+			if *calls != tt.wantScans {
+				t.Errorf("scans = %d, want %d (sleeps %v)", *calls, tt.wantScans, clock.sleeps)
+			}
+			if stdout.String() != tt.wantOutput {
+				t.Errorf("stdout = %q, want %q", stdout.String(), tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestRunWait_ScanFailures(t *testing.T) {
+	env, stdout, stderr := newTestEnv()
+	clock := &fakeClock{now: testNow}
+	env.Now, env.Sleep = clock.Now, clock.Sleep
+	calls := 0
+	env.Scan = func() ([]scanner.PortInfo, error) {
+		calls++
+		if calls <= 2 {
+			return nil, errors.New("ss: exit status 1")
+		}
+		return testPorts[1:3], nil
+	}
+
+	if code := runWait(env, []string{"3000"}); code != ExitOK {
+		t.Errorf("runWait() = %d, want %d after transient scan failures", code, ExitOK)
+	}
+	if calls != 3 {
+		t.Errorf("scans = %d, want 3", calls)
+	}
+	if !strings.Contains(stderr.String(), "retrying") {
+		t.Errorf("stderr = %q, want the failures logged", stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Port 3000 is listening") {
+		t.Errorf("stdout = %q", stdout.String())
+	}
+}
+
+func TestRunWait_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantErr  string
+	}{
+		{"listening and free", []string{"3000", "--listening", "--free"}, ExitUsage, "mutually exclusive"},
+		{"zero interval", []string{"3000", "--interval", "0s"}, ExitUsage, "--interval"},
+		{"negative timeout", []string{"3000", "--timeout", "-1s"}, ExitUsage, "--timeout"},
+		{"missing port", []string{"--free"}, ExitUsage, "Usage: port-digger wait"},
+		{"scan keeps failing", []string{"3000"}, ExitError, "port scan failed 5 times in a row"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _, stderr := newTestEnv()
+			env.Scan = func() ([]scanner.PortInfo, error) { return nil, errors.New("no backend") }
+			if code := runWait(env, tt.args); code != tt.wantCode {
+				t.Errorf("runWait(%v) = %d, want %d", tt.args, code, tt.wantCode)
+			}
+			if !strings.Contains(stderr.String(), tt.wantErr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"port-digger/menu"
+	"strings"
+	"time"
+)
+
+// whoEntry is an entry with the process details printed by who --format json
+type whoEntry struct {
+	entry
+	PPID       int       `json:"ppid,omitempty"`
+	ParentName string    `json:"parent_name,omitempty"`
+	StartTime  time.Time `json:"start_time,omitzero"`
+	Cwd        string    `json:"cwd,omitempty"`
+	RSS        int64     `json:"rss_bytes,omitempty"`
+	CPU        float64   `json:"cpu_percent"`
+}
+
+// protocolFlag checks a --protocol value; "" matches TCP and UDP
+func protocolFlag(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "":
+		return "", nil
+	case "tcp":
+		return "TCP", nil
+	case "udp":
+		return "UDP", nil
+	}
+	return "", fmt.Errorf("unknown protocol %q: use tcp or udp", value)
+}
+
+// findPort returns the entries listening on port with the given protocol
+func findPort(env *Env, port int, protocol string) ([]entry, error) {
+	all, err := collectEntries(env)
+	if err != nil {
+		return nil, err
+	}
+	var found []entry
+	for _, e := range all {
+		if e.Port == port && (protocol == "" || e.Protocol == protocol) {
+			found = append(found, e)
+		}
+	}
+	return found, nil
+}
+
+func runWho(env *Env, args []string) int {
+	fs := flag.NewFlagSet("who", flag.ContinueOnError)
+	fs.SetOutput(env.Stderr)
+	format := fs.String("format", "text", "Output format: text or json")
+	protocolName := fs.String("protocol", "", "Only match tcp or udp sockets")
+	backend := fs.String("backend", "", backendUsage())
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return ExitUsage
+	}
+	port, ok := portArg(env, "who", positional)
+	if !ok {
+		return ExitUsage
+	}
+	protocol, err := protocolFlag(*protocolName)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(env.Stderr, "Unknown format %q: use text or json\n", *format)
+		return ExitUsage
+	}
+	if err := env.SetBackend(*backend); err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitUsage
+	}
+
+	found, err := findPort(env, port, protocol)
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error: port scan failed:", err)
+		return ExitError
+	}
+	if len(found) == 0 {
+		fmt.Fprintf(env.Stderr, "Nothing is listening on port %d\n", port)
+		return ExitNotFound
+	}
+
+	// Fall back to a per-PID lookup when the batch lookup missed the command line
+	for i, e := range found {
+		if e.details.Command == "" {
+			if command := env.FullCommand(e.PID); command != "" {
+				found[i].Command = command
+			}
+		}
+	}
+
+	if *format == "json" {
+		err = writeWhoJSON(env.Stdout, found)
+	} else {
+		err = writeWhoText(env.Stdout, found, env.Now())
+	}
+	if err != nil {
+		fmt.Fprintln(env.Stderr, "Error:", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// writeWhoText prints one block per listener, e.g.
+//
+//	Port 3000/TCP: node (PID 4242)
+//	  Service: Vite
+//	  Address: 0.0.0.0, ::
+//	  ...
+//	  Command: node /app/node_modules/.bin/vite
+func writeWhoText(w io.Writer, entries []entry, now time.Time) error {
+	for i, e := range entries {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Port %d/%s: %s (PID %d)\n", e.Port, e.Protocol, e.Process, e.PID)
+		if e.Service != "" {
+			fmt.Fprintf(w, "  Service: %s\n", e.Service)
+		}
+		address := strings.Join(e.Addresses, ", ")
+		if e.Exposed {
+			address += " (reachable from the network)"
+		}
+		fmt.Fprintf(w, "  Address: %s\n", address)
+		for _, line := range menu.FormatDetails(e.details, now) {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+		if _, err := fmt.Fprintf(w, "  Command: %s\n", e.Command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeWhoJSON(w io.Writer, entries []entry) error {
+	result := make([]whoEntry, len(entries))
+	for i, e := range entries {
+		result[i] = whoEntry{
+			entry:      e,
+			PPID:       e.details.PPID,
+			ParentName: e.details.ParentName,
+			StartTime:  e.details.StartTime,
+			Cwd:        e.details.Cwd,
+			RSS:        e.details.RSS,
+			CPU:        e.details.CPU,
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"encoding/json"
+	"port-digger/scanner"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunWho_Text(t *testing.T) {
+	env, stdout, _ := newTestEnv()
+	processes := map[int]scanner.ProcessInfo{
+		4242: {
+			PID: 4242, PPID: 4200, ParentName: "zsh", User: "alice",
+			StartTime: testNow.Add(-90 * time.Minute), Cwd: "/home/alice/app", RSS: 50 << 20, CPU: 1.5,
+			Command: "node /home/alice/app/node_modules/.bin/vite",
+		},
+	}
+	env.Processes = func([]int) (map[int]scanner.ProcessInfo, error) { return processes, nil }
+
+	if code := runWho(env, []string{"3000"}); code != ExitOK {
+		t.Fatalf("runWho() = %d", code)
+	}
+	want := []string{
+		"Port 3000/TCP: node (PID 4242)",
+		"  Service: Vite",
+		"  Address: 0.0.0.0, :: (reachable from the network)",
+		"  User: alice",
+		"  Parent: zsh (PID 4200)",
+		"  Started: 2026-03-14 13:39 (up 1h 30m)",
+		"  Directory: /home/alice/app",
+		"  Memory: 50.0 MB",
+		"  CPU: 1.5%",
+		"  Command: node /home/alice/app/node_modules/.bin/vite",
+	}
+	if got := stdout.String(); got != strings.Join(want, "\n")+"\n" {
+		t.Errorf("output:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestRunWho_JSON(t *testing.T) {
+	env, stdout, _ := newTestEnv()
+	if code := runWho(env, []string{"--format", "json", "5432"}); code != ExitOK {
+		t.Fatalf("runWho() = %d", code)
+	}
+	var result []map[string]any
+	if err := json.Unmarshal(stdout.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON %q: %v", stdout.String(), err)
+	}
+	if len(result) != 1 || result[0]["process"] != "postgres" || result[0]["user"] != "postgres" || result[0]["port"] != 5432.0 {
+		t.Errorf("result = %v", result)
+	}
+}
+
+func TestRunWho_FullCommandFallback(t *testing.T) {
+	env, stdout, _ := newTestEnv()
+	env.Processes = func([]int) (map[int]scanner.ProcessInfo, error) { return nil, nil }
+	env.FullCommand = func(pid int) string {
+		if pid == 812 {
+			return "postgres -D /data"
+		}
+		return ""
+	}
+
+	if code := runWho(env, []string{"5432"}); code != ExitOK {
+		t.Fatalf("runWho() = %d", code)
+	}
+	if !strings.Contains(stdout.String(), "Command: postgres -D /data") {
+		t.Errorf("output = %q, want the per-PID command", stdout.String())
+	}
+}
+
+func TestRunWho_Exit(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+	}{
+		{"udp port", []string{"5353"}, ExitOK},
+		{"protocol mismatch", []string{"5353", "--protocol", "tcp"}, ExitNotFound},
+		{"nothing listening", []string{"8080"}, ExitNotFound},
+		{"missing port", nil, ExitUsage},
+		{"two ports", []string{"3000", "5432"}, ExitUsage},
+		{"invalid port", []string{"70000"}, ExitUsage},
+		{"bad protocol", []string{"3000", "--protocol", "sctp"}, ExitUsage},
+		{"bad format", []string{"3000", "--format", "yaml"}, ExitUsage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, _, _ := newTestEnv()
+			if code := runWho(env, tt.args); code != tt.wantCode {
+				t.Errorf("runWho(%v) = %d, want %d", tt.args, code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -29,22 +29,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	if config.LLM.APIKey == "" {
-		fmt.Println("API key is empty. Set it in ~/.config/port-digger/config.yaml")
-		os.Exit(1)
-	}
-
-	fmt.Printf("Config: URL=%s, Model=%s\n", config.LLM.URL, config.LLM.Model)
+	fmt.Printf("Config: Provider=%s, URL=%s, Model=%s\n", config.LLM.Provider, config.LLM.URL, config.LLM.Model)
 	fmt.Printf("Command: %s\n", command)
 	fmt.Println("---")
 
 	// Create client and call LLM
-	client := llm.NewClient(&config.LLM)
-	result, err := client.RewriteProcessName(command)
+	client, err := llm.NewClient(&config.LLM)
+	if err != nil {
+		fmt.Printf("LLM Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Sent: %s\n", client.Canonical(command))
+	info, err := client.DescribeService(command)
 	if err != nil {
 		fmt.Printf("LLM Error: %v\n", err)
 		os.Exit(1)
 	}
+	if !info.Known() {
+		fmt.Println("Result: 未知")
+		return
+	}
 
-	fmt.Printf("Result: %s\n", result)
+	fmt.Printf("Result: %s\n", info.Name)
+	fmt.Printf("Category: %s\n", info.Category)
+	fmt.Printf("Framework: %s\n", info.Framework)
+	fmt.Printf("Description: %s\n", info.Description)
+	fmt.Printf("Confidence: %.2f\n", info.Confidence)
 }
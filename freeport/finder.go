@@ -0,0 +1,100 @@
+package freeport
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"port-digger/scanner"
+	"strings"
+)
+
+// ErrNoFreePort is returned when every port in the range is taken or reserved
+var ErrNoFreePort = errors.New("no free port")
+
+// DefaultRange is searched when Options.Range is not set
+var DefaultRange = Range{Low: 3000, High: 3999}
+
+// Options configures a search
+type Options struct {
+	Range        Range  // Zero uses DefaultRange
+	Protocol     string // "tcp" (default) or "udp"
+	Project      string // Ports reserved for this project may be returned
+	Reservations Reservations
+}
+
+// Finder looks for ports nothing listens on
+type Finder struct {
+	Scan func() ([]scanner.PortInfo, error)
+	Bind func(protocol string, port int) error // Test-binds a port, nil if it is free
+}
+
+// NewFinder returns a Finder using the port scanner and real sockets
+func NewFinder() *Finder {
+	return &Finder{Scan: scanner.ScanPorts, Bind: TryBind}
+}
+
+// Find returns the lowest port in opts.Range that no process uses, that is
+// not reserved for another project and that can actually be bound. The scan
+// catches listeners the bind test misses (e.g. on another address), and the
+// bind test catches sockets the scan cannot see (other users, TIME_WAIT)
+func (f *Finder) Find(opts Options) (int, error) {
+	protocol := strings.ToUpper(opts.Protocol)
+	if protocol == "" {
+		protocol = "TCP"
+	}
+	if protocol != "TCP" && protocol != "UDP" {
+		return 0, fmt.Errorf("unknown protocol %q: use tcp or udp", opts.Protocol)
+	}
+
+	if opts.Range == (Range{}) {
+		opts.Range = DefaultRange
+	}
+
+	ports, err := f.Scan()
+	if err != nil {
+		return 0, fmt.Errorf("port scan failed: %w", err)
+	}
+	used := make(map[int]bool)
+	for _, p := range ports {
+		if p.IsUDP() == (protocol == "UDP") {
+			used[p.Port] = true
+		}
+	}
+
+	for port := opts.Range.Low; port <= opts.Range.High; port++ {
+		if used[port] {
+			continue
+		}
+		if opts.Reservations.ReservedByOthers(port, opts.Project) {
+			continue
+		}
+		if f.Bind(protocol, port) != nil {
+			continue
+		}
+		return port, nil
+	}
+	return 0, fmt.Errorf("%w in %s", ErrNoFreePort, opts.Range)
+}
+
+// TryBind binds port on all interfaces and on loopback, then releases it.
+// Both are tried because some systems allow a wildcard bind next to a
+// loopback one
+func TryBind(protocol string, port int) error {
+	for _, host := range []string{"", "127.0.0.1"} {
+		address := net.JoinHostPort(host, fmt.Sprint(port))
+		if protocol == "UDP" {
+			conn, err := net.ListenPacket("udp", address)
+			if err != nil {
+				return err
+			}
+			conn.Close()
+			continue
+		}
+		listener, err := net.Listen("tcp", address)
+		if err != nil {
+			return err
+		}
+		listener.Close()
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package freeport
+
+import (
+	"errors"
+	"net"
+	"port-digger/scanner"
+	"syscall"
+	"testing"
+)
+
+// fakeFinder reports scanned as in use and refuses to bind the ports in bound
+func fakeFinder(scanned []scanner.PortInfo, bound ...int) (*Finder, *[]int) {
+	var attempts []int
+	busy := make(map[int]bool)
+	for _, port := range bound {
+		busy[port] = true
+	}
+	return &Finder{
+		Scan: func() ([]scanner.PortInfo, error) { return scanned, nil },
+		Bind: func(protocol string, port int) error {
+			attempts = append(attempts, port)
+			if busy[port] {
+				return syscall.EADDRINUSE
+			}
+			return nil
+		},
+	}, &attempts
+}
+
+func TestFind(t *testing.T) {
+	scanned := []scanner.PortInfo{
+		{Port: 3000, Protocol: "TCP"},
+		{Port: 3001, Protocol: "TCP6"},
+		{Port: 3002, Protocol: "UDP"},
+	}
+	reservations := Reservations{"billing-api": {{3002, 3003}}}
+
+	tests := []struct {
+		name     string
+		opts     Options
+		bound    []int
+		want     int
+		wantErr  error
+		attempts int
+	}{
+		{
+			name:     "skips scanned and reserved ports",
+			opts:     Options{Range: Range{3000, 3010}, Reservations: reservations},
+			want:     3004,
+			attempts: 1,
+		},
+		{
+			name:     "own reservation is allowed",
+			opts:     Options{Range: Range{3000, 3010}, Reservations: reservations, Project: "billing-api"},
+			want:     3002, // The UDP socket does not block TCP
+			attempts: 1,
+		},
+		{
+			name:     "reservation shared with another project",
+			opts:     Options{Range: Range{3000, 3010}, Reservations: Reservations{"a": {{3002, 3003}}, "b": {{3002, 3002}}}, Project: "b"},
+			want:     3002,
+			attempts: 1,
+		},
+		{
+			name:     "udp",
+			opts:     Options{Range: Range{3000, 3010}, Protocol: "udp"},
+			want:     3000,
+			attempts: 1,
+		},
+		{
+			name:     "bind test catches unscanned sockets",
+			opts:     Options{Range: Range{3000, 3010}},
+			bound:    []int{3002, 3003},
+			want:     3004,
+			attempts: 3,
+		},
+		{
+			name:     "default range",
+			opts:     Options{},
+			want:     3002,
+			attempts: 1,
+		},
+		{
+			name:     "range exhausted",
+			opts:     Options{Range: Range{3000, 3003}, Reservations: reservations},
+			wantErr:  ErrNoFreePort,
+			attempts: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finder, attempts := fakeFinder(scanned, tt.bound...)
+			got, err := finder.Find(tt.opts)
+			if !errors.Is(err, tt.wantErr) || got != tt.want {
+				t.Errorf("Find() = %d, %v, want %d, %v", got, err, tt.want, tt.wantErr)
+			}
+			if len(*attempts) != tt.attempts {
+				t.Errorf("bind attempts = %v, want %d", *attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestFind_Errors(t *testing.T) {
+	finder, _ := fakeFinder(nil)
+	if _, err := finder.Find(Options{Protocol: "sctp"}); err == nil {
+		t.Error("Find() with an unknown protocol: error = nil")
+	}
+
+	finder.Scan = func() ([]scanner.PortInfo, error) { return nil, errors.New("lsof: not found") }
+	if _, err := finder.Find(Options{}); err == nil {
+		t.Error("Find() with a failing scan: error = nil")
+	}
+}
+
+func TestTryBind(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer listener.Close()
+	port := listener.Addr().(*net.TCPAddr).Port
+
+	if err := TryBind("TCP", port); err == nil {
+		t.Errorf("TryBind(%d) = nil while 127.0.0.1:%d is listening", port, port)
+	}
+
+	listener.Close()
+	if err := TryBind("TCP", port); err != nil {
+		t.Errorf("TryBind(%d) after close = %v, want nil", port, err)
+	}
+}
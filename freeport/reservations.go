@@ -0,0 +1,124 @@
+// Package freeport finds unused ports, skipping ports other projects have
+// reserved in ~/.config/port-digger/reservations.yaml
+package freeport
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Range is an inclusive port range
+type Range struct {
+	Low  int
+	High int
+}
+
+// ParseRange parses "3000-3999" or a single port "8080"
+func ParseRange(s string) (Range, error) {
+	low, high, found := strings.Cut(strings.TrimSpace(s), "-")
+	if !found {
+		high = low
+	}
+	r := Range{}
+	var err1, err2 error
+	r.Low, err1 = strconv.Atoi(strings.TrimSpace(low))
+	r.High, err2 = strconv.Atoi(strings.TrimSpace(high))
+	if err1 != nil || err2 != nil || r.Low < 1 || r.High > 65535 || r.Low > r.High {
+		return Range{}, fmt.Errorf("invalid port range %q: want e.g. 3000-3999", s)
+	}
+	return r, nil
+}
+
+func (r Range) String() string {
+	if r.Low == r.High {
+		return strconv.Itoa(r.Low)
+	}
+	return fmt.Sprintf("%d-%d", r.Low, r.High)
+}
+
+// Contains reports whether port is within the range
+func (r Range) Contains(port int) bool {
+	return port >= r.Low && port <= r.High
+}
+
+// UnmarshalYAML accepts a port number or a "low-high" string
+func (r *Range) UnmarshalYAML(node *yaml.Node) error {
+	parsed, err := ParseRange(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: %w", node.Line, err)
+	}
+	*r = parsed
+	return nil
+}
+
+// Reservations maps a project name to the ports reserved for it. The file is
+// maintained by hand; port-digger only reads it, e.g.
+//
+//	billing-api: [3001, 3002]
+//	storefront: ["4000-4099", 8080]
+type Reservations map[string][]Range
+
+// ReservationsPath returns ~/.config/port-digger/reservations.yaml
+func ReservationsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "port-digger", "reservations.yaml"), nil
+}
+
+// LoadReservations reads the reservation file; a missing file means no reservations
+func LoadReservations() (Reservations, error) {
+	path, err := ReservationsPath()
+	if err != nil {
+		return nil, err
+	}
+	return LoadReservationsFrom(path)
+}
+
+// LoadReservationsFrom reads reservations from path
+func LoadReservationsFrom(path string) (Reservations, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Reservations{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reservations: %w", err)
+	}
+
+	reservations := Reservations{}
+	if err := yaml.Unmarshal(data, &reservations); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return reservations, nil
+}
+
+// ReservedByOthers reports whether port is reserved and project is not one of
+// the projects that claim it
+func (r Reservations) ReservedByOthers(port int, project string) bool {
+	if r.claims(project, port) {
+		return false
+	}
+	for other := range r {
+		if r.claims(other, port) {
+			return true
+		}
+	}
+	return false
+}
+
+// claims reports whether project reserved port
+func (r Reservations) claims(project string, port int) bool {
+	for _, reserved := range r[project] {
+		if reserved.Contains(port) {
+			return true
+		}
+	}
+	return false
+}
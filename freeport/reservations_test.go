@@ -0,0 +1,110 @@
+package freeport
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Range
+		wantErr bool
+	}{
+		{"3000-3999", Range{3000, 3999}, false},
+		{" 8080 ", Range{8080, 8080}, false},
+		{"4000 - 4010", Range{4000, 4010}, false},
+		{"3999-3000", Range{}, true},
+		{"0-10", Range{}, true},
+		{"60000-70000", Range{}, true},
+		{"http", Range{}, true},
+		{"", Range{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseRange(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRange(%q) = %v, %v, want %v, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestLoadReservationsFrom(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "reservations.yaml")
+	content := `billing-api: [3001, 3002]
+storefront: ["4000-4099", 8080]
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reservations, err := LoadReservationsFrom(path)
+	if err != nil {
+		t.Fatalf("LoadReservationsFrom() error = %v", err)
+	}
+
+	tests := []struct {
+		port  int
+		owner string
+	}{
+		{3001, "billing-api"},
+		{3003, ""},
+		{4050, "storefront"},
+		{8080, "storefront"},
+		{8081, ""},
+	}
+	for _, tt := range tests {
+		if got := reservations.ReservedByOthers(tt.port, ""); got != (tt.owner != "") {
+			t.Errorf("port %d reserved = %v, want reserved by %q", tt.port, got, tt.owner)
+		}
+		if tt.owner != "" && reservations.ReservedByOthers(tt.port, tt.owner) {
+			t.Errorf("port %d is not free for its owner %s", tt.port, tt.owner)
+		}
+	}
+}
+
+func TestLoadReservationsFrom_Missing(t *testing.T) {
+	reservations, err := LoadReservationsFrom(filepath.Join(t.TempDir(), "nope.yaml"))
+	if err != nil {
+		t.Fatalf("LoadReservationsFrom() error = %v", err)
+	}
+	if len(reservations) != 0 {
+		t.Errorf("reservations = %v, want none", reservations)
+	}
+}
+
+func TestLoadReservationsFrom_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reservations.yaml")
+	if err := os.WriteFile(path, []byte("web: [\"5000-4000\"]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadReservationsFrom(path); err == nil {
+		t.Error("LoadReservationsFrom() error = nil, want an invalid range error")
+	}
+}
+
+func TestReservedByOthers(t *testing.T) {
+	reservations := Reservations{
+		"a": {{3000, 3010}},
+		"b": {{3005, 3005}},
+	}
+	tests := []struct {
+		port    int
+		project string
+		want    bool
+	}{
+		{3005, "a", false},
+		{3005, "b", false},
+		{3005, "", true},
+		{3005, "c", true},
+		{3001, "b", true},
+		{3011, "b", false},
+	}
+	for _, tt := range tests {
+		if got := reservations.ReservedByOthers(tt.port, tt.project); got != tt.want {
+			t.Errorf("ReservedByOthers(%d, %q) = %v, want %v", tt.port, tt.project, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// anthropicVersion is the Messages API version sent in the anthropic-version header
+const anthropicVersion = "2023-06-01"
+
+// anthropicMaxTokens bounds the reply; it fits a full batch of short
+// service descriptions
+const anthropicMaxTokens = 4096
+
+// anthropicToolName is the tool whose input carries a structured answer
+const anthropicToolName = "answer"
+
+type anthropicRequest struct {
+	Model      string          `json:"model"`
+	MaxTokens  int             `json:"max_tokens"`
+	Messages   []ChatMessage   `json:"messages"`
+	Tools      []anthropicTool `json:"tools,omitempty"`
+	ToolChoice map[string]any  `json:"tool_choice,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"input_schema"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`  // For tool_use blocks
+		Input json.RawMessage `json:"input"` // For tool_use blocks
+	} `json:"content"`
+}
+
+// anthropicProvider speaks the Anthropic Messages API
+type anthropicProvider struct {
+	endpoint
+}
+
+// Complete forces a call of the answer tool when a schema is given, since
+// tool input is the way the Messages API enforces a JSON schema
+func (p *anthropicProvider) Complete(prompt string, schema map[string]any) (string, error) {
+	if err := p.check(true); err != nil {
+		return "", err
+	}
+
+	req := anthropicRequest{
+		Model:     p.model,
+		MaxTokens: anthropicMaxTokens,
+		Messages:  []ChatMessage{{Role: "user", Content: prompt}},
+	}
+	if schema != nil {
+		req.Tools = []anthropicTool{{
+			Name:        anthropicToolName,
+			Description: "Report the answer",
+			InputSchema: schema,
+		}}
+		req.ToolChoice = map[string]any{"type": "tool", "name": anthropicToolName}
+	}
+	var resp anthropicResponse
+	err := p.postJSON(map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}, req, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	// The reply is a list of content blocks; the answer is the tool input or
+	// the text blocks
+	var text strings.Builder
+	for _, block := range resp.Content {
+		switch {
+		case block.Type == "tool_use" && block.Name == anthropicToolName:
+			return string(block.Input), nil
+		case block.Type == "text":
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no text content in response")
+	}
+	return text.String(), nil
+}
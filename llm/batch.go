@@ -0,0 +1,157 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"port-digger/logger"
+	"strings"
+	"time"
+)
+
+// batchSchema is the JSON schema of a batch answer: one ServiceInfo per
+// command, tagged with the command's number in the prompt. The array sits in
+// an object because structured output requires an object at the top level
+var batchSchema = func() map[string]any {
+	properties := maps.Clone(serviceSchema["properties"].(map[string]any))
+	properties["id"] = map[string]any{"type": "integer", "description": "Number of the command"}
+	required := append([]string{"id"}, serviceSchema["required"].([]string)...)
+
+	item := maps.Clone(serviceSchema)
+	item["properties"] = properties
+	item["required"] = required
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"services": map[string]any{"type": "array", "items": item},
+		},
+		"required":             []string{"services"},
+		"additionalProperties": false,
+	}
+}()
+
+// buildBatchPrompt creates the prompt identifying several commands at once
+func buildBatchPrompt(commands []string) string {
+	var list strings.Builder
+	for i, command := range commands {
+		fmt.Fprintf(&list, "%d. %s\n", i+1, command)
+	}
+	return fmt.Sprintf(`你是一个命令分析专家。你的任务是识别下列每条原始命令运行的服务，并以 JSON 对象回答。
+
+JSON 对象只有一个字段 services，它是一个数组，每条命令对应一个元素。每个元素的字段：
+- id: 命令的编号
+%s
+
+只输出 JSON 对象，不要有任何其他解释。
+
+示例：
+- 输入:
+1. /opt/homebrew/opt/redis/bin/redis-server 127.0.0.1:6379
+2. node a.js
+- 输出: {"services":[{"id":1,"service_name":"Redis","category":"database","framework":"","description":"In-memory key-value store","confidence":0.99},{"id":2,"service_name":"","category":"other","framework":"Node.js","description":"","confidence":0.1}]}
+
+现在请分析以下命令：
+%s`, serviceFields, list.String())
+}
+
+// DescribeServices asks the LLM about several commands in one request.
+// Results are keyed by the index of the command; commands the answer left
+// out or answered with an invalid record are missing, so the caller can ask
+// for them one by one. Unidentified services are present but not Known
+func (c *Client) DescribeServices(commands []string) (map[int]ServiceInfo, error) {
+	canonical := make([]string, len(commands))
+	for i, command := range commands {
+		canonical[i] = c.Canonical(command)
+	}
+
+	start := time.Now()
+	results, err := c.describeServices(canonical)
+	llmDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		llmRequests.Inc("error")
+	} else {
+		llmRequests.Inc("success")
+	}
+	return results, err
+}
+
+func (c *Client) describeServices(commands []string) (map[int]ServiceInfo, error) {
+	logger.Debug("LLM batch request started for %d commands via %s", len(commands), c.provider.Name())
+
+	reply, err := c.provider.Complete(buildBatchPrompt(commands), batchSchema)
+	if err != nil {
+		logger.Error("LLM batch request failed: %v", err)
+		return nil, err
+	}
+
+	results, err := parseServiceBatch(reply, len(commands))
+	if err != nil {
+		logger.Error("LLM batch reply rejected: %v", err)
+		return nil, err
+	}
+
+	for i, command := range commands {
+		info, ok := results[i]
+		if !ok {
+			logger.Error("LLM batch reply has no valid answer for command: %s", command)
+			continue
+		}
+		result := unknownName
+		if info.Known() {
+			result = info.Name
+		}
+		logger.LogLLMRequest(command, result, nil)
+	}
+
+	return results, nil
+}
+
+// parseServiceBatch validates a batch answer for n commands. A reply that is
+// not a services object fails as a whole; single bad elements are left out
+func parseServiceBatch(reply string, n int) (map[int]ServiceInfo, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no JSON object in reply %q", reply)
+	}
+
+	var answer struct {
+		Services []json.RawMessage `json:"services"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &answer); err != nil {
+		return nil, fmt.Errorf("invalid JSON in reply: %w", err)
+	}
+	if answer.Services == nil {
+		return nil, fmt.Errorf("no services array in reply %q", reply)
+	}
+
+	results := make(map[int]ServiceInfo, n)
+	seen := make(map[int]bool, n)
+	for _, raw := range answer.Services {
+		var element struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(raw, &element); err != nil || element.ID < 1 || element.ID > n {
+			logger.Debug("Ignoring batch element without a valid id: %s", raw)
+			continue
+		}
+		if seen[element.ID] {
+			// Neither answer can be trusted
+			logger.Debug("Ignoring duplicate answers for command %d", element.ID)
+			delete(results, element.ID-1)
+			continue
+		}
+		seen[element.ID] = true
+
+		info, err := parseServiceInfo(string(raw))
+		if errors.Is(err, errUnknownService) {
+			info, err = ServiceInfo{}, nil
+		}
+		if err != nil {
+			logger.Debug("Ignoring batch answer for command %d: %v", element.ID, err)
+			continue
+		}
+		results[element.ID-1] = info
+	}
+	return results, nil
+}
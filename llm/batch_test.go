@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseServiceBatch(t *testing.T) {
+	vite := ServiceInfo{Name: "Vite", Category: "web-dev-server", Confidence: 0.9}
+	redis := ServiceInfo{Name: "Redis", Category: "database", Confidence: 0.99}
+
+	tests := []struct {
+		name    string
+		reply   string
+		want    map[int]ServiceInfo
+		wantErr string
+	}{
+		{
+			name:  "all answered out of order",
+			reply: `{"services":[{"id":2,"service_name":"Redis","category":"database","confidence":0.99},{"id":1,"service_name":"Vite","category":"web-dev-server","confidence":0.9}]}`,
+			want:  map[int]ServiceInfo{0: vite, 1: redis},
+		},
+		{
+			name:  "code block",
+			reply: "```json\n{\"services\":[{\"id\":1,\"service_name\":\"Vite\",\"category\":\"web-dev-server\",\"confidence\":0.9}]}\n```",
+			want:  map[int]ServiceInfo{0: vite},
+		},
+		{
+			name:  "unidentified is an answer",
+			reply: `{"services":[{"id":1,"service_name":"","category":"other","confidence":0.1},{"id":2,"service_name":"Redis","category":"database","confidence":0.99}]}`,
+			want:  map[int]ServiceInfo{0: {}, 1: redis},
+		},
+		{
+			name:  "invalid elements left out",
+			reply: `{"services":[{"id":1,"service_name":"Vite","confidence":3},{"id":7,"service_name":"Redis","confidence":0.99},{"service_name":"Redis","confidence":0.99},{"id":2,"service_name":"Redis","category":"database","confidence":0.99}]}`,
+			want:  map[int]ServiceInfo{1: redis},
+		},
+		{
+			name:  "duplicate answers left out",
+			reply: `{"services":[{"id":1,"service_name":"Vite","confidence":0.9},{"id":1,"service_name":"Redis","confidence":0.9},{"id":1,"service_name":"Vite","confidence":0.9}]}`,
+			want:  map[int]ServiceInfo{},
+		},
+		{name: "plain text", reply: "Vite, Redis", wantErr: "no JSON object"},
+		{name: "single answer instead of a batch", reply: `{"service_name":"Vite","confidence":0.9}`, wantErr: "no services array"},
+		{name: "broken JSON", reply: `{"services":[{"id":1,}]}`, wantErr: "invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseServiceBatch(tt.reply, 2)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseServiceBatch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseServiceBatch() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildBatchPrompt(t *testing.T) {
+	prompt := buildBatchPrompt([]string{"node vite", "redis-server *:6379"})
+	if !strings.HasSuffix(prompt, "1. node vite\n2. redis-server *:6379\n") {
+		t.Errorf("prompt does not end with the numbered commands:\n%s", prompt)
+	}
+}
+
+func TestBatchSchema(t *testing.T) {
+	items := batchSchema["properties"].(map[string]any)["services"].(map[string]any)["items"].(map[string]any)
+	if _, ok := items["properties"].(map[string]any)["id"]; !ok {
+		t.Error("batch items have no id")
+	}
+	if _, ok := serviceSchema["properties"].(map[string]any)["id"]; ok {
+		t.Error("building the batch schema changed serviceSchema")
+	}
+	if required := items["required"].([]string); required[0] != "id" || len(required) != len(serviceSchema["required"].([]string))+1 {
+		t.Errorf("required = %v", required)
+	}
+}
@@ -4,19 +4,35 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"port-digger/metrics"
 	"sync"
 )
 
+// cacheLookups counts Get calls by result, "hit" or "miss"
+var cacheLookups = Metrics.NewCounter("port_digger_llm_cache_lookups_total",
+	"Service name cache lookups by result.", "result")
+
+func init() {
+	Metrics.NewGaugeFunc("port_digger_llm_cache_hit_ratio",
+		"Share of service name cache lookups that were hits since start.", func() []metrics.Sample {
+			hits, misses := cacheLookups.Value("hit"), cacheLookups.Value("miss")
+			if hits+misses == 0 {
+				return nil
+			}
+			return []metrics.Sample{{Value: hits / (hits + misses)}}
+		})
+}
+
 // Cache stores the command to service name mappings
 type Cache struct {
 	mu    sync.RWMutex
-	items map[string]string // command -> service name
+	items map[string]ServiceInfo // command -> service
 }
 
 // NewCache creates a new empty cache
 func NewCache() *Cache {
 	return &Cache{
-		items: make(map[string]string),
+		items: make(map[string]ServiceInfo),
 	}
 }
 
@@ -45,7 +61,8 @@ func LoadCache() (*Cache, error) {
 		return nil, err
 	}
 
-	var items map[string]string
+	// Entries of older versions are plain service names, see ServiceInfo.UnmarshalJSON
+	var items map[string]ServiceInfo
 	if err := json.Unmarshal(data, &items); err != nil {
 		return nil, err
 	}
@@ -78,21 +95,39 @@ func (c *Cache) Save() error {
 // Get retrieves the service name for a command
 // Returns empty string if not cached
 func (c *Cache) Get(command string) string {
+	info, _ := c.GetInfo(command)
+	return info.Name
+}
+
+// GetInfo retrieves everything known about the service of a command
+func (c *Cache) GetInfo(command string) (ServiceInfo, bool) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-	return c.items[command]
+	info, ok := c.items[command]
+	c.mu.RUnlock()
+
+	if ok {
+		cacheLookups.Inc("hit")
+	} else {
+		cacheLookups.Inc("miss")
+	}
+	return info, ok
 }
 
 // Set stores a command to service name mapping
 // Does NOT cache "未知" results
 func (c *Cache) Set(command, serviceName string) {
+	c.SetInfo(command, ServiceInfo{Name: serviceName})
+}
+
+// SetInfo stores the service of a command; unidentified services are not cached
+func (c *Cache) SetInfo(command string, info ServiceInfo) {
 	// Don't cache unknown results
-	if serviceName == "未知" {
+	if !info.Known() {
 		return
 	}
 
 	c.mu.Lock()
-	c.items[command] = serviceName
+	c.items[command] = info
 	c.mu.Unlock()
 }
 
@@ -103,3 +138,29 @@ func (c *Cache) Has(command string) bool {
 	_, ok := c.items[command]
 	return ok
 }
+
+// Rekey replaces every key by rekey(key) and returns how many keys changed
+// When keys collide, an entry that already had the new key wins
+func (c *Cache) Rekey(rekey func(command string) string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items := make(map[string]ServiceInfo, len(c.items))
+	moved := make(map[string]ServiceInfo)
+	changed := 0
+	for command, info := range c.items {
+		if key := rekey(command); key != command {
+			moved[key] = info
+			changed++
+		} else {
+			items[command] = info
+		}
+	}
+	for key, info := range moved {
+		if _, ok := items[key]; !ok {
+			items[key] = info
+		}
+	}
+	c.items = items
+	return changed
+}
@@ -1,6 +1,9 @@
 package llm
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -71,3 +74,89 @@ func TestCache_ThreadSafe(t *testing.T) {
 		<-done
 	}
 }
+
+func TestCache_LookupMetrics(t *testing.T) {
+	hits, misses := cacheLookups.Value("hit"), cacheLookups.Value("miss")
+
+	cache := NewCache()
+	cache.Set("redis-server *:6379", "Redis")
+	cache.Get("redis-server *:6379")
+	cache.Get("redis-server *:6379")
+	cache.Get("node a.js")
+
+	if got := cacheLookups.Value("hit") - hits; got != 2 {
+		t.Errorf("hits = %v, want 2", got)
+	}
+	if got := cacheLookups.Value("miss") - misses; got != 1 {
+		t.Errorf("misses = %v, want 1", got)
+	}
+}
+
+func TestLoadCache_LegacyFormat(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "port-digger")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{"node /app/node_modules/.bin/vite": "Vite"}`
+	if err := os.WriteFile(filepath.Join(dir, "cache.json"), []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := LoadCache()
+	if err != nil {
+		t.Fatalf("LoadCache() error = %v", err)
+	}
+	if got := cache.Get("node /app/node_modules/.bin/vite"); got != "Vite" {
+		t.Errorf("Get() = %q, want the legacy name", got)
+	}
+
+	// Saving upgrades the file to structured entries
+	cache.SetInfo("postgres", ServiceInfo{Name: "PostgreSQL", Category: CategoryDatabase, Confidence: 0.99})
+	cache.SetInfo("node a.js", ServiceInfo{Confidence: 0.1})
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := LoadCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, ok := reloaded.GetInfo("postgres"); !ok || info.Category != CategoryDatabase {
+		t.Errorf("GetInfo() = %+v, %v after reload", info, ok)
+	}
+	if info, _ := reloaded.GetInfo("node /app/node_modules/.bin/vite"); info != (ServiceInfo{Name: "Vite"}) {
+		t.Errorf("legacy entry after reload = %+v", info)
+	}
+	if reloaded.Has("node a.js") {
+		t.Error("unidentified service was cached")
+	}
+}
+
+func TestCache_Rekey(t *testing.T) {
+	cache := NewCache()
+	cache.Set("app --port=1", "Old")
+	cache.Set("app --port=2", "Other")
+	cache.Set("app --port=N", "Current")
+	cache.Set("redis-server", "Redis")
+
+	n := cache.Rekey(func(command string) string {
+		if strings.HasPrefix(command, "app --port=") {
+			return "app --port=N"
+		}
+		return command
+	})
+
+	if n != 2 {
+		t.Errorf("Rekey() = %d, want 2", n)
+	}
+	if got := cache.Get("app --port=N"); got != "Current" {
+		t.Errorf("Get() = %q, want the entry that already had the key", got)
+	}
+	if cache.Has("app --port=1") || cache.Has("app --port=2") {
+		t.Error("old keys still cached")
+	}
+	if got := cache.Get("redis-server"); got != "Redis" {
+		t.Errorf("unchanged key lost: Get() = %q", got)
+	}
+}
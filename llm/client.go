@@ -1,161 +1,153 @@
 package llm
 
 import (
-	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"port-digger/logger"
+	"port-digger/metrics"
 	"strings"
 	"time"
 )
 
-// Client is the LLM API client
+// Metrics holds the LLM request and cache metrics. They are only meaningful in
+// the process that calls the LLM, so unlike metrics.Default the exporter does
+// not serve them; the menu bar app serves them on its API
+var Metrics = metrics.NewRegistry()
+
+// LLM request metrics; result is "success" or "error"
+var (
+	llmRequests = Metrics.NewCounter("port_digger_llm_requests_total",
+		"LLM service name requests by result.", "result")
+	llmDuration = Metrics.NewHistogram("port_digger_llm_request_duration_seconds",
+		"Time taken by LLM service name requests.", metrics.DefaultBuckets)
+)
+
+// Console receives the requests, answers and errors printed for visibility
+// next to the log; a terminal UI replaces it so they do not draw over it
+var Console io.Writer = os.Stdout
+
+// Client asks an LLM provider for service names
 type Client struct {
-	httpClient *http.Client
-	config     *LLMSettings
+	provider Provider
+	keys     *Canonicalizer // Applied to commands before anything else
 }
 
-// NewClient creates a new LLM client
-func NewClient(config *LLMSettings) *Client {
-	return &Client{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		config: config,
+// NewClient creates a client for the provider selected in config
+func NewClient(config *LLMSettings) (*Client, error) {
+	keys, err := NewCanonicalizer(config)
+	if err != nil {
+		return nil, err
 	}
+	provider, err := NewProvider(*config, &http.Client{
+		Timeout: 30 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Client{provider: provider, keys: keys}, nil
 }
 
-// ChatMessage represents a message in the chat format
-type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-// ChatRequest represents the request body for the chat API
-type ChatRequest struct {
-	Model    string        `json:"model"`
-	Messages []ChatMessage `json:"messages"`
+// Canonical returns command as it is sent to the LLM and cached: without
+// secrets, and with ports, versions and random names replaced by placeholders
+func (c *Client) Canonical(command string) string {
+	return c.keys.Canonical(command)
 }
 
-// ChatResponse represents the response from the chat API
-type ChatResponse struct {
-	Choices []struct {
-		Message struct {
-			Content string `json:"content"`
-		} `json:"message"`
-	} `json:"choices"`
-}
+// serviceFields describes the fields of a ServiceInfo answer in prompts
+const serviceFields = `- service_name: 简短的服务名称，通常是一个单词或短名称；无法识别时为空字符串
+- category: 以下之一：database, web-dev-server, ide, proxy, system, other
+- framework: 使用的框架或运行时，例如 Vite、Django、Spring Boot；没有则为空字符串
+- description: 一句简短的英文说明，不超过 80 个字符
+- confidence: 0 到 1 之间的数字，表示你对识别结果的把握`
 
-// buildPrompt creates the prompt for service name extraction
+// buildPrompt creates the prompt for service identification
 func buildPrompt(command string) string {
-	return fmt.Sprintf(`你是一个命令分析专家。你的任务是从原始命令中提取出简短的服务名称。
+	return fmt.Sprintf(`你是一个命令分析专家。你的任务是识别原始命令运行的服务，并以 JSON 对象回答。
 
-规则：
-1. 识别命令实际运行的服务或工具名称
-2. 输出应该简短，通常是一个单词或短名称
-3. 如果无法识别具体服务，输出"未知"
-4. 只输出服务名称，不要有任何其他解释
+字段：
+%s
+
+只输出 JSON 对象，不要有任何其他解释。
 
 示例：
 - 输入: node /opt/homebrew/bin/claude-code-ui --database-path /Users/xxx/.config/claude-code-ui/db.db
-- 输出: claude-code-ui
+- 输出: {"service_name":"claude-code-ui","category":"web-dev-server","framework":"Node.js","description":"Web UI for Claude Code sessions","confidence":0.9}
 
 - 输入: /usr/bin/python3 -m http.server 8000
-- 输出: http.server
+- 输出: {"service_name":"http.server","category":"web-dev-server","framework":"Python","description":"Python built-in static file server","confidence":0.95}
 
-- 输入: /Applications/Antigravity.app/Contents/MacOS/Electron .
-- 输出: Antigravity
+- 输入: /opt/homebrew/opt/postgresql@16/bin/postgres -D /opt/homebrew/var/postgresql@16
+- 输出: {"service_name":"PostgreSQL","category":"database","framework":"","description":"PostgreSQL 16 database server","confidence":0.99}
 
 - 输入: node a.js
-- 输出: 未知
+- 输出: {"service_name":"","category":"other","framework":"Node.js","description":"","confidence":0.1}
 
 现在请分析以下命令：
-%s`, command)
+%s`, serviceFields, command)
 }
 
-// RewriteProcessName calls the LLM to extract a service name from the command
-func (c *Client) RewriteProcessName(command string) (string, error) {
-	logger.Debug("LLM rewrite request started for command: %s", command)
-
-	if c.config.URL == "" || c.config.APIKey == "" {
-		err := fmt.Errorf("LLM not configured")
-		logger.LogLLMRequest(command, "", err)
-		return "", err
-	}
-
-	prompt := buildPrompt(command)
-
-	reqBody := ChatRequest{
-		Model: c.config.Model,
-		Messages: []ChatMessage{
-			{Role: "user", Content: prompt},
-		},
-	}
-
-	jsonData, err := json.Marshal(reqBody)
+// DescribeService asks the LLM what the command runs. An unidentified
+// service is not an error; it returns a ServiceInfo that is not Known
+func (c *Client) DescribeService(command string) (ServiceInfo, error) {
+	command = c.Canonical(command)
+	start := time.Now()
+	info, err := c.describeService(command)
+	llmDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
-		err = fmt.Errorf("failed to marshal request: %w", err)
-		logger.LogLLMRequest(command, "", err)
-		return "", err
+		llmRequests.Inc("error")
+	} else {
+		llmRequests.Inc("success")
 	}
+	return info, err
+}
 
-	req, err := http.NewRequest("POST", c.config.URL, bytes.NewBuffer(jsonData))
+// RewriteProcessName returns only the service name, "未知" if unidentified
+func (c *Client) RewriteProcessName(command string) (string, error) {
+	info, err := c.DescribeService(command)
 	if err != nil {
-		err = fmt.Errorf("failed to create request: %w", err)
-		logger.LogLLMRequest(command, "", err)
 		return "", err
 	}
+	if !info.Known() {
+		return unknownName, nil
+	}
+	return info.Name, nil
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
-
-	logger.Debug("Sending LLM API request to %s with model %s", c.config.URL, c.config.Model)
+func (c *Client) describeService(command string) (ServiceInfo, error) {
+	logger.Debug("LLM rewrite request started for command: %s", command)
+	logger.Debug("Sending LLM request via %s", c.provider.Name())
 
-	resp, err := c.httpClient.Do(req)
+	reply, err := c.provider.Complete(buildPrompt(command), serviceSchema)
 	if err != nil {
-		err = fmt.Errorf("request failed: %w", err)
 		logger.LogLLMRequest(command, "", err)
-		return "", err
+		return ServiceInfo{}, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		err = fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(body))
-		logger.LogLLMRequest(command, "", err)
-		return "", err
+	info, err := parseServiceInfo(reply)
+	if errors.Is(err, errUnknownService) {
+		info, err = ServiceInfo{}, nil
 	}
-
-	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		err = fmt.Errorf("failed to read response: %w", err)
-		logger.LogLLMRequest(command, "", err)
-		return "", err
-	}
-
-	var chatResp ChatResponse
-	if err := json.Unmarshal(body, &chatResp); err != nil {
-		err = fmt.Errorf("failed to parse response: %w", err)
 		logger.LogLLMRequest(command, "", err)
-		return "", err
+		return ServiceInfo{}, err
 	}
 
-	if len(chatResp.Choices) == 0 {
-		err = fmt.Errorf("no response choices")
-		logger.LogLLMRequest(command, "", err)
-		return "", err
+	result := info.Name
+	if !info.Known() {
+		result = unknownName
+	} else if info.Category != "" {
+		result += " [" + info.Category + "]"
 	}
 
-	result := strings.TrimSpace(chatResp.Choices[0].Message.Content)
-
 	// Log successful request
 	logger.LogLLMRequest(command, result, nil)
 
 	// Also print to console for visibility
-	fmt.Printf("LLM Input: %s\n", command)
-	fmt.Printf("LLM Output: %s\n", result)
+	fmt.Fprintf(Console, "LLM Input: %s\n", command)
+	fmt.Fprintf(Console, "LLM Output: %s\n", strings.TrimSpace(reply))
 
-	return result, nil
+	return info, nil
 }
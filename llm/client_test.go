@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewClient_UnknownProvider(t *testing.T) {
+	if _, err := NewClient(&LLMSettings{Provider: "gemini"}); err == nil {
+		t.Error("NewClient() accepted an unknown provider")
+	}
+}
+
+func TestClient_RewriteProcessName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"choices":[{"message":{"content":"{\"service_name\":\"Vite\",\"category\":\"web-dev-server\",\"confidence\":0.9}"}}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		apiKey  string
+		want    string
+		wantErr bool
+	}{
+		{"success", "test-key", "Vite", false},
+		{"API error", "wrong-key", "", true},
+		{"not configured", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			successes, errors := llmRequests.Value("success"), llmRequests.Value("error")
+			observed := llmDuration.Count()
+
+			client, err := NewClient(&LLMSettings{URL: server.URL, APIKey: tt.apiKey, Model: "test"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := client.RewriteProcessName("node node_modules/.bin/vite")
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("RewriteProcessName() = %q, %v, want %q, error %v", got, err, tt.want, tt.wantErr)
+			}
+
+			wantSuccesses, wantErrors := 1.0, 0.0
+			if tt.wantErr {
+				wantSuccesses, wantErrors = 0, 1
+			}
+			if llmRequests.Value("success")-successes != wantSuccesses || llmRequests.Value("error")-errors != wantErrors {
+				t.Errorf("requests counted: success +%v, error +%v", llmRequests.Value("success")-successes, llmRequests.Value("error")-errors)
+			}
+			if llmDuration.Count()-observed != 1 {
+				t.Error("request duration not observed")
+			}
+		})
+	}
+}
+
+func TestClient_DescribeService(t *testing.T) {
+	tests := []struct {
+		name     string
+		answer   string
+		want     ServiceInfo
+		wantName string
+		wantErr  bool
+	}{
+		{
+			name:     "identified",
+			answer:   `{"service_name":"PostgreSQL","category":"database","framework":"","description":"Database server","confidence":0.99}`,
+			want:     ServiceInfo{Name: "PostgreSQL", Category: "database", Description: "Database server", Confidence: 0.99},
+			wantName: "PostgreSQL",
+		},
+		{
+			name:     "unidentified",
+			answer:   `{"service_name":"","category":"other","framework":"Node.js","description":"","confidence":0.1}`,
+			wantName: "未知",
+		},
+		{
+			name:    "not JSON",
+			answer:  "PostgreSQL",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, recorded := standIn(t, http.StatusOK, `{"choices":[{"message":{"content":`+strconv.Quote(tt.answer)+`}}]}`)
+			client, err := NewClient(&LLMSettings{URL: server.URL, APIKey: "k", Model: "gpt-4o-mini"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			info, err := client.DescribeService("postgres -D /data")
+			if (err != nil) != tt.wantErr || info != tt.want {
+				t.Errorf("DescribeService() = %+v, %v, want %+v, error %v", info, err, tt.want, tt.wantErr)
+			}
+			if format, ok := recorded.body["response_format"].(map[string]any); !ok || format["type"] != "json_schema" {
+				t.Errorf("response_format = %v, want a JSON schema", recorded.body["response_format"])
+			}
+
+			name, err := client.RewriteProcessName("postgres -D /data")
+			if (err != nil) != tt.wantErr || name != tt.wantName {
+				t.Errorf("RewriteProcessName() = %q, %v, want %q", name, err, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestClient_Console(t *testing.T) {
+	var console strings.Builder
+	saved := Console
+	Console = &console
+	defer func() { Console = saved }()
+
+	provider := &fakeProvider{reply: func(string, bool) (string, error) {
+		return `{"service_name":"Vite","category":"web-dev-server","confidence":0.9}`, nil
+	}}
+	client := &Client{provider: provider}
+	if _, err := client.DescribeService("node vite"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(console.String(), "LLM Input: node vite\n") {
+		t.Errorf("console = %q, want the request", console.String())
+	}
+}
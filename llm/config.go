@@ -7,17 +7,125 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
-// Config holds the LLM configuration
+// Config holds the application configuration stored in config.yaml
 type Config struct {
-	LLM LLMSettings `yaml:"llm"`
+	LLM     LLMSettings     `yaml:"llm"`
+	Scanner ScannerSettings `yaml:"scanner"`
+	Refresh RefreshSettings `yaml:"refresh"`
+	Notify  NotifySettings  `yaml:"notifications"`
+	API     APISettings     `yaml:"api"`
 }
 
 // LLMSettings contains the LLM-specific settings
 type LLMSettings struct {
-	Enabled bool   `yaml:"enabled"`
-	URL     string `yaml:"url"`
-	APIKey  string `yaml:"apikey"`
-	Model   string `yaml:"model"`
+	Enabled bool `yaml:"enabled"`
+	// Provider selects the API: "openai" (default), "openai-compatible",
+	// "anthropic" or "ollama"
+	Provider string `yaml:"provider"`
+	// URL and Model default to the provider's own when empty
+	URL    string `yaml:"url"`
+	APIKey string `yaml:"apikey"`
+	Model  string `yaml:"model"`
+	// Redact lists extra regular expressions removed from commands before
+	// they are sent, logged or cached, on top of the built-in secret rules
+	Redact []string `yaml:"redact,omitempty"`
+	// Normalize controls how commands are canonicalised into cache keys
+	Normalize NormalizeSettings `yaml:"normalize,omitempty"`
+}
+
+// NormalizeSettings turns built-in normalization rules off and adds custom ones
+type NormalizeSettings struct {
+	// Disable lists built-in rules: "version", "temp-dir", "uuid", "hash",
+	// "port" and "pid"
+	Disable []string        `yaml:"disable,omitempty"`
+	Rules   []NormalizeRule `yaml:"rules,omitempty"`
+}
+
+// NormalizeRule replaces matches of Pattern with Replace, which may refer to
+// capture groups as ${1}
+type NormalizeRule struct {
+	Pattern string `yaml:"pattern"`
+	Replace string `yaml:"replace"`
+}
+
+// ScannerSettings contains the port scanner settings
+type ScannerSettings struct {
+	// Backend forces a scanner backend: "auto", "procfs", "ss", "lsof" or "netstat"
+	// The others are still used as fallbacks when it is missing or fails
+	Backend string `yaml:"backend"`
+}
+
+// RefreshSettings controls background rescanning of ports
+type RefreshSettings struct {
+	// Interval between rescans in seconds; 0 disables background refresh
+	Interval int `yaml:"interval"`
+	// IdleAfter is how many seconds without keyboard or mouse input count as idle
+	IdleAfter int `yaml:"idle_after"`
+	// MaxInterval caps the rescan interval in seconds while backing off when idle
+	MaxInterval int `yaml:"max_interval"`
+}
+
+// NotifySettings controls desktop notifications about port changes
+// They are driven by background refresh and need a refresh interval above 0
+type NotifySettings struct {
+	Enabled bool `yaml:"enabled"`
+	// Events notified when no rule matches: "open", "close" and/or "replace"
+	Events []string     `yaml:"events"`
+	Rules  []NotifyRule `yaml:"rules"`
+	// Burst notifications may be shown at once, then one per RateInterval seconds
+	Burst        int `yaml:"burst"`
+	RateInterval int `yaml:"rate_interval"`
+}
+
+// NotifyRule matches events by port and process; the first matching rule decides
+type NotifyRule struct {
+	Port    int      `yaml:"port"`    // 0 matches any port
+	Process string   `yaml:"process"` // Glob on the process name
+	Events  []string `yaml:"events"`  // Empty matches all events
+	Exposed bool     `yaml:"exposed"` // Only listeners reachable from other machines
+	Notify  bool     `yaml:"notify"`  // false silences matching events
+}
+
+// APISettings controls the local HTTP API served by the menu bar app
+type APISettings struct {
+	Enabled bool `yaml:"enabled"`
+	// Socket is the Unix socket path; empty means ~/.config/port-digger/api.sock
+	Socket string `yaml:"socket"`
+	// Listen serves on a loopback TCP address instead, e.g. "127.0.0.1:7878"
+	Listen string `yaml:"listen"`
+	// Token TCP clients must send as "Authorization: Bearer <token>"
+	Token string `yaml:"token"`
+}
+
+// defaultConfig returns the configuration used when no config file exists
+func defaultConfig() *Config {
+	return &Config{
+		LLM: LLMSettings{
+			Enabled:  false,
+			Provider: "openai",
+			URL:      "https://api.openai.com/v1/chat/completions",
+			APIKey:   "",
+			Model:    "gpt-4o-mini",
+		},
+		Scanner: ScannerSettings{
+			Backend: "auto",
+		},
+		Refresh: RefreshSettings{
+			Interval:    10,
+			IdleAfter:   300,
+			MaxInterval: 120,
+		},
+		Notify: NotifySettings{
+			Enabled: true,
+			Events:  []string{"close", "replace"},
+			Rules: []NotifyRule{
+				// Something new listening on all interfaces is worth knowing about
+				{Events: []string{"open"}, Exposed: true, Notify: true},
+			},
+			Burst:        5,
+			RateInterval: 60,
+		},
+	}
 }
 
 // configDir returns the configuration directory path
@@ -48,7 +156,8 @@ func ensureConfigDir() error {
 }
 
 // LoadConfig loads the configuration from disk
-// Returns default config if file doesn't exist
+// Returns default config if file doesn't exist; sections missing from the
+// file keep their defaults
 func LoadConfig() (*Config, error) {
 	path, err := ConfigPath()
 	if err != nil {
@@ -59,24 +168,17 @@ func LoadConfig() (*Config, error) {
 	if err != nil {
 		if os.IsNotExist(err) {
 			// Return default config
-			return &Config{
-				LLM: LLMSettings{
-					Enabled: false,
-					URL:     "https://api.openai.com/v1/chat/completions",
-					APIKey:  "",
-					Model:   "gpt-4o-mini",
-				},
-			}, nil
+			return defaultConfig(), nil
 		}
 		return nil, err
 	}
 
-	var config Config
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	config := defaultConfig()
+	if err := yaml.Unmarshal(data, config); err != nil {
 		return nil, err
 	}
 
-	return &config, nil
+	return config, nil
 }
 
 // SaveConfig saves the configuration to disk
@@ -111,14 +213,5 @@ func EnsureDefaultConfig() error {
 	}
 
 	// Create default config
-	defaultConfig := &Config{
-		LLM: LLMSettings{
-			Enabled: false,
-			URL:     "https://api.openai.com/v1/chat/completions",
-			APIKey:  "",
-			Model:   "gpt-4o-mini",
-		},
-	}
-
-	return SaveConfig(defaultConfig)
+	return SaveConfig(defaultConfig())
 }
@@ -70,3 +70,31 @@ func TestConfig_RoundTrip(t *testing.T) {
 		t.Errorf("Expected Model to be llama3.2, got %s", config.LLM.Model)
 	}
 }
+
+func TestLoadConfig_MissingSectionsUseDefaults(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".config", "port-digger")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := "llm:\n  enabled: true\n  model: llama3.2\nrefresh:\n  interval: 30\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !config.LLM.Enabled || config.LLM.Model != "llama3.2" {
+		t.Errorf("LLM = %+v, want values from file", config.LLM)
+	}
+	if config.LLM.URL == "" || config.Scanner.Backend != "auto" {
+		t.Errorf("missing keys not defaulted: URL = %q, Backend = %q", config.LLM.URL, config.Scanner.Backend)
+	}
+	if config.Refresh.Interval != 30 || config.Refresh.IdleAfter != 300 || config.Refresh.MaxInterval != 120 {
+		t.Errorf("Refresh = %+v, want interval from file and default idle settings", config.Refresh)
+	}
+}
@@ -0,0 +1,73 @@
+package llm
+
+import "port-digger/logger"
+
+// openCache loads the cache and moves entries to the keys given by key, as
+// written by older versions that kept secrets, ports and versions in the
+// keys; the cache file is rewritten when any key changed. When keys collide,
+// an entry already under the new key wins
+func openCache(key func(command string) string) (*Cache, error) {
+	cache, err := LoadCache()
+	if err != nil {
+		return nil, err
+	}
+	if n := cache.Rekey(key); n > 0 {
+		logger.Info("Migrated %d LLM cache keys", n)
+		if err := cache.Save(); err != nil {
+			return nil, err
+		}
+	}
+	return cache, nil
+}
+
+// Canonicalizer turns commands into the form sent to the LLM and used as
+// cache keys: redacted first, then normalized
+type Canonicalizer struct {
+	redactor   *Redactor
+	normalizer *Normalizer
+}
+
+// NewCanonicalizer builds the configured redaction and normalization rules;
+// it needs no provider, so it works with the LLM disabled
+func NewCanonicalizer(config *LLMSettings) (*Canonicalizer, error) {
+	redactor, err := NewRedactor(config.Redact)
+	if err != nil {
+		return nil, err
+	}
+	normalizer, err := NewNormalizer(config.Normalize)
+	if err != nil {
+		return nil, err
+	}
+	return &Canonicalizer{redactor: redactor, normalizer: normalizer}, nil
+}
+
+// Canonical returns the canonical form of command; a nil Canonicalizer
+// applies the built-in rules
+func (c *Canonicalizer) Canonical(command string) string {
+	if c == nil {
+		return (*Normalizer)(nil).Normalize((*Redactor)(nil).Redact(command))
+	}
+	return c.normalizer.Normalize(c.redactor.Redact(command))
+}
+
+// CachedNames returns a lookup of cached service names for commands that
+// never ask the LLM, like list and serve. It works with the LLM disabled and
+// canonicalizes commands with the configured rules, as the client does when
+// it stores names
+func CachedNames() (func(command string) string, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	keys, err := NewCanonicalizer(&config.LLM)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := openCache(keys.Canonical)
+	if err != nil {
+		return nil, err
+	}
+	return func(command string) string {
+		return cache.Get(keys.Canonical(command))
+	}, nil
+}
@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// normalizeRules canonicalise the parts of a command that change between
+// runs without changing what runs, so the cache key stays the same. Rules
+// sharing a name are disabled together
+var normalizeRules = []redactRule{
+	// /opt/homebrew/Cellar/node/22.3.0/bin, ~/.nvm/versions/node/v20.11.0/bin
+	{"version", regexp.MustCompile(`(/)v?\d+(?:\.\d+)+(?:[-+_][0-9A-Za-z.]*)?(/)`), "${1}<version>${2}"},
+	// $TMPDIR on macOS, /tmp/tmp.XXXX, /tmp/go-build123
+	{"temp-dir", regexp.MustCompile(`(?:/private)?/var/folders/[^/\s]+/[^/\s]+/[TC]\b`), "<tmp>"},
+	{"temp-dir", regexp.MustCompile(`(?:/private)?/tmp/[^/\s]+`), "<tmp>"},
+	{"uuid", regexp.MustCompile(`\b[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}\b`), "<uuid>"},
+	{"hash", regexp.MustCompile(`\b[0-9a-fA-F]{16,}\b`), "<hash>"},
+	// --port 51234, --inspect-port=9229, -p 8080, PORT=3000
+	{"port", regexp.MustCompile(`(?i)((?:^|\s)--?(?:[\w-]*-)?port[=\s]|(?:^|\s)-p\s?|\bport=)\d{1,5}\b`), "${1}<port>"},
+	// 127.0.0.1:51234, localhost:3000, *:6379, [::1]:8080
+	{"port", regexp.MustCompile(`((?:^|[\s=/@])(?:localhost|\d{1,3}(?:\.\d{1,3}){3}|\[[0-9a-fA-F:]+\]|\*)?):\d{1,5}\b`), "${1}:<port>"},
+	// --clientProcessId=4242, --parent-pid 1
+	{"pid", regexp.MustCompile(`(?i)((?:^|\s)--?[\w-]*(?:pid|process-?id)[=\s])\d+\b`), "${1}<pid>"},
+}
+
+// NormalizeRuleNames returns the names of the built-in normalization rules
+func NormalizeRuleNames() []string {
+	var names []string
+	for _, rule := range normalizeRules {
+		if !slices.Contains(names, rule.name) {
+			names = append(names, rule.name)
+		}
+	}
+	return names
+}
+
+// Normalizer turns commands into cache keys that survive restarts
+type Normalizer struct {
+	rules []redactRule
+}
+
+// NewNormalizer returns a normalizer with the built-in rules not disabled in
+// settings, followed by its custom rules
+func NewNormalizer(settings NormalizeSettings) (*Normalizer, error) {
+	names := NormalizeRuleNames()
+	for _, name := range settings.Disable {
+		if !slices.Contains(names, name) {
+			return nil, fmt.Errorf("unknown normalization rule %q (valid: %v)", name, names)
+		}
+	}
+
+	var rules []redactRule
+	for _, rule := range normalizeRules {
+		if !slices.Contains(settings.Disable, rule.name) {
+			rules = append(rules, rule)
+		}
+	}
+	for _, custom := range settings.Rules {
+		re, err := regexp.Compile(custom.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid normalization pattern %q: %w", custom.Pattern, err)
+		}
+		rules = append(rules, redactRule{name: custom.Pattern, re: re, replacement: custom.Replace})
+	}
+	return &Normalizer{rules: rules}, nil
+}
+
+// Normalize returns the canonical form of command; a nil Normalizer applies
+// all built-in rules
+func (n *Normalizer) Normalize(command string) string {
+	rules := normalizeRules
+	if n != nil {
+		rules = n.rules
+	}
+	for _, rule := range rules {
+		command = rule.re.ReplaceAllString(command, rule.replacement)
+	}
+	return command
+}
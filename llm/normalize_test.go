@@ -0,0 +1,227 @@
+package llm
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNormalizer_Builtin(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{
+			name:    "stable command",
+			command: "postgres -D /opt/homebrew/var/postgresql@16",
+			want:    "postgres -D /opt/homebrew/var/postgresql@16",
+		},
+		{
+			name:    "port flag",
+			command: "node server.js --port 51234",
+			want:    "node server.js --port <port>",
+		},
+		{
+			name:    "port flag with equals",
+			command: "node --inspect-port=9229 server.js --port=3000",
+			want:    "node --inspect-port=<port> server.js --port=<port>",
+		},
+		{
+			name:    "short port flag",
+			command: "python -m http.server -p 8000",
+			want:    "python -m http.server -p <port>",
+		},
+		{
+			name:    "port variable",
+			command: "env PORT=3000 next dev",
+			want:    "env PORT=<port> next dev",
+		},
+		{
+			name:    "host and port",
+			command: "redis-server *:6379 --replicaof 127.0.0.1:6380 --bind [::1]:8080",
+			want:    "redis-server *:<port> --replicaof 127.0.0.1:<port> --bind [::1]:<port>",
+		},
+		{
+			name:    "bare port after equals",
+			command: "node --inspect=:9229 app.js",
+			want:    "node --inspect=:<port> app.js",
+		},
+		{
+			name:    "URL keeps its scheme",
+			command: "proxy --upstream http://localhost:8080/api",
+			want:    "proxy --upstream http://localhost:<port>/api",
+		},
+		{
+			name:    "process id",
+			command: "Code Helper --clientProcessId=4242 --parent-pid 1",
+			want:    "Code Helper --clientProcessId=<pid> --parent-pid <pid>",
+		},
+		{
+			name:    "pid file is not a pid",
+			command: "nginx --pidfile=/var/run/nginx.pid",
+			want:    "nginx --pidfile=/var/run/nginx.pid",
+		},
+		{
+			name:    "Homebrew Cellar",
+			command: "/opt/homebrew/Cellar/node/22.3.0/bin/node server.js",
+			want:    "/opt/homebrew/Cellar/node/<version>/bin/node server.js",
+		},
+		{
+			name:    "nvm",
+			command: "~/.nvm/versions/node/v20.11.0/bin/node app.js",
+			want:    "~/.nvm/versions/node/<version>/bin/node app.js",
+		},
+		{
+			name:    "pre-release version",
+			command: "/usr/local/Cellar/deno/2.0.0-rc.1/bin/deno run main.ts",
+			want:    "/usr/local/Cellar/deno/<version>/bin/deno run main.ts",
+		},
+		{
+			name:    "macOS temp dir",
+			command: "/private/var/folders/x7/k2j3h4g5/T/go-build123/b001/exe/main",
+			want:    "<tmp>/go-build123/b001/exe/main",
+		},
+		{
+			name:    "tmp dir",
+			command: "/tmp/tmp.Xk3b9/server --config /tmp/tmp.Xk3b9/cfg.json",
+			want:    "<tmp>/server --config <tmp>/cfg.json",
+		},
+		{
+			name:    "UUID",
+			command: "worker --session 3f2b8c1e-9a4d-4e6f-8b2a-1c3d5e7f9a0b",
+			want:    "worker --session <uuid>",
+		},
+		{
+			name:    "hash",
+			command: "~/.vscode-server/bin/e170252f762678dec6ca2cc69aba1570769a5d39/node",
+			want:    "~/.vscode-server/bin/<hash>/node",
+		},
+		{
+			name:    "short hex is not a hash",
+			command: "app --color ff00aa --build 2024",
+			want:    "app --color ff00aa --build 2024",
+		},
+	}
+
+	var n *Normalizer // The built-in rules
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := n.Normalize(tt.command)
+			if got != tt.want {
+				t.Errorf("Normalize(%q)\n got %q\nwant %q", tt.command, got, tt.want)
+			}
+			if again := n.Normalize(got); again != got {
+				t.Errorf("Normalize() is not idempotent: %q became %q", got, again)
+			}
+		})
+	}
+}
+
+func TestNormalizer_Settings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings NormalizeSettings
+		command  string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "disabled rule",
+			settings: NormalizeSettings{Disable: []string{"port"}},
+			command:  "node server.js --port 51234 --session 3f2b8c1e-9a4d-4e6f-8b2a-1c3d5e7f9a0b",
+			want:     "node server.js --port 51234 --session <uuid>",
+		},
+		{
+			name:     "custom rule with group",
+			settings: NormalizeSettings{Rules: []NormalizeRule{{Pattern: `(--worker=)\d+`, Replace: "${1}<n>"}}},
+			command:  "gunicorn app:wsgi --worker=7",
+			want:     "gunicorn app:wsgi --worker=<n>",
+		},
+		{
+			name:     "unknown rule",
+			settings: NormalizeSettings{Disable: []string{"ports"}},
+			wantErr:  `unknown normalization rule "ports"`,
+		},
+		{
+			name:     "invalid pattern",
+			settings: NormalizeSettings{Rules: []NormalizeRule{{Pattern: `[`}}},
+			wantErr:  `invalid normalization pattern "["`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := NewNormalizer(tt.settings)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("NewNormalizer() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := n.Normalize(tt.command); got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeRuleNames(t *testing.T) {
+	want := []string{"version", "temp-dir", "uuid", "hash", "port", "pid"}
+	if got := NormalizeRuleNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("NormalizeRuleNames() = %v, want %v", got, want)
+	}
+}
+
+func TestNewRewriter_MigratesCacheKeys(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".config", "port-digger")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	config := "llm:\n  enabled: true\n  apikey: k\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	legacy := `{
+  "node server.js --port 51234": "Express",
+  "node server.js --port 40000": "Express",
+  "/opt/homebrew/Cellar/redis/7.2.4/bin/redis-server --requirepass x": "Redis",
+  "mysqld --password=hunter2": "MySQL"
+}`
+	if err := os.WriteFile(filepath.Join(dir, "cache.json"), []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRewriter()
+	if err != nil {
+		t.Fatalf("NewRewriter() error = %v", err)
+	}
+	if got := r.GetServiceName("node server.js --port 61000"); got != "Express" {
+		t.Errorf("GetServiceName() = %q for a new port, want Express", got)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved map[string]ServiceInfo
+	if err := json.Unmarshal(data, &saved); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]ServiceInfo{
+		"node server.js --port <port>":                                          {Name: "Express"},
+		"/opt/homebrew/Cellar/redis/<version>/bin/redis-server --requirepass x": {Name: "Redis"},
+		"mysqld --password=[REDACTED]":                                          {Name: "MySQL"},
+	}
+	if !reflect.DeepEqual(saved, want) {
+		t.Errorf("saved cache = %v, want %v", saved, want)
+	}
+}
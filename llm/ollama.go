@@ -0,0 +1,47 @@
+package llm
+
+import "fmt"
+
+type ollamaRequest struct {
+	Model    string         `json:"model"`
+	Messages []ChatMessage  `json:"messages"`
+	Stream   bool           `json:"stream"`
+	Format   map[string]any `json:"format,omitempty"` // JSON schema of the reply
+}
+
+type ollamaResponse struct {
+	Message ChatMessage `json:"message"`
+}
+
+// ollamaProvider speaks the /api/chat endpoint of an Ollama server; a local
+// one needs no API key, a key is sent as a bearer token for proxied ones
+type ollamaProvider struct {
+	endpoint
+}
+
+func (p *ollamaProvider) Complete(prompt string, schema map[string]any) (string, error) {
+	if err := p.check(false); err != nil {
+		return "", err
+	}
+
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+	var resp ollamaResponse
+	err := p.postJSON(headers, ollamaRequest{
+		Model:    p.model,
+		Messages: []ChatMessage{{Role: "user", Content: prompt}},
+		// One JSON object instead of a stream of chunks
+		Stream: false,
+		Format: schema,
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	if resp.Message.Content == "" {
+		return "", fmt.Errorf("empty response message")
+	}
+	return resp.Message.Content, nil
+}
@@ -0,0 +1,77 @@
+package llm
+
+import "fmt"
+
+// ChatMessage represents a message in the chat format
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// ChatRequest represents the request body for the chat API
+type ChatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []ChatMessage   `json:"messages"`
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+}
+
+// ResponseFormat asks for a reply matching a JSON schema
+type ResponseFormat struct {
+	Type       string     `json:"type"` // "json_schema"
+	JSONSchema JSONSchema `json:"json_schema"`
+}
+
+// JSONSchema names a schema for ResponseFormat
+type JSONSchema struct {
+	Name   string         `json:"name"`
+	Strict bool           `json:"strict"`
+	Schema map[string]any `json:"schema"`
+}
+
+// ChatResponse represents the response from the chat API
+type ChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+	} `json:"choices"`
+}
+
+// openAIProvider speaks the chat completions API of OpenAI and the many
+// servers that copy it
+type openAIProvider struct {
+	endpoint
+	requireKey bool
+	structured bool // Supports response_format with json_schema
+}
+
+func (p *openAIProvider) Complete(prompt string, schema map[string]any) (string, error) {
+	if err := p.check(p.requireKey); err != nil {
+		return "", err
+	}
+
+	headers := map[string]string{}
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
+	}
+	req := ChatRequest{
+		Model:    p.model,
+		Messages: []ChatMessage{{Role: "user", Content: prompt}},
+	}
+	if schema != nil && p.structured {
+		req.ResponseFormat = &ResponseFormat{
+			Type:       "json_schema",
+			JSONSchema: JSONSchema{Name: "answer", Strict: true, Schema: schema},
+		}
+	}
+	var resp ChatResponse
+	err := p.postJSON(headers, req, &resp)
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response choices")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,127 @@
+package llm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Provider sends a prompt to one kind of LLM API and returns the reply
+type Provider interface {
+	// Name identifies the provider in logs and config, e.g. "anthropic"
+	Name() string
+	// Complete sends prompt as a single user message and returns the reply
+	// text. With a schema the reply should be a JSON object matching it;
+	// providers that cannot enforce one rely on the prompt asking for it
+	Complete(prompt string, schema map[string]any) (string, error)
+}
+
+// providerDefaults are the URL and model each provider uses when config.yaml
+// leaves them empty or at the OpenAI values of defaultConfig. OpenAI-compatible
+// servers have no defaults; both must be configured
+var providerDefaults = map[string]struct{ url, model string }{
+	"openai":            {"https://api.openai.com/v1/chat/completions", "gpt-4o-mini"},
+	"openai-compatible": {"", ""},
+	"anthropic":         {"https://api.anthropic.com/v1/messages", "claude-3-5-haiku-latest"},
+	"ollama":            {"http://localhost:11434/api/chat", "llama3.2"},
+}
+
+// ProviderNames returns the accepted values of the provider key
+func ProviderNames() []string {
+	names := make([]string, 0, len(providerDefaults))
+	for name := range providerDefaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewProvider returns the provider selected by settings.Provider, "openai" if empty
+func NewProvider(settings LLMSettings, httpClient *http.Client) (Provider, error) {
+	name := settings.Provider
+	if name == "" {
+		name = "openai"
+	}
+	defaults, ok := providerDefaults[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM provider %q: use %s", name, strings.Join(ProviderNames(), ", "))
+	}
+
+	openAI := providerDefaults["openai"]
+	url, model := settings.URL, settings.Model
+	if defaults.url != "" && (url == "" || url == openAI.url) {
+		url = defaults.url
+	}
+	if defaults.model != "" && (model == "" || model == openAI.model) {
+		model = defaults.model
+	}
+
+	base := endpoint{name: name, url: url, apiKey: settings.APIKey, model: model, http: httpClient}
+	switch name {
+	case "anthropic":
+		return &anthropicProvider{base}, nil
+	case "ollama":
+		return &ollamaProvider{base}, nil
+	case "openai-compatible":
+		// Local servers like LM Studio or vLLM often need no key
+		return &openAIProvider{endpoint: base}, nil
+	}
+	return &openAIProvider{endpoint: base, requireKey: true, structured: true}, nil
+}
+
+// endpoint holds what every provider needs to make a request
+type endpoint struct {
+	name   string
+	url    string
+	apiKey string
+	model  string
+	http   *http.Client
+}
+
+func (e endpoint) Name() string { return e.name }
+
+// check reports missing settings before a request is made
+func (e endpoint) check(requireKey bool) error {
+	if e.url == "" || e.model == "" || (requireKey && e.apiKey == "") {
+		return fmt.Errorf("LLM not configured")
+	}
+	return nil
+}
+
+// postJSON sends body to the endpoint and decodes a 200 response into out
+func (e endpoint) postJSON(headers map[string]string, body, out any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
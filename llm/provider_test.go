@@ -0,0 +1,264 @@
+package llm
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// recordedRequest is what a stand-in server received
+type recordedRequest struct {
+	path    string
+	headers http.Header
+	body    map[string]any
+}
+
+// standIn starts a server that records the request and answers with status and reply
+func standIn(t *testing.T, status int, reply string) (*httptest.Server, *recordedRequest) {
+	t.Helper()
+	recorded := &recordedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		recorded.path, recorded.headers = r.URL.Path, r.Header
+		if err := json.Unmarshal(data, &recorded.body); err != nil {
+			t.Errorf("request body is not JSON: %s", data)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, recorded
+}
+
+func TestProviders(t *testing.T) {
+	messages := []any{map[string]any{"role": "user", "content": "which service?"}}
+
+	tests := []struct {
+		name        string
+		settings    LLMSettings
+		reply       string
+		want        string
+		wantHeaders map[string]string // "" means the header must be absent
+		wantBody    map[string]any
+	}{
+		{
+			name:        "openai",
+			settings:    LLMSettings{Provider: "openai", APIKey: "sk-test", Model: "gpt-4o-mini"},
+			reply:       `{"choices":[{"message":{"role":"assistant","content":"Vite"}}]}`,
+			want:        "Vite",
+			wantHeaders: map[string]string{"Authorization": "Bearer sk-test", "Content-Type": "application/json"},
+			wantBody:    map[string]any{"model": "gpt-4o-mini", "messages": messages},
+		},
+		{
+			name:        "openai-compatible without key",
+			settings:    LLMSettings{Provider: "openai-compatible", Model: "qwen2.5"},
+			reply:       `{"choices":[{"message":{"content":"Jupyter"}}]}`,
+			want:        "Jupyter",
+			wantHeaders: map[string]string{"Authorization": ""},
+			wantBody:    map[string]any{"model": "qwen2.5", "messages": messages},
+		},
+		{
+			name:     "anthropic",
+			settings: LLMSettings{Provider: "anthropic", APIKey: "sk-ant-test", Model: "claude-3-5-haiku-latest"},
+			reply: `{"id":"msg_1","type":"message","role":"assistant","content":[` +
+				`{"type":"thinking","thinking":"node a.js..."},{"type":"text","text":"Post"},{"type":"text","text":"gres"}],"stop_reason":"end_turn"}`,
+			want: "Postgres",
+			wantHeaders: map[string]string{
+				"X-Api-Key":         "sk-ant-test",
+				"Anthropic-Version": "2023-06-01",
+				"Authorization":     "",
+			},
+			wantBody: map[string]any{"model": "claude-3-5-haiku-latest", "max_tokens": float64(anthropicMaxTokens), "messages": messages},
+		},
+		{
+			name:        "ollama",
+			settings:    LLMSettings{Provider: "ollama", Model: "llama3.2"},
+			reply:       `{"model":"llama3.2","message":{"role":"assistant","content":"Redis"},"done":true}`,
+			want:        "Redis",
+			wantHeaders: map[string]string{"Authorization": ""},
+			wantBody:    map[string]any{"model": "llama3.2", "messages": messages, "stream": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, recorded := standIn(t, http.StatusOK, tt.reply)
+			tt.settings.URL = server.URL + "/v1/endpoint"
+
+			provider, err := NewProvider(tt.settings, server.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := provider.Complete("which service?", nil)
+			if err != nil || got != tt.want {
+				t.Fatalf("Complete() = %q, %v, want %q", got, err, tt.want)
+			}
+
+			if recorded.path != "/v1/endpoint" {
+				t.Errorf("path = %q", recorded.path)
+			}
+			for header, want := range tt.wantHeaders {
+				if got := recorded.headers.Get(header); got != want {
+					t.Errorf("header %s = %q, want %q", header, got, want)
+				}
+			}
+			if !reflect.DeepEqual(recorded.body, tt.wantBody) {
+				t.Errorf("body = %v, want %v", recorded.body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestProviders_Schema(t *testing.T) {
+	schema := map[string]any{"type": "object", "properties": map[string]any{"service_name": map[string]any{"type": "string"}}}
+	answer := `{"service_name":"Vite"}`
+
+	tests := []struct {
+		provider string
+		reply    string
+		check    func(t *testing.T, body map[string]any)
+	}{
+		{
+			provider: "openai",
+			reply:    `{"choices":[{"message":{"content":` + strconv.Quote(answer) + `}}]}`,
+			check: func(t *testing.T, body map[string]any) {
+				format, _ := body["response_format"].(map[string]any)
+				spec, _ := format["json_schema"].(map[string]any)
+				if format["type"] != "json_schema" || spec["strict"] != true || !reflect.DeepEqual(spec["schema"], schema) {
+					t.Errorf("response_format = %v", body["response_format"])
+				}
+			},
+		},
+		{
+			provider: "openai-compatible",
+			reply:    `{"choices":[{"message":{"content":` + strconv.Quote(answer) + `}}]}`,
+			check: func(t *testing.T, body map[string]any) {
+				if _, ok := body["response_format"]; ok {
+					t.Error("response_format sent to a server that may not support it")
+				}
+			},
+		},
+		{
+			provider: "anthropic",
+			reply:    `{"content":[{"type":"tool_use","id":"toolu_1","name":"answer","input":` + answer + `}],"stop_reason":"tool_use"}`,
+			check: func(t *testing.T, body map[string]any) {
+				tools, _ := body["tools"].([]any)
+				if len(tools) != 1 || !reflect.DeepEqual(tools[0].(map[string]any)["input_schema"], schema) {
+					t.Errorf("tools = %v", body["tools"])
+				}
+				if choice, _ := body["tool_choice"].(map[string]any); choice["type"] != "tool" || choice["name"] != "answer" {
+					t.Errorf("tool_choice = %v", body["tool_choice"])
+				}
+			},
+		},
+		{
+			provider: "ollama",
+			reply:    `{"message":{"role":"assistant","content":` + strconv.Quote(answer) + `},"done":true}`,
+			check: func(t *testing.T, body map[string]any) {
+				if !reflect.DeepEqual(body["format"], schema) {
+					t.Errorf("format = %v", body["format"])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			server, recorded := standIn(t, http.StatusOK, tt.reply)
+			provider, err := NewProvider(LLMSettings{Provider: tt.provider, URL: server.URL, APIKey: "k", Model: "m"}, server.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := provider.Complete("which service?", schema)
+			if err != nil || got != answer {
+				t.Fatalf("Complete() = %q, %v, want %q", got, err, answer)
+			}
+			tt.check(t, recorded.body)
+		})
+	}
+}
+
+func TestProviders_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider string
+		apiKey   string
+		status   int
+		reply    string
+		wantErr  string
+	}{
+		{"openai needs a key", "openai", "", http.StatusOK, `{}`, "LLM not configured"},
+		{"anthropic needs a key", "anthropic", "", http.StatusOK, `{}`, "LLM not configured"},
+		{"API error", "anthropic", "k", http.StatusUnauthorized,
+			`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`, "status 401"},
+		{"no choices", "openai", "k", http.StatusOK, `{"choices":[]}`, "no response choices"},
+		{"no text block", "anthropic", "k", http.StatusOK, `{"content":[{"type":"tool_use"}]}`, "no text content"},
+		{"empty message", "ollama", "", http.StatusOK, `{"message":{"role":"assistant","content":""}}`, "empty response"},
+		{"not JSON", "ollama", "", http.StatusOK, `<html>`, "failed to parse response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := standIn(t, tt.status, tt.reply)
+			provider, err := NewProvider(LLMSettings{Provider: tt.provider, URL: server.URL, APIKey: tt.apiKey, Model: "m"}, server.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := provider.Complete("x", nil); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Complete() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewProvider_Defaults(t *testing.T) {
+	openAI := defaultConfig().LLM
+
+	tests := []struct {
+		name      string
+		settings  LLMSettings
+		wantName  string
+		wantURL   string
+		wantModel string
+	}{
+		{"empty means openai", LLMSettings{}, "openai", "https://api.openai.com/v1/chat/completions", "gpt-4o-mini"},
+		{"anthropic replaces the OpenAI defaults",
+			LLMSettings{Provider: "anthropic", URL: openAI.URL, Model: openAI.Model},
+			"anthropic", "https://api.anthropic.com/v1/messages", "claude-3-5-haiku-latest"},
+		{"ollama keeps explicit values",
+			LLMSettings{Provider: "ollama", URL: "http://gpu-box:11434/api/chat", Model: "qwen2.5"},
+			"ollama", "http://gpu-box:11434/api/chat", "qwen2.5"},
+		{"openai-compatible has no defaults", LLMSettings{Provider: "openai-compatible"}, "openai-compatible", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewProvider(tt.settings, http.DefaultClient)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var e endpoint
+			switch p := provider.(type) {
+			case *openAIProvider:
+				e = p.endpoint
+			case *anthropicProvider:
+				e = p.endpoint
+			case *ollamaProvider:
+				e = p.endpoint
+			}
+			if provider.Name() != tt.wantName || e.url != tt.wantURL || e.model != tt.wantModel {
+				t.Errorf("got %s %q %q, want %s %q %q", provider.Name(), e.url, e.model, tt.wantName, tt.wantURL, tt.wantModel)
+			}
+		})
+	}
+
+	if _, err := NewProvider(LLMSettings{Provider: "gemini"}, http.DefaultClient); err == nil ||
+		!strings.Contains(err.Error(), "anthropic, ollama, openai, openai-compatible") {
+		t.Errorf("unknown provider error = %v", err)
+	}
+}
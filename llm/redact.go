@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// redacted replaces secrets in commands
+const redacted = "[REDACTED]"
+
+// redactRule replaces the matches of re with replacement, which may refer to
+// capture groups to keep e.g. the name of a flag
+type redactRule struct {
+	name        string
+	re          *regexp.Regexp
+	replacement string
+}
+
+// secretNames match option and variable names whose values are secrets
+const secretNames = `[\w.-]*(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?|auth)[\w.-]*`
+
+// builtinRules run in order before the configured patterns. Rules for
+// specific token formats come first so a key=value rule keeps its name
+var builtinRules = []redactRule{
+	{"url-userinfo", regexp.MustCompile(`\b([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s@]+@`), "${1}" + redacted + "@"},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), redacted},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[A-Z0-9]{16}\b`), redacted},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`), redacted},
+	{"api-token", regexp.MustCompile(`\b(?:sk-(?:ant-)?[A-Za-z0-9_-]{20,}|xox[abprs]-[A-Za-z0-9-]{10,}|AIza[0-9A-Za-z_-]{35}|[rs]k_(?:live|test)_[A-Za-z0-9]{16,})`), redacted},
+	{"bearer", regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9._~+/=-]+`), "${1}" + redacted},
+	// --password=x, PGPASSWORD=x, api_key:x
+	{"key-value", regexp.MustCompile(`(?i)\b(` + secretNames + `)([=:])\S+`), "${1}${2}" + redacted},
+	// --password x; the value must not look like the next option
+	{"flag-value", regexp.MustCompile(`(?i)(^|\s)(--?` + secretNames + `)\s+([^\s-]\S*)`), "${1}${2} " + redacted},
+	{"home-directory", regexp.MustCompile(`(^|[\s=:"'])(?:/Users|/home)/[^/\s]+`), "${1}~"},
+}
+
+// Redactor removes secrets and personal paths from command lines before they
+// are sent to an LLM, logged or stored as cache keys
+type Redactor struct {
+	rules []redactRule
+}
+
+// NewRedactor returns a redactor with the built-in rules plus patterns,
+// regular expressions whose matches are replaced as a whole
+func NewRedactor(patterns []string) (*Redactor, error) {
+	rules := append([]redactRule{}, builtinRules...)
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		rules = append(rules, redactRule{name: pattern, re: re, replacement: redacted})
+	}
+	return &Redactor{rules: rules}, nil
+}
+
+// Redact returns command with every rule applied; a nil Redactor applies
+// the built-in rules
+func (r *Redactor) Redact(command string) string {
+	rules := builtinRules
+	if r != nil {
+		rules = r.rules
+	}
+	for _, rule := range rules {
+		command = rule.re.ReplaceAllString(command, rule.replacement)
+	}
+	return command
+}
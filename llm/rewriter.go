@@ -1,15 +1,34 @@
 package llm
 
 import (
+	"fmt"
+	"port-digger/logger"
 	"sync"
+	"time"
 )
 
+// batchWindow is how long TriggerRewrite collects commands before asking the
+// LLM about all of them in one request; a menu build triggers its commands
+// well within it
+const batchWindow = 300 * time.Millisecond
+
+// maxBatchSize bounds the commands per request, keeping prompts and replies
+// short enough for small models
+const maxBatchSize = 20
+
 // Rewriter orchestrates LLM-based process name rewriting with caching
 type Rewriter struct {
 	config  *Config
 	client  *Client
 	cache   *Cache
 	pending sync.Map // tracks in-flight requests to avoid duplicates
+
+	window   time.Duration
+	maxBatch int
+
+	mu        sync.Mutex
+	queue     []string // Commands waiting for the batch window to end
+	onRewrite func()   // Called after new service names were cached
 }
 
 // NewRewriter creates a new rewriter instance
@@ -19,20 +38,31 @@ func NewRewriter() (*Rewriter, error) {
 		return nil, err
 	}
 
-	cache, err := LoadCache()
+	// Cache keys are migrated even while the LLM is disabled, so secrets do
+	// not stay on disk
+	keys, err := NewCanonicalizer(&config.LLM)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := openCache(keys.Canonical)
 	if err != nil {
 		return nil, err
 	}
 
 	var client *Client
 	if config.LLM.Enabled {
-		client = NewClient(&config.LLM)
+		client, err = NewClient(&config.LLM)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &Rewriter{
-		config: config,
-		client: client,
-		cache:  cache,
+		config:   config,
+		client:   client,
+		cache:    cache,
+		window:   batchWindow,
+		maxBatch: maxBatchSize,
 	}, nil
 }
 
@@ -47,15 +77,35 @@ func (r *Rewriter) GetServiceName(command string) string {
 	if !r.IsEnabled() {
 		return ""
 	}
-	return r.cache.Get(command)
+	return r.cache.Get(r.client.Canonical(command))
+}
+
+// GetServiceInfo returns the cached service details for a command
+func (r *Rewriter) GetServiceInfo(command string) (ServiceInfo, bool) {
+	if !r.IsEnabled() {
+		return ServiceInfo{}, false
+	}
+	return r.cache.GetInfo(r.client.Canonical(command))
+}
+
+// OnRewrite sets fn to be called after a batch of rewrites cached new service
+// names, so a menu built before they were known can be rendered again
+func (r *Rewriter) OnRewrite(fn func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onRewrite = fn
 }
 
-// TriggerRewrite starts an async background rewrite for the given command
+// TriggerRewrite queues an async background rewrite for the given command
+// Commands queued within the batch window are sent in one request
 // If the command is already cached or a request is in-flight, this is a no-op
 func (r *Rewriter) TriggerRewrite(command string) {
 	if !r.IsEnabled() {
 		return
 	}
+	// Secrets never reach the cache file, the queue or the LLM, and commands
+	// differing only in ports or temp dirs share one entry
+	command = r.client.Canonical(command)
 
 	// Already cached
 	if r.cache.Has(command) {
@@ -67,23 +117,80 @@ func (r *Rewriter) TriggerRewrite(command string) {
 		return
 	}
 
-	// Start async rewrite
-	go func() {
-		defer r.pending.Delete(command)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queue = append(r.queue, command)
+	if len(r.queue) == 1 {
+		// The first command of a batch opens the window
+		time.AfterFunc(r.window, r.flush)
+	}
+}
+
+// flush sends the queued commands, one request per maxBatch commands
+func (r *Rewriter) flush() {
+	r.mu.Lock()
+	queue := r.queue
+	r.queue = nil
+	onRewrite := r.onRewrite
+	r.mu.Unlock()
+
+	cached := 0
+	for len(queue) > 0 {
+		n := min(len(queue), r.maxBatch)
+		cached += r.rewrite(queue[:n])
+		queue = queue[n:]
+	}
+	// Unidentified commands are not cached and are asked about again on the
+	// next render, so only new names trigger one
+	if cached > 0 && onRewrite != nil {
+		onRewrite()
+	}
+}
 
-		serviceName, err := r.client.RewriteProcessName(command)
+// rewrite describes and caches commands, falling back to one request per
+// command for those the batch request did not answer. It returns how many
+// service names were cached
+func (r *Rewriter) rewrite(commands []string) int {
+	defer func() {
+		for _, command := range commands {
+			r.pending.Delete(command)
+		}
+	}()
+
+	cached := 0
+	var results map[int]ServiceInfo
+	if len(commands) > 1 {
+		var err error
+		results, err = r.client.DescribeServices(commands)
 		if err != nil {
-			// Log error but don't fail
-			println("LLM rewrite error:", err.Error())
-			return
+			// DescribeServices logged the error
+			logger.Info("Retrying %d commands one by one", len(commands))
 		}
+	}
 
-		// Cache the result (cache.Set handles skipping "未知")
-		r.cache.Set(command, serviceName)
+	for i, command := range commands {
+		info, ok := results[i]
+		if !ok {
+			var err error
+			info, err = r.client.DescribeService(command)
+			if err != nil {
+				// Logged by DescribeService; don't fail
+				fmt.Fprintln(Console, "LLM rewrite error:", err)
+				continue
+			}
+		}
 
-		// Persist cache
-		if err := r.cache.Save(); err != nil {
-			println("Failed to save cache:", err.Error())
+		// Cache the result (cache.SetInfo skips unidentified services)
+		if info.Known() {
+			cached++
 		}
-	}()
+		r.cache.SetInfo(command, info)
+	}
+
+	// Persist cache
+	if err := r.cache.Save(); err != nil {
+		logger.Error("Failed to save LLM cache: %v", err)
+		fmt.Fprintln(Console, "Failed to save cache:", err)
+	}
+	return cached
 }
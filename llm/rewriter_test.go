@@ -0,0 +1,262 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeProvider answers prompts with reply and records them
+type fakeProvider struct {
+	reply func(prompt string, batch bool) (string, error)
+
+	mu      sync.Mutex
+	prompts []string
+	batches int
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Complete(prompt string, schema map[string]any) (string, error) {
+	batch := schema != nil && schema["properties"].(map[string]any)["services"] != nil
+	p.mu.Lock()
+	p.prompts = append(p.prompts, prompt)
+	if batch {
+		p.batches++
+	}
+	p.mu.Unlock()
+	return p.reply(prompt, batch)
+}
+
+func (p *fakeProvider) requests() (total, batches int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.prompts), p.batches
+}
+
+// knownServices are the answers of the fake provider by command
+var knownServices = map[string]string{
+	"node vite":           "Vite",
+	"redis-server *:6379": "Redis",
+	"postgres -D /data":   "PostgreSQL",
+}
+
+// knownService returns the name of a command as the provider sees it
+func knownService(sent string) string {
+	var n *Normalizer // The built-in rules
+	for command, name := range knownServices {
+		if n.Normalize(command) == sent {
+			return name
+		}
+	}
+	return ""
+}
+
+// singleReply answers a single prompt from knownServices
+func singleReply(prompt string) string {
+	sent := prompt[strings.LastIndex(prompt, "\n")+1:]
+	if name := knownService(sent); name != "" {
+		return fmt.Sprintf(`{"service_name":%q,"category":"other","confidence":0.9}`, name)
+	}
+	return `{"service_name":"","category":"other","confidence":0}`
+}
+
+// batchReply answers every command of a batch prompt from knownServices
+func batchReply(prompt string) string {
+	var answers []string
+	for id := 1; ; id++ {
+		prefix := fmt.Sprintf("\n%d. ", id)
+		i := strings.LastIndex(prompt, prefix) // After the example in the prompt
+		if i < 0 {
+			break
+		}
+		command, _, _ := strings.Cut(prompt[i+len(prefix):], "\n")
+		answers = append(answers, fmt.Sprintf(`{"id":%d,"service_name":%q,"category":"other","confidence":0.9}`, id, knownService(command)))
+	}
+	return `{"services":[` + strings.Join(answers, ",") + `]}`
+}
+
+// newTestRewriter returns an enabled rewriter on provider with an empty cache
+// in a temporary home
+func newTestRewriter(t *testing.T, provider Provider) *Rewriter {
+	t.Setenv("HOME", t.TempDir())
+	return &Rewriter{
+		config:   &Config{LLM: LLMSettings{Enabled: true}},
+		client:   &Client{provider: provider},
+		cache:    NewCache(),
+		window:   20 * time.Millisecond,
+		maxBatch: maxBatchSize,
+	}
+}
+
+// waitIdle waits until no rewrite is queued or in flight
+func waitIdle(t *testing.T, r *Rewriter) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		busy := false
+		r.pending.Range(func(any, any) bool {
+			busy = true
+			return false
+		})
+		if !busy {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("rewrites did not finish")
+}
+
+func TestRewriter_Batching(t *testing.T) {
+	commands := []string{"node vite", "redis-server *:6379", "postgres -D /data"}
+
+	tests := []struct {
+		name         string
+		maxBatch     int
+		batch        func(prompt string) (string, error)
+		wantRequests int
+		wantBatches  int
+	}{
+		{
+			name:         "one request",
+			maxBatch:     maxBatchSize,
+			batch:        func(prompt string) (string, error) { return batchReply(prompt), nil },
+			wantRequests: 1,
+			wantBatches:  1,
+		},
+		{
+			name:         "split by batch size",
+			maxBatch:     2,
+			batch:        func(prompt string) (string, error) { return batchReply(prompt), nil },
+			wantRequests: 2, // A batch of two and a single request
+			wantBatches:  1,
+		},
+		{
+			name:     "missing answers asked one by one",
+			maxBatch: maxBatchSize,
+			batch: func(string) (string, error) {
+				return `{"services":[{"id":1,"service_name":"Vite","category":"other","confidence":0.9},{"id":2,"service_name":"Redis","confidence":42}]}`, nil
+			},
+			wantRequests: 3,
+			wantBatches:  1,
+		},
+		{
+			name:         "unparseable reply",
+			maxBatch:     maxBatchSize,
+			batch:        func(string) (string, error) { return "Vite, Redis and PostgreSQL", nil },
+			wantRequests: 4,
+			wantBatches:  1,
+		},
+		{
+			name:         "failed request",
+			maxBatch:     maxBatchSize,
+			batch:        func(string) (string, error) { return "", errors.New("rate limited") },
+			wantRequests: 4,
+			wantBatches:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &fakeProvider{reply: func(prompt string, batch bool) (string, error) {
+				if batch {
+					return tt.batch(prompt)
+				}
+				return singleReply(prompt), nil
+			}}
+			r := newTestRewriter(t, provider)
+			r.maxBatch = tt.maxBatch
+
+			for _, command := range commands {
+				r.TriggerRewrite(command)
+				r.TriggerRewrite(command) // In flight, ignored
+			}
+			waitIdle(t, r)
+
+			if total, batches := provider.requests(); total != tt.wantRequests || batches != tt.wantBatches {
+				t.Errorf("requests = %d (%d batches), want %d (%d batches)", total, batches, tt.wantRequests, tt.wantBatches)
+			}
+			for _, command := range commands {
+				if got := r.GetServiceName(command); got != knownServices[command] {
+					t.Errorf("GetServiceName(%q) = %q, want %q", command, got, knownServices[command])
+				}
+			}
+
+			// Everything is cached now
+			for _, command := range commands {
+				r.TriggerRewrite(command)
+			}
+			waitIdle(t, r)
+			if total, _ := provider.requests(); total != tt.wantRequests {
+				t.Errorf("cached commands were requested again")
+			}
+		})
+	}
+}
+
+func TestRewriter_SingleCommand(t *testing.T) {
+	provider := &fakeProvider{reply: func(prompt string, batch bool) (string, error) {
+		if batch {
+			t.Error("a single command was sent as a batch")
+		}
+		return singleReply(prompt), nil
+	}}
+	r := newTestRewriter(t, provider)
+
+	r.TriggerRewrite("node vite")
+	waitIdle(t, r)
+
+	if got := r.GetServiceName("node vite"); got != "Vite" {
+		t.Errorf("GetServiceName() = %q, want Vite", got)
+	}
+}
+
+func TestRewriter_OnRewrite(t *testing.T) {
+	provider := &fakeProvider{reply: func(prompt string, batch bool) (string, error) {
+		if batch {
+			return batchReply(prompt), nil
+		}
+		return singleReply(prompt), nil
+	}}
+	r := newTestRewriter(t, provider)
+
+	// The callback sees the new names, with no change of listeners
+	names := make(chan string, 2)
+	r.OnRewrite(func() { names <- r.GetServiceName("node vite") })
+
+	r.TriggerRewrite("node vite")
+	r.TriggerRewrite("unknown-daemon --foreground")
+	select {
+	case name := <-names:
+		if name != "Vite" {
+			t.Errorf("name after rewrite = %q, want Vite", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("OnRewrite callback not called")
+	}
+
+	// Asking again about a command nobody recognises caches nothing
+	r.TriggerRewrite("unknown-daemon --foreground")
+	waitIdle(t, r)
+	select {
+	case <-names:
+		t.Error("OnRewrite called although no name was cached")
+	case <-time.After(5 * r.window):
+	}
+}
+
+func TestRewriter_Disabled(t *testing.T) {
+	provider := &fakeProvider{reply: func(string, bool) (string, error) { return "", nil }}
+	r := newTestRewriter(t, provider)
+	r.config.LLM.Enabled = false
+
+	r.TriggerRewrite("node vite")
+	time.Sleep(2 * r.window)
+
+	if total, _ := provider.requests(); total != 0 {
+		t.Errorf("disabled rewriter sent %d requests", total)
+	}
+}
@@ -0,0 +1,121 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Service categories reported by the LLM
+const (
+	CategoryDatabase     = "database"
+	CategoryWebDevServer = "web-dev-server"
+	CategoryIDE          = "ide"
+	CategoryProxy        = "proxy"
+	CategorySystem       = "system"
+	CategoryOther        = "other"
+)
+
+// categories are the accepted values of ServiceInfo.Category
+var categories = []string{CategoryDatabase, CategoryWebDevServer, CategoryIDE, CategoryProxy, CategorySystem, CategoryOther}
+
+// unknownName is what older prompts asked the model to answer for
+// unrecognized commands; it is never cached
+const unknownName = "未知"
+
+// minConfidence is the confidence below which an answer counts as unknown
+const minConfidence = 0.5
+
+// maxNameLength keeps a confused model from putting a sentence in the menu
+const maxNameLength = 40
+
+// ServiceInfo is what the LLM reports about a command
+type ServiceInfo struct {
+	Name        string  `json:"service_name"`
+	Category    string  `json:"category,omitempty"`
+	Framework   string  `json:"framework,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Confidence  float64 `json:"confidence,omitempty"`
+}
+
+// Known reports whether the service was identified
+func (s ServiceInfo) Known() bool {
+	return s.Name != "" && s.Name != unknownName
+}
+
+// UnmarshalJSON also accepts a plain string, the cache format before
+// structured output, as a name without further details
+func (s *ServiceInfo) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*s = ServiceInfo{Name: name}
+		return nil
+	}
+	type plain ServiceInfo // Without this method
+	return json.Unmarshal(data, (*plain)(s))
+}
+
+// serviceSchema is the JSON schema of the answer, sent to providers that can
+// enforce it. All fields are required for OpenAI's strict mode; unknown
+// values are empty strings
+var serviceSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"service_name": map[string]any{"type": "string", "description": "Short service name, empty if unknown"},
+		"category":     map[string]any{"type": "string", "enum": categories},
+		"framework":    map[string]any{"type": "string", "description": "Framework or runtime, empty if none"},
+		"description":  map[string]any{"type": "string", "description": "One short sentence"},
+		"confidence":   map[string]any{"type": "number", "minimum": 0, "maximum": 1},
+	},
+	"required":             []string{"service_name", "category", "framework", "description", "confidence"},
+	"additionalProperties": false,
+}
+
+// errUnknownService is returned by parseServiceInfo for valid answers that do
+// not identify the service
+var errUnknownService = errors.New("service not identified")
+
+// parseServiceInfo validates a JSON answer. Models without schema support
+// sometimes wrap it in a Markdown code block or add text around it
+func parseServiceInfo(reply string) (ServiceInfo, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return ServiceInfo{}, fmt.Errorf("no JSON object in reply %q", reply)
+	}
+
+	var info ServiceInfo
+	decoder := json.NewDecoder(strings.NewReader(reply[start : end+1]))
+	if err := decoder.Decode(&info); err != nil {
+		return ServiceInfo{}, fmt.Errorf("invalid JSON in reply: %w", err)
+	}
+
+	info.Name = strings.TrimSpace(info.Name)
+	info.Category = strings.ToLower(strings.TrimSpace(info.Category))
+	info.Framework = strings.TrimSpace(info.Framework)
+	info.Description = strings.TrimSpace(info.Description)
+
+	if info.Confidence < 0 || info.Confidence > 1 {
+		return ServiceInfo{}, fmt.Errorf("confidence %v out of range", info.Confidence)
+	}
+	if !info.Known() || info.Confidence < minConfidence {
+		return ServiceInfo{}, errUnknownService
+	}
+	if utf8.RuneCountInString(info.Name) > maxNameLength || strings.ContainsAny(info.Name, "\r\n") {
+		return ServiceInfo{}, fmt.Errorf("implausible service name %q", info.Name)
+	}
+	if !validCategory(info.Category) {
+		info.Category = CategoryOther
+	}
+	return info, nil
+}
+
+func validCategory(category string) bool {
+	for _, c := range categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseServiceInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		want    ServiceInfo
+		wantErr string // "" for success; errUnknownService is matched with errors.Is
+	}{
+		{
+			name:  "complete",
+			reply: `{"service_name":" Vite ","category":"Web-Dev-Server","framework":"Vite","description":"Frontend dev server","confidence":0.92}`,
+			want:  ServiceInfo{Name: "Vite", Category: "web-dev-server", Framework: "Vite", Description: "Frontend dev server", Confidence: 0.92},
+		},
+		{
+			name:  "code block",
+			reply: "```json\n{\"service_name\":\"Redis\",\"category\":\"database\",\"confidence\":1}\n```",
+			want:  ServiceInfo{Name: "Redis", Category: "database", Confidence: 1},
+		},
+		{
+			name:  "unknown category becomes other",
+			reply: `{"service_name":"Ollama","category":"ai","confidence":0.8}`,
+			want:  ServiceInfo{Name: "Ollama", Category: "other", Confidence: 0.8},
+		},
+		{name: "empty name", reply: `{"service_name":"","confidence":0.9}`, wantErr: "unknown"},
+		{name: "legacy unknown marker", reply: `{"service_name":"未知","confidence":0.9}`, wantErr: "unknown"},
+		{name: "low confidence", reply: `{"service_name":"node","confidence":0.3}`, wantErr: "unknown"},
+		{name: "missing confidence", reply: `{"service_name":"node"}`, wantErr: "unknown"},
+		{name: "confidence out of range", reply: `{"service_name":"node","confidence":7}`, wantErr: "out of range"},
+		{name: "sentence as name", reply: `{"service_name":"` + strings.Repeat("a", 41) + `","confidence":0.9}`, wantErr: "implausible"},
+		{name: "plain text", reply: "Vite", wantErr: "no JSON object"},
+		{name: "broken JSON", reply: `{"service_name": Vite}`, wantErr: "invalid JSON"},
+		{name: "wrong type", reply: `{"service_name":"Vite","confidence":"high"}`, wantErr: "invalid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseServiceInfo(tt.reply)
+			switch {
+			case tt.wantErr == "unknown":
+				if !errors.Is(err, errUnknownService) {
+					t.Errorf("parseServiceInfo() error = %v, want errUnknownService", err)
+				}
+			case tt.wantErr != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("parseServiceInfo() error = %v, want %q", err, tt.wantErr)
+				}
+			case err != nil || got != tt.want:
+				t.Errorf("parseServiceInfo() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceInfo_UnmarshalLegacy(t *testing.T) {
+	var items map[string]ServiceInfo
+	data := `{"node vite":"Vite","postgres -D /data":{"service_name":"PostgreSQL","category":"database","confidence":0.99}}`
+	if err := json.Unmarshal([]byte(data), &items); err != nil {
+		t.Fatal(err)
+	}
+	if items["node vite"] != (ServiceInfo{Name: "Vite"}) {
+		t.Errorf("legacy entry = %+v", items["node vite"])
+	}
+	if items["postgres -D /data"] != (ServiceInfo{Name: "PostgreSQL", Category: "database", Confidence: 0.99}) {
+		t.Errorf("structured entry = %+v", items["postgres -D /data"])
+	}
+}
@@ -79,13 +79,13 @@ func Debug(format string, v ...interface{}) {
 	logger.Printf("[DEBUG] %s", msg)
 }
 
-// LogLsofQuery logs an lsof query execution
-func LogLsofQuery(command []string, portCount int, err error) {
+// LogScanQuery logs a port scan executed by the given scanner backend
+func LogScanQuery(backend string, command []string, portCount int, err error) {
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
 	if err != nil {
-		Error("lsof query failed at %s: command=%v, error=%v", timestamp, command, err)
+		Error("%s query failed at %s: command=%v, error=%v", backend, timestamp, command, err)
 	} else {
-		Info("lsof query succeeded at %s: command=%v, found %d ports", timestamp, command, portCount)
+		Info("%s query succeeded at %s: command=%v, found %d ports", backend, timestamp, command, portCount)
 	}
 }
 
@@ -1,16 +1,27 @@
 package main
 
 import (
+	"context"
 	_ "embed"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"os"
-	"os/exec"
 	"port-digger/actions"
+	"port-digger/api"
+	"port-digger/cli"
+	"port-digger/freeport"
 	"port-digger/llm"
 	"port-digger/logger"
 	"port-digger/menu"
+	"port-digger/metrics"
+	"port-digger/monitor"
+	"port-digger/notify"
 	"port-digger/scanner"
-	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/getlantern/systray"
 	"golang.design/x/clipboard"
@@ -19,12 +30,23 @@ import (
 // Global LLM rewriter instance
 var rewriter *llm.Rewriter
 
+// controller keeps the port list in the tray menu up to date
+var controller *menu.Controller
+
 //go:embed icon/icon.png
 var iconData []byte
 
 const version = "1.0.0"
 
 func main() {
+	// Subcommands run headless, without the logger, clipboard or tray
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		os.Exit(cli.Run(os.Args[1:]))
+	}
+
+	backend := flag.String("backend", "", "Force a scanner backend: "+strings.Join(append([]string{scanner.AutoBackend}, scanner.BackendNames()...), ", "))
+	flag.Parse()
+
 	// Initialize logger first
 	err := logger.Init()
 	if err != nil {
@@ -42,6 +64,9 @@ func main() {
 		logger.Error("Clipboard initialization failed: %v", err)
 	}
 
+	// Select scanner backend (flag overrides config)
+	configureScanner(*backend)
+
 	// Initialize LLM rewriter (non-fatal if it fails)
 	rewriter, err = llm.NewRewriter()
 	if err != nil {
@@ -54,182 +79,435 @@ func main() {
 	systray.Run(onReady, onExit)
 }
 
+// configureScanner forces the scanner backend from the -backend flag or config.yaml
+func configureScanner(flagBackend string) {
+	name := flagBackend
+	if name == "" {
+		config, err := llm.LoadConfig()
+		if err != nil {
+			logger.Error("Failed to load config for scanner settings: %v", err)
+			return
+		}
+		name = config.Scanner.Backend
+	}
+
+	if err := scanner.SetBackend(name); err != nil {
+		println("Warning:", err.Error())
+		logger.Error("Failed to set scanner backend: %v", err)
+		return
+	}
+	if name != "" {
+		logger.Info("Scanner backend preference: %s", name)
+	}
+}
+
 func onReady() {
 	// Use icon instead of emoji
 	systray.SetIcon(iconData)
 	systray.SetTooltip(fmt.Sprintf("Port Digger v%s - Monitor TCP Ports", version))
 
-	logger.Info("Building menu...")
-	buildMenu()
-}
-
-func buildMenu() {
-	// Add refresh button
+	// Refresh stays on top; everything below it is updated in place
 	mRefresh := systray.AddMenuItem("🔄 Refresh", "Rescan ports")
+	systray.AddSeparator()
+	controller = menu.NewController(systrayTray{})
+
 	go func() {
 		for range mRefresh.ClickedCh {
-			logger.Info("Refresh button clicked, restarting app...")
-			// Restart the app to rebuild menu
-			restartApp()
+			logger.Info("Refresh button clicked, rescanning...")
+			refreshMenu()
 		}
 	}()
 
-	systray.AddSeparator()
+	// Service names arrive after the menu was built; the poller only renders
+	// when listeners change, so show them as soon as they are cached
+	if rewriter != nil {
+		rewriter.OnRewrite(func() {
+			logger.Info("New service names cached, refreshing menu...")
+			refreshMenu()
+		})
+	}
 
-	// Scan ports
-	logger.Info("Scanning ports...")
-	ports, err := scanner.ScanPorts()
+	logger.Info("Building menu...")
+	refreshMenu()
+	startAutoRefresh()
+}
+
+// startAutoRefresh rescans in the background as configured in config.yaml
+func startAutoRefresh() {
+	config, err := llm.LoadConfig()
 	if err != nil {
-		logger.Error("Port scan failed: %v", err)
-		systray.AddMenuItem("❌ Scan failed", err.Error())
-		addBottomMenu()
+		logger.Error("Failed to load config for refresh settings: %v", err)
 		return
 	}
+	apiServer := startAPI(config.API)
 
-	if len(ports) == 0 {
-		logger.Info("No ports listening")
-		systray.AddMenuItem("No ports listening", "")
-		addBottomMenu()
+	settings := config.Refresh
+	if settings.Interval <= 0 {
+		logger.Info("Background refresh disabled")
 		return
 	}
 
-	// Sort by port number
-	sort.Slice(ports, func(i, j int) bool {
-		return ports[i].Port < ports[j].Port
+	poller := monitor.NewPoller(scanner.ScanPorts, monitor.Options{
+		Interval:    time.Duration(settings.Interval) * time.Second,
+		IdleAfter:   time.Duration(settings.IdleAfter) * time.Second,
+		MaxInterval: time.Duration(settings.MaxInterval) * time.Second,
 	})
+	dispatcher := newDispatcher(config.Notify)
+	logger.Info("Background refresh every %ds", settings.Interval)
+	go poller.Run(context.Background(), func(listeners []scanner.Listener, diff monitor.Diff) {
+		renderListeners(listeners)
+		if dispatcher != nil {
+			dispatcher.Handle(diff)
+		}
+		if apiServer != nil {
+			apiServer.Publish(diff)
+		}
+	})
+}
 
-	logger.Info("Found %d listening ports, adding to menu", len(ports))
+// startAPI serves the local HTTP API as configured in config.yaml, or returns nil
+// /events needs background refresh to report changes
+func startAPI(settings llm.APISettings) *api.Server {
+	if !settings.Enabled {
+		return nil
+	}
 
-	// Add port menu items
-	for _, p := range ports {
-		addPortMenuItem(p)
+	var listener net.Listener
+	var err error
+	if settings.Listen != "" {
+		if settings.Token == "" {
+			println("Warning: api.listen needs api.token; the API was not started")
+			logger.Error("API not started: api.listen is set without api.token")
+			return nil
+		}
+		listener, err = api.ListenLoopback(settings.Listen)
+	} else {
+		path := settings.Socket
+		if path == "" {
+			path, err = api.SocketPath()
+		}
+		if err == nil {
+			listener, err = api.ListenUnix(path)
+		}
+	}
+	if err != nil {
+		println("Warning: API not started:", err.Error())
+		logger.Error("API not started: %v", err)
+		return nil
 	}
 
-	addBottomMenu()
+	server := api.NewServer(api.Deps{
+		Scan:      scanner.ScanPorts,
+		Processes: scanner.LookupProcesses,
+		ServiceName: func(command string) string {
+			if rewriter == nil {
+				return ""
+			}
+			return rewriter.GetServiceName(command)
+		},
+		Kill: actions.Kill,
+		// This process scans and calls the LLM, so its metrics are the real ones
+		Metrics: []*metrics.Registry{metrics.Default, llm.Metrics},
+	}, settings.Token)
+	logger.Info("API listening on %s", listener.Addr())
+	go func() {
+		if err := api.Serve(context.Background(), listener, server); err != nil {
+			logger.Error("API server stopped: %v", err)
+		}
+	}()
+	return server
 }
 
-// restartApp restarts the application to refresh the menu
-func restartApp() {
-	executable, err := os.Executable()
-	if err != nil {
-		logger.Error("Failed to get executable path: %v", err)
-		return
+// newDispatcher builds the port change notifier from config.yaml, or nil if disabled
+func newDispatcher(settings llm.NotifySettings) *notify.Dispatcher {
+	notifier := notify.Default()
+	if !settings.Enabled || notifier == nil {
+		logger.Info("Notifications disabled")
+		return nil
 	}
 
-	// Start a new instance
-	cmd := exec.Command(executable, os.Args[1:]...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Stdin = os.Stdin
+	toKinds := func(names []string) []notify.Kind {
+		kinds := make([]notify.Kind, len(names))
+		for i, name := range names {
+			kinds[i] = notify.Kind(name)
+		}
+		return kinds
+	}
+	opts := notify.Options{Events: toKinds(settings.Events)}
+	for _, r := range settings.Rules {
+		opts.Rules = append(opts.Rules, notify.Rule{
+			Port:    r.Port,
+			Process: r.Process,
+			Events:  toKinds(r.Events),
+			Exposed: r.Exposed,
+			Notify:  r.Notify,
+		})
+	}
+	limiter := notify.NewLimiter(settings.Burst, time.Duration(settings.RateInterval)*time.Second)
+	return notify.NewDispatcher(notifier, limiter, opts)
+}
 
-	err = cmd.Start()
+// refreshMenu rescans ports and updates the menu in place
+func refreshMenu() {
+	logger.Info("Scanning ports...")
+	ports, err := scanner.ScanPorts()
 	if err != nil {
-		logger.Error("Failed to restart app: %v", err)
+		logger.Error("Port scan failed: %v", err)
+		controller.Render(append([]menu.Row{{Title: "❌ Scan failed", Tooltip: err.Error()}}, bottomRows()...))
 		return
 	}
 
-	// Exit current instance
-	systray.Quit()
+	// Merge IPv4/IPv6 and duplicate sockets of the same process into one entry
+	renderListeners(scanner.GroupListeners(ports))
 }
 
-func addBottomMenu() {
-	// Add quit at bottom
-	systray.AddSeparator()
-	systray.AddMenuItem(fmt.Sprintf("Port Digger v%s", version), "About")
+// renderListeners updates the menu to show listeners
+func renderListeners(listeners []scanner.Listener) {
+	controller.Render(append(portRows(listeners), bottomRows()...))
+}
 
-	// LLM Settings submenu
-	mLLM := systray.AddMenuItem("⚙️ LLM Settings", "Configure LLM for process name rewriting")
-	mLLMOpen := mLLM.AddSubMenuItem("Open Config File", "Edit ~/.config/port-digger/config.yaml")
-	mLLMStatus := mLLM.AddSubMenuItemCheckbox("Enabled", "", rewriter != nil && rewriter.IsEnabled())
-	mLLMStatus.Disable() // Read-only indicator
+// portRows returns one row per listener
+func portRows(listeners []scanner.Listener) []menu.Row {
+	if len(listeners) == 0 {
+		logger.Info("No ports listening")
+		return []menu.Row{{Title: "No ports listening"}}
+	}
 
-	go func() {
-		for range mLLMOpen.ClickedCh {
-			configPath, err := llm.ConfigPath()
-			if err != nil {
-				println("Failed to get config path:", err.Error())
-				logger.Error("Failed to get config path: %v", err)
-				continue
-			}
-			// Ensure config file exists
-			if err := llm.EnsureDefaultConfig(); err != nil {
-				println("Failed to create default config:", err.Error())
-				logger.Error("Failed to create default config: %v", err)
-			}
-			// Open in default editor using 'open' command on macOS
-			actions.OpenFile(configPath)
+	logger.Info("Found %d listeners, adding to menu", len(listeners))
+
+	// Fetch details of all owning processes at once instead of one ps per port
+	pids := make([]int, len(listeners))
+	for i, l := range listeners {
+		pids[i] = l.PID
+	}
+	processes, err := scanner.LookupProcesses(pids)
+	if err != nil {
+		logger.Error("Process lookup failed: %v", err)
+	}
+
+	// Add TCP listeners first, then UDP sockets under their own header
+	var rows, udp []menu.Row
+	for _, l := range listeners {
+		if l.IsUDP() {
+			udp = append(udp, portRow(l, processes[l.PID]))
+			continue
 		}
-	}()
+		rows = append(rows, portRow(l, processes[l.PID]))
+	}
+	if len(udp) > 0 {
+		rows = append(rows, menu.HeaderRow("UDP"))
+		rows = append(rows, udp...)
+	}
+	return rows
+}
 
-	mQuit := systray.AddMenuItem("Quit", "Quit Port Digger")
-	go func() {
-		<-mQuit.ClickedCh
-		logger.Info("Quit button clicked, exiting...")
-		systray.Quit()
-	}()
+func bottomRows() []menu.Row {
+	return []menu.Row{
+		menu.SeparatorRow(),
+		{Title: "📋 Copy a Free Port", Tooltip: "Copy an unused port that no project has reserved", Sub: freePortRows()},
+		{Title: fmt.Sprintf("Port Digger v%s", version), Tooltip: "About"},
+		// LLM Settings submenu
+		{
+			Title:   "⚙️ LLM Settings",
+			Tooltip: "Configure LLM for process name rewriting",
+			Sub: []menu.Row{
+				{Title: "Open Config File", Tooltip: "Edit ~/.config/port-digger/config.yaml", Action: openConfigFile},
+				// Read-only indicator
+				{Title: "Enabled", Checked: rewriter != nil && rewriter.IsEnabled(), Disabled: true},
+			},
+		},
+		{Title: "Quit", Tooltip: "Quit Port Digger", Action: func() {
+			logger.Info("Quit button clicked, exiting...")
+			systray.Quit()
+		}},
+	}
 }
 
-// Placeholder for next step
-func addPortMenuItem(info scanner.PortInfo) {
+// freePortRanges are offered by the "Copy a Free Port" submenu
+var freePortRanges = []freeport.Range{{Low: 3000, High: 3999}, {Low: 8000, High: 8999}, {Low: 49152, High: 65535}}
+
+// freePortRows returns one row per range that copies a free port from it
+func freePortRows() []menu.Row {
+	rows := make([]menu.Row, len(freePortRanges))
+	for i, r := range freePortRanges {
+		rows[i] = menu.Row{Title: r.String(), Tooltip: "Copy a free port in this range", Action: func() { copyFreePort(r) }}
+	}
+	return rows
+}
+
+// copyFreePort finds a free port in r and copies it to the clipboard
+func copyFreePort(r freeport.Range) {
+	reservations, err := freeport.LoadReservations()
+	if err != nil {
+		logger.Error("Failed to load port reservations: %v", err)
+	}
+	port, err := freeport.NewFinder().Find(freeport.Options{Range: r, Reservations: reservations})
+	if err != nil {
+		println("Failed to find a free port:", err.Error())
+		logger.Error("Failed to find a free port in %s: %v", r, err)
+		return
+	}
+	logger.Info("Copying free port %d to clipboard", port)
+	if err := actions.CopyToClipboard(port); err != nil {
+		println("Failed to copy to clipboard:", err.Error())
+		logger.Error("Failed to copy port %d to clipboard: %v", port, err)
+	}
+}
+
+// openConfigFile opens config.yaml in the default editor, creating it first if needed
+func openConfigFile() {
+	configPath, err := llm.ConfigPath()
+	if err != nil {
+		println("Failed to get config path:", err.Error())
+		logger.Error("Failed to get config path: %v", err)
+		return
+	}
+	// Ensure config file exists
+	if err := llm.EnsureDefaultConfig(); err != nil {
+		println("Failed to create default config:", err.Error())
+		logger.Error("Failed to create default config: %v", err)
+	}
+	// Open in default editor using 'open' command on macOS
+	actions.OpenFile(configPath)
+}
+
+// portRow builds a listener row and its action submenu
+func portRow(l scanner.Listener, proc scanner.ProcessInfo) menu.Row {
 	// Get full command for LLM rewriting
-	fullCommand := scanner.GetFullCommand(info.PID)
+	fullCommand := proc.Command
 	if fullCommand == "" {
-		fullCommand = info.ProcessName
+		fullCommand = l.ProcessName
 	}
 
-	// Check for cached rewritten name
-	var rewrittenName string
+	// Check for cached service details
+	var service llm.ServiceInfo
 	if rewriter != nil && rewriter.IsEnabled() {
-		rewrittenName = rewriter.GetServiceName(fullCommand)
+		var cached bool
+		service, cached = rewriter.GetServiceInfo(fullCommand)
 
 		// Trigger async rewrite if not cached
-		if rewrittenName == "" {
+		if !cached {
 			rewriter.TriggerRewrite(fullCommand)
 		}
 	}
 
-	// Format menu item with rewritten name if available
-	itemText := menu.FormatPortItemWithRewrite(info, rewrittenName)
-	mPort := systray.AddMenuItem(itemText, "")
+	primary := l.Primary()
 
-	// Add submenu items
-	mOpen := mPort.AddSubMenuItem("Open in Browser", "Open http://localhost:PORT")
-	mCopy := mPort.AddSubMenuItem("Copy Port Number", "Copy to clipboard")
-	mPort.AddSubMenuItemCheckbox("------", "", false) // separator-like
-	mKill := mPort.AddSubMenuItem(
-		fmt.Sprintf("Kill Process (PID: %d)", info.PID),
-		"Terminate this process")
+	var addresses []menu.Row
+	for _, sock := range l.Sockets {
+		addresses = append(addresses, addressRow(sock))
+	}
+	var details []menu.Row
+	for _, line := range append(menu.FormatServiceDetails(service.Category, service.Framework), menu.FormatDetails(proc, time.Now())...) {
+		details = append(details, menu.Row{Title: line, Disabled: true})
+	}
 
-	// Handle submenu actions
-	go func() {
-		for {
-			select {
-			case <-mOpen.ClickedCh:
-				logger.Info("Opening browser for port %d", info.Port)
-				actions.OpenBrowser(info.Port)
-			case <-mCopy.ClickedCh:
-				logger.Info("Copying port %d to clipboard", info.Port)
-				err := actions.CopyToClipboard(info.Port)
+	row := menu.Row{
+		// Format menu item with rewritten name and category if available
+		Title:   menu.FormatServiceItem(l, service.Name, service.Category),
+		Tooltip: service.Description,
+		Sub: []menu.Row{
+			{
+				Title:   "Open in Browser",
+				Tooltip: "Open the bound address in the default browser",
+				// There is nothing to browse on a UDP socket
+				Disabled: l.IsUDP(),
+				Action: func() {
+					logger.Info("Opening browser for port %d on %s", l.Port, primary.BindAddress)
+					actions.OpenBrowser(primary.BindAddress, l.Port)
+				},
+			},
+			{Title: "Copy Port Number", Tooltip: "Copy to clipboard", Action: func() {
+				logger.Info("Copying port %d to clipboard", l.Port)
+				err := actions.CopyToClipboard(l.Port)
 				if err != nil {
 					println("Failed to copy to clipboard:", err.Error())
-					logger.Error("Failed to copy port %d to clipboard: %v", info.Port, err)
-				}
-			case <-mKill.ClickedCh:
-				logger.Info("Killing process PID %d (port %d)", info.PID, info.Port)
-				err := actions.KillProcess(info.PID)
-				if err != nil {
-					// Could show notification, but keep it simple for now
-					println("Failed to kill process:", err.Error())
-					logger.Error("Failed to kill process PID %d: %v", info.PID, err)
-				} else {
-					logger.Info("Successfully killed process PID %d", info.PID)
-					// Restart app to refresh the port list
-					logger.Info("Restarting app to refresh port list...")
-					restartApp()
+					logger.Error("Failed to copy port %d to clipboard: %v", l.Port, err)
 				}
-			}
-		}
-	}()
+			}},
+			{Title: "Listening on", Tooltip: "Bind addresses of this listener", Sub: addresses},
+			{Title: "Details", Tooltip: "Owning process", Disabled: len(details) == 0, Sub: details},
+			menu.SeparatorRow(),
+			{Title: fmt.Sprintf("Kill Process (PID: %d)", l.PID), Tooltip: "Terminate this process", Action: func() {
+				killListener(l, false)
+			}},
+		},
+	}
+	// Administrator rights are only used after the user clicks again
+	if deniedKills.has(l.PID) {
+		row.Sub = append(row.Sub, menu.Row{
+			Title:   fmt.Sprintf("Kill as Administrator (PID: %d)…", l.PID),
+			Tooltip: "The process belongs to another user; asks for the administrator password",
+			Action:  func() { killListener(l, true) },
+		})
+	}
+	return row
+}
+
+// deniedKills holds the PIDs an unprivileged kill was not allowed to signal
+var deniedKills = &pidSet{pids: make(map[int]bool)}
+
+// pidSet is a set of PIDs shared by menu actions and refreshes
+type pidSet struct {
+	mu   sync.Mutex
+	pids map[int]bool
+}
+
+func (s *pidSet) has(pid int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pids[pid]
+}
+
+func (s *pidSet) set(pid int, on bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if on {
+		s.pids[pid] = true
+	} else {
+		delete(s.pids, pid)
+	}
+}
+
+// killListener terminates the listener's process, with administrator rights
+// only if privileged. A denied unprivileged kill offers the privileged one
+func killListener(l scanner.Listener, privileged bool) {
+	logger.Info("Killing process PID %d (port %d, privileged: %v)", l.PID, l.Port, privileged)
+	result, err := actions.Kill([]int{l.PID}, actions.KillOptions{
+		Grace:      actions.DefaultGrace,
+		Escalate:   true,
+		Privileged: privileged,
+		Port:       l.Port,
+		Protocol:   l.Protocol,
+	})
+	deniedKills.set(l.PID, !privileged && errors.Is(err, actions.ErrPermission))
+	switch {
+	case errors.Is(err, actions.ErrPermission) && !privileged:
+		logger.Info("PID %d belongs to another user, offering Kill as Administrator", l.PID)
+	case err != nil:
+		// Could show notification, but keep it simple for now
+		println("Failed to kill process:", err.Error())
+		logger.Error("Failed to kill process PID %d: %v", l.PID, err)
+	case len(result.Survivors()) > 0:
+		logger.Error("Process PID %d still running after %s", l.PID, result.Elapsed)
+	default:
+		logger.Info("Killed process PID %d in %s (escalated: %v, port %d freed: %v)",
+			l.PID, result.Elapsed, result.Escalated, l.Port, result.PortFreed)
+	}
+	refreshMenu()
+}
+
+// addressRow builds one bind address row; clicking it opens that address
+func addressRow(sock scanner.PortInfo) menu.Row {
+	return menu.Row{
+		Title:    menu.FormatAddressItem(sock),
+		Tooltip:  "Open this address in the default browser",
+		Disabled: sock.IsUDP(),
+		Action: func() {
+			logger.Info("Opening browser for %s", sock.Address())
+			actions.OpenBrowser(sock.BindAddress, sock.Port)
+		},
+	}
 }
 
 func onExit() {
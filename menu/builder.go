@@ -3,20 +3,63 @@ package menu
 import (
 	"fmt"
 	"port-digger/scanner"
+	"strings"
 )
 
-// FormatPortItem formats a port info as "  PORT • ProcessName"
-// Port is right-aligned in 5 characters
+// FormatBindAddress formats a bind address for display
+// IPv6 addresses are bracketed so they read unambiguously next to a port
+func FormatBindAddress(address string) string {
+	if strings.Contains(address, ":") {
+		return "[" + address + "]"
+	}
+	return address
+}
+
+// bindSuffix returns " · ADDRESS, ADDRESS" for the known bind addresses
+func bindSuffix(addresses []string) string {
+	var shown []string
+	for _, address := range addresses {
+		if address != "" {
+			shown = append(shown, FormatBindAddress(address))
+		}
+	}
+	if len(shown) == 0 {
+		return ""
+	}
+	return " · " + strings.Join(shown, ", ")
+}
+
+// formatItem renders "  PORT • ProcessName (ServiceName✨) · Addresses"
+// The service name is shown unless it is empty, "未知" or the process name itself
+func formatItem(port int, processName, rewrittenName string, addresses []string) string {
+	name := processName
+	if rewrittenName != "" && rewrittenName != "未知" && rewrittenName != processName {
+		name = fmt.Sprintf("%s (%s✨)", processName, rewrittenName)
+	}
+	return fmt.Sprintf("%5d • %s%s", port, name, bindSuffix(addresses))
+}
+
+// FormatPortItem formats a port info as "  PORT • ProcessName · BindAddress"
+// Port is right-aligned in 5 characters; the bind address is omitted when unknown
 func FormatPortItem(info scanner.PortInfo) string {
-	return fmt.Sprintf("%5d • %s", info.Port, info.ProcessName)
+	return formatItem(info.Port, info.ProcessName, "", []string{info.BindAddress})
 }
 
 // FormatPortItemWithRewrite formats a port info with a rewritten service name
-// Format: "  PORT • ProcessName (ServiceName)"
+// Format: "  PORT • ProcessName (ServiceName) · BindAddress"
 // If rewrittenName is empty or "未知", falls back to FormatPortItem
 func FormatPortItemWithRewrite(info scanner.PortInfo, rewrittenName string) string {
-	if rewrittenName == "" || rewrittenName == "未知" || rewrittenName == info.ProcessName {
-		return FormatPortItem(info)
-	}
-	return fmt.Sprintf("%5d • %s (%s✨)", info.Port, info.ProcessName, rewrittenName)
+	return formatItem(info.Port, info.ProcessName, rewrittenName, []string{info.BindAddress})
+}
+
+// FormatListenerItem formats a grouped listener with all of its bind addresses
+// Format: "  PORT • ProcessName (ServiceName) · 0.0.0.0, [::]"
+func FormatListenerItem(l scanner.Listener, rewrittenName string) string {
+	return formatItem(l.Port, l.ProcessName, rewrittenName, l.BindAddresses())
+}
+
+// FormatAddressItem formats one socket of a listener for its address submenu
+// Format: "127.0.0.1:3000 (IPv4)"
+func FormatAddressItem(info scanner.PortInfo) string {
+	return fmt.Sprintf("%s (%s)", info.Address(), info.Family)
 }
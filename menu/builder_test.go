@@ -31,6 +31,21 @@ func TestFormatPortItem(t *testing.T) {
 			info: scanner.PortInfo{Port: 8080, ProcessName: "java"},
 			want: " 8080 • java",
 		},
+		{
+			name: "loopback IPv4",
+			info: scanner.PortInfo{Port: 8080, ProcessName: "java", BindAddress: "127.0.0.1", Family: scanner.FamilyIPv4},
+			want: " 8080 • java · 127.0.0.1",
+		},
+		{
+			name: "all interfaces IPv4",
+			info: scanner.PortInfo{Port: 3000, ProcessName: "node", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4},
+			want: " 3000 • node · 0.0.0.0",
+		},
+		{
+			name: "loopback IPv6",
+			info: scanner.PortInfo{Port: 3000, ProcessName: "node", BindAddress: "::1", Family: scanner.FamilyIPv6},
+			want: " 3000 • node · [::1]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -68,6 +83,12 @@ func TestFormatPortItemWithRewrite(t *testing.T) {
 			rewriteName: "未知",
 			want:        " 8080 • python",
 		},
+		{
+			name:        "with rewritten name and bind address",
+			info:        scanner.PortInfo{Port: 3000, ProcessName: "node", BindAddress: "::", Family: scanner.FamilyIPv6},
+			rewriteName: "vite",
+			want:        " 3000 • node (vite✨) · [::]",
+		},
 	}
 
 	for _, tt := range tests {
@@ -79,3 +100,83 @@ func TestFormatPortItemWithRewrite(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatBindAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"127.0.0.1", "127.0.0.1"},
+		{"0.0.0.0", "0.0.0.0"},
+		{"::1", "[::1]"},
+		{"::", "[::]"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBindAddress(tt.address); got != tt.want {
+			t.Errorf("FormatBindAddress(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestFormatListenerItem(t *testing.T) {
+	dualStack := scanner.Listener{
+		Port:        3000,
+		PID:         42,
+		ProcessName: "node",
+		Protocol:    "TCP",
+		Sockets: []scanner.PortInfo{
+			{Port: 3000, BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4},
+			{Port: 3000, BindAddress: "::", Family: scanner.FamilyIPv6},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		listener    scanner.Listener
+		rewriteName string
+		want        string
+	}{
+		{
+			name:     "dual-stack",
+			listener: dualStack,
+			want:     " 3000 • node · 0.0.0.0, [::]",
+		},
+		{
+			name:        "dual-stack with rewritten name",
+			listener:    dualStack,
+			rewriteName: "vite",
+			want:        " 3000 • node (vite✨) · 0.0.0.0, [::]",
+		},
+		{
+			name:     "no sockets",
+			listener: scanner.Listener{Port: 80, ProcessName: "nginx"},
+			want:     "   80 • nginx",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatListenerItem(tt.listener, tt.rewriteName); got != tt.want {
+				t.Errorf("FormatListenerItem() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAddressItem(t *testing.T) {
+	tests := []struct {
+		info scanner.PortInfo
+		want string
+	}{
+		{scanner.PortInfo{Port: 3000, BindAddress: "127.0.0.1", Family: scanner.FamilyIPv4}, "127.0.0.1:3000 (IPv4)"},
+		{scanner.PortInfo{Port: 3000, BindAddress: "::1", Family: scanner.FamilyIPv6}, "[::1]:3000 (IPv6)"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatAddressItem(tt.info); got != tt.want {
+			t.Errorf("FormatAddressItem() = %q, want %q", got, tt.want)
+		}
+	}
+}
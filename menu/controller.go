@@ -0,0 +1,158 @@
+package menu
+
+import "sync"
+
+// Item is the part of a tray menu item the Controller drives. The systray
+// library cannot remove or reorder items, so unused items are hidden instead
+type Item interface {
+	SetTitle(title string)
+	SetTooltip(tooltip string)
+	Show()
+	Hide()
+	Enable()
+	Disable()
+	Check()
+	Uncheck()
+	AddSubItem(title, tooltip string) Item
+	Clicked() <-chan struct{}
+}
+
+// Tray creates top-level menu items, appended after all existing ones
+type Tray interface {
+	AddItem(title, tooltip string) Item
+}
+
+// Row describes one menu item and its submenu as it should currently look
+type Row struct {
+	Title    string
+	Tooltip  string
+	Disabled bool
+	Checked  bool
+	Sub      []Row
+	Action   func() // Called on click; nil for display-only rows
+}
+
+// HeaderRow returns a disabled row used as a section title, e.g. "UDP"
+func HeaderRow(title string) Row {
+	return Row{Title: title, Disabled: true}
+}
+
+// SeparatorRow returns a disabled divider row. Real separators cannot be
+// hidden, so pooled menus draw dividers as rows
+func SeparatorRow() Row {
+	return HeaderRow("──────────")
+}
+
+// slot is a pooled menu item together with the state last applied to it
+type slot struct {
+	item     Item
+	title    string
+	tooltip  string
+	hidden   bool
+	disabled bool
+	checked  bool
+	action   func()
+	children []*slot
+}
+
+// Controller keeps a pool of menu items and updates them in place, so the
+// menu can be refreshed without recreating the tray. Rows are shown by the
+// pooled items in order; the pool grows when needed and surplus items are hidden
+type Controller struct {
+	mu   sync.Mutex
+	tray Tray
+	top  []*slot
+}
+
+// NewController returns a Controller that adds its items to tray
+func NewController(tray Tray) *Controller {
+	return &Controller{tray: tray}
+}
+
+// Render makes the menu show rows, touching only items that changed
+func (c *Controller) Render(rows []Row) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.top = c.renderRows(c.top, rows, c.tray.AddItem)
+}
+
+// renderRows applies rows to the pooled slots in order, adding items with
+// add. An item that ever had a submenu keeps it (hidden) and on macOS no
+// longer reports clicks, so rows without a submenu skip such slots
+func (c *Controller) renderRows(slots []*slot, rows []Row, add func(title, tooltip string) Item) []*slot {
+	next := 0
+	for _, row := range rows {
+		for ; next < len(slots) && len(row.Sub) == 0 && len(slots[next].children) > 0; next++ {
+			c.hide(slots[next])
+		}
+		if next == len(slots) {
+			slots = append(slots, c.newSlot(add(row.Title, row.Tooltip), row))
+		}
+		c.apply(slots[next], row)
+		next++
+	}
+	for _, s := range slots[next:] {
+		c.hide(s)
+	}
+	return slots
+}
+
+// hide hides an unused slot and drops its action
+func (c *Controller) hide(s *slot) {
+	if !s.hidden {
+		s.item.Hide()
+		s.hidden = true
+	}
+	s.action = nil
+}
+
+// newSlot wraps a freshly added item and starts dispatching its clicks
+func (c *Controller) newSlot(item Item, row Row) *slot {
+	s := &slot{item: item, title: row.Title, tooltip: row.Tooltip}
+	go func() {
+		for range item.Clicked() {
+			c.mu.Lock()
+			action := s.action
+			c.mu.Unlock()
+			if action != nil {
+				action()
+			}
+		}
+	}()
+	return s
+}
+
+// apply updates one slot to look like row
+func (c *Controller) apply(s *slot, row Row) {
+	if s.title != row.Title {
+		s.item.SetTitle(row.Title)
+		s.title = row.Title
+	}
+	if s.tooltip != row.Tooltip {
+		s.item.SetTooltip(row.Tooltip)
+		s.tooltip = row.Tooltip
+	}
+	if s.hidden {
+		s.item.Show()
+		s.hidden = false
+	}
+	if s.disabled != row.Disabled {
+		if row.Disabled {
+			s.item.Disable()
+		} else {
+			s.item.Enable()
+		}
+		s.disabled = row.Disabled
+	}
+	if s.checked != row.Checked {
+		if row.Checked {
+			s.item.Check()
+		} else {
+			s.item.Uncheck()
+		}
+		s.checked = row.Checked
+	}
+	s.action = row.Action
+	s.children = c.renderRows(s.children, row.Sub, s.item.AddSubItem)
+}
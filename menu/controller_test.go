@@ -0,0 +1,229 @@
+package menu
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeItem records the state a tray would display
+type fakeItem struct {
+	mu       sync.Mutex
+	title    string
+	tooltip  string
+	hidden   bool
+	disabled bool
+	checked  bool
+	updates  int // Setter calls after creation
+	children []*fakeItem
+	clicked  chan struct{}
+}
+
+func newFakeItem(title, tooltip string) *fakeItem {
+	return &fakeItem{title: title, tooltip: tooltip, clicked: make(chan struct{})}
+}
+
+func (f *fakeItem) set(fn func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fn()
+	f.updates++
+}
+
+func (f *fakeItem) SetTitle(title string)     { f.set(func() { f.title = title }) }
+func (f *fakeItem) SetTooltip(tooltip string) { f.set(func() { f.tooltip = tooltip }) }
+func (f *fakeItem) Show()                     { f.set(func() { f.hidden = false }) }
+func (f *fakeItem) Hide()                     { f.set(func() { f.hidden = true }) }
+func (f *fakeItem) Enable()                   { f.set(func() { f.disabled = false }) }
+func (f *fakeItem) Disable()                  { f.set(func() { f.disabled = true }) }
+func (f *fakeItem) Check()                    { f.set(func() { f.checked = true }) }
+func (f *fakeItem) Uncheck()                  { f.set(func() { f.checked = false }) }
+func (f *fakeItem) Clicked() <-chan struct{}  { return f.clicked }
+
+func (f *fakeItem) AddSubItem(title, tooltip string) Item {
+	child := newFakeItem(title, tooltip)
+	f.children = append(f.children, child)
+	return child
+}
+
+type fakeTray struct {
+	items []*fakeItem
+}
+
+func (t *fakeTray) AddItem(title, tooltip string) Item {
+	item := newFakeItem(title, tooltip)
+	t.items = append(t.items, item)
+	return item
+}
+
+// visible returns the titles of the items that are shown, in menu order
+func visible(items []*fakeItem) []string {
+	var titles []string
+	for _, item := range items {
+		if !item.hidden {
+			titles = append(titles, item.title)
+		}
+	}
+	return titles
+}
+
+func assertTitles(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %q, want %q", what, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s = %q, want %q", what, got, want)
+		}
+	}
+}
+
+func TestController_ReusesItems(t *testing.T) {
+	tray := &fakeTray{}
+	c := NewController(tray)
+
+	c.Render([]Row{
+		{Title: " 3000 • node", Sub: []Row{{Title: "Open in Browser"}, {Title: "Copy Port Number"}}},
+		{Title: " 5432 • postgres"},
+		SeparatorRow(),
+		{Title: "Quit"},
+	})
+	assertTitles(t, "menu", visible(tray.items), []string{" 3000 • node", " 5432 • postgres", "──────────", "Quit"})
+	if !tray.items[2].disabled {
+		t.Error("separator row is enabled")
+	}
+
+	// One listener went away: items shift up and the surplus one is hidden
+	c.Render([]Row{
+		{Title: " 5432 • postgres", Sub: []Row{{Title: "Copy Port Number"}}},
+		SeparatorRow(),
+		{Title: "Quit"},
+	})
+	if len(tray.items) != 4 {
+		t.Fatalf("tray has %d items, want the 4 pooled ones", len(tray.items))
+	}
+	assertTitles(t, "menu", visible(tray.items), []string{" 5432 • postgres", "──────────", "Quit"})
+	assertTitles(t, "submenu", visible(tray.items[0].children), []string{"Copy Port Number"})
+	if tray.items[2].disabled {
+		t.Error("Quit kept the disabled state of the separator it replaced")
+	}
+
+	// Growing again shows hidden items before adding new ones. Rows without
+	// a submenu skip the first item, which has one
+	c.Render([]Row{{Title: "a"}, {Title: "b"}, {Title: "c"}, {Title: "d"}, {Title: "e"}})
+	if len(tray.items) != 6 {
+		t.Fatalf("tray has %d items, want 6", len(tray.items))
+	}
+	assertTitles(t, "menu", visible(tray.items), []string{"a", "b", "c", "d", "e"})
+	if !tray.items[0].hidden {
+		t.Error("item with a submenu shows a row without one")
+	}
+}
+
+func TestController_QuitAfterShrinking(t *testing.T) {
+	tray := &fakeTray{}
+	c := NewController(tray)
+
+	quit := make(chan struct{}, 1)
+	bottom := []Row{
+		SeparatorRow(),
+		{Title: "Copy a Free Port", Sub: []Row{{Title: "3000-3999"}}},
+		{Title: "Port Digger v1.0"},
+		{Title: "LLM Settings", Sub: []Row{{Title: "Enabled"}}},
+		{Title: "Quit", Action: func() { quit <- struct{}{} }},
+	}
+	ports := []Row{
+		{Title: " 3000 • node", Sub: []Row{{Title: "Copy Port Number"}}},
+		{Title: " 5432 • postgres", Sub: []Row{{Title: "Copy Port Number"}}},
+		{Title: " 6379 • redis", Sub: []Row{{Title: "Copy Port Number"}}},
+	}
+	c.Render(append(append([]Row{}, ports...), bottom...))
+
+	// A listener went away, so Quit moves up to where LLM Settings was
+	c.Render(append(ports[:2:2], bottom...))
+	assertTitles(t, "menu", visible(tray.items), []string{" 3000 • node", " 5432 • postgres", "──────────", "Copy a Free Port", "Port Digger v1.0", "LLM Settings", "Quit"})
+
+	var item *fakeItem
+	for _, it := range tray.items {
+		if !it.hidden && it.title == "Quit" {
+			item = it
+		}
+	}
+	// On macOS an item with a submenu sends no clicks
+	if len(item.children) > 0 {
+		t.Fatalf("Quit is shown by an item with %d submenu items", len(item.children))
+	}
+	item.clicked <- struct{}{}
+	select {
+	case <-quit:
+	case <-time.After(time.Second):
+		t.Fatal("clicking Quit did nothing")
+	}
+}
+
+func TestController_SkipsUnchangedItems(t *testing.T) {
+	tray := &fakeTray{}
+	c := NewController(tray)
+
+	rows := []Row{
+		{Title: "About", Disabled: true},
+		{Title: "LLM Settings", Sub: []Row{{Title: "Enabled", Checked: true, Disabled: true}}},
+	}
+	c.Render(rows)
+	before := make([]int, len(tray.items))
+	for i, item := range tray.items {
+		before[i] = item.updates
+	}
+
+	c.Render(rows)
+	for i, item := range tray.items {
+		if item.updates != before[i] {
+			t.Errorf("item %q updated %d times on identical render", item.title, item.updates-before[i])
+		}
+	}
+	enabled := tray.items[1].children[0]
+	if !enabled.checked || !enabled.disabled {
+		t.Errorf("Enabled item = %+v, want checked and disabled", enabled)
+	}
+
+	rows[1].Sub[0].Checked = false
+	c.Render(rows)
+	if enabled.checked {
+		t.Error("Uncheck not applied")
+	}
+}
+
+func TestController_ClickRunsCurrentAction(t *testing.T) {
+	tray := &fakeTray{}
+	c := NewController(tray)
+
+	got := make(chan string, 1)
+	c.Render([]Row{{Title: "first", Action: func() { got <- "first" }}})
+	c.Render([]Row{{Title: "second", Action: func() { got <- "second" }}})
+
+	tray.items[0].clicked <- struct{}{}
+	select {
+	case name := <-got:
+		if name != "second" {
+			t.Errorf("click ran %q action, want the latest one", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("click did not run the action")
+	}
+
+	// Hidden items have no action; an action may re-render the menu
+	c.Render([]Row{{Title: "refresh", Action: func() {
+		c.Render(nil)
+		got <- "refresh"
+	}}})
+	tray.items[0].clicked <- struct{}{}
+	select {
+	case <-got:
+	case <-time.After(time.Second):
+		t.Fatal("action that re-renders deadlocked")
+	}
+	if !tray.items[0].hidden {
+		t.Error("item not hidden after rendering no rows")
+	}
+}
@@ -0,0 +1,83 @@
+package menu
+
+import (
+	"fmt"
+	"port-digger/scanner"
+	"strings"
+	"time"
+)
+
+// FormatDetails renders the lines of a listener's Details submenu
+// Fields that could not be collected are left out
+func FormatDetails(info scanner.ProcessInfo, now time.Time) []string {
+	var lines []string
+	if info.User != "" {
+		lines = append(lines, "User: "+info.User)
+	}
+	if info.PPID > 0 {
+		parent := fmt.Sprintf("PID %d", info.PPID)
+		if info.ParentName != "" {
+			parent = fmt.Sprintf("%s (PID %d)", info.ParentName, info.PPID)
+		}
+		lines = append(lines, "Parent: "+parent)
+	}
+	if !info.StartTime.IsZero() {
+		lines = append(lines, fmt.Sprintf("Started: %s (up %s)",
+			info.StartTime.Format("2006-01-02 15:04"), FormatUptime(info.Uptime(now))))
+	}
+	if info.Cwd != "" {
+		lines = append(lines, "Directory: "+info.Cwd)
+	}
+	if info.RSS > 0 {
+		lines = append(lines, "Memory: "+FormatBytes(info.RSS))
+	}
+	if info.PID > 0 {
+		lines = append(lines, fmt.Sprintf("CPU: %.1f%%", info.CPU))
+	}
+	return lines
+}
+
+// FormatUptime renders a duration with its two largest units, e.g. "3d 4h", "12m 5s"
+func FormatUptime(d time.Duration) string {
+	secs := int64(d / time.Second)
+	units := []struct {
+		suffix string
+		size   int64
+	}{
+		{"d", 86400},
+		{"h", 3600},
+		{"m", 60},
+		{"s", 1},
+	}
+
+	var parts []string
+	for _, u := range units {
+		if n := secs / u.size; n > 0 || len(parts) > 0 {
+			parts = append(parts, fmt.Sprintf("%d%s", n, u.suffix))
+			secs %= u.size
+		}
+		if len(parts) == 2 {
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return "0s"
+	}
+	return strings.Join(parts, " ")
+}
+
+// FormatBytes renders a byte count with a binary unit, e.g. "51.1 MB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value := float64(n)
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", value, suffixes[i])
+}
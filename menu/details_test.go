@@ -0,0 +1,87 @@
+package menu
+
+import (
+	"port-digger/scanner"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestFormatDetails(t *testing.T) {
+	start := time.Date(2026, time.October, 16, 9, 5, 0, 0, time.Local)
+	now := start.Add(3*time.Hour + 12*time.Minute)
+
+	tests := []struct {
+		name string
+		info scanner.ProcessInfo
+		want []string
+	}{
+		{
+			name: "all fields",
+			info: scanner.ProcessInfo{PID: 4242, PPID: 1, ParentName: "launchd", User: "dev", StartTime: start, CPU: 0.3, RSS: 52344 * 1024, Cwd: "/Users/dev/app"},
+			want: []string{
+				"User: dev",
+				"Parent: launchd (PID 1)",
+				"Started: 2026-10-16 09:05 (up 3h 12m)",
+				"Directory: /Users/dev/app",
+				"Memory: 51.1 MB",
+				"CPU: 0.3%",
+			},
+		},
+		{
+			name: "unknown parent name and cwd",
+			info: scanner.ProcessInfo{PID: 10, PPID: 2, User: "root"},
+			want: []string{"User: root", "Parent: PID 2", "CPU: 0.0%"},
+		},
+		{
+			name: "process not found",
+			info: scanner.ProcessInfo{},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDetails(tt.info, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FormatDetails() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0s"},
+		{42 * time.Second, "42s"},
+		{12*time.Minute + 5*time.Second, "12m 5s"},
+		{3*time.Hour + 7*time.Second, "3h 0m"},
+		{4*24*time.Hour + 5*time.Hour + 30*time.Minute, "4d 5h"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatUptime(tt.d); got != tt.want {
+			t.Errorf("FormatUptime(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{512, "512 B"},
+		{1536, "1.5 KB"},
+		{52344 * 1024, "51.1 MB"},
+		{3 << 30, "3.0 GB"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatBytes(tt.n); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package menu
+
+import "port-digger/scanner"
+
+// categoryIcons mark the service categories reported by the LLM
+var categoryIcons = map[string]string{
+	"database":       "🗄️",
+	"web-dev-server": "🌐",
+	"ide":            "🧑‍💻",
+	"proxy":          "🔀",
+	"system":         "⚙️",
+}
+
+// CategoryIcon returns the icon of a service category, "" for "other" and
+// unknown categories
+func CategoryIcon(category string) string {
+	return categoryIcons[category]
+}
+
+// FormatServiceItem formats a listener with its service name and category
+// Format: "  PORT • ProcessName (🗄️ ServiceName✨) · Addresses"
+func FormatServiceItem(l scanner.Listener, serviceName, category string) string {
+	if icon := CategoryIcon(category); icon != "" && serviceName != "" && serviceName != "未知" && serviceName != l.ProcessName {
+		serviceName = icon + " " + serviceName
+	}
+	return FormatListenerItem(l, serviceName)
+}
+
+// FormatServiceDetails renders the service lines shown above the process
+// details; empty fields are left out
+func FormatServiceDetails(category, framework string) []string {
+	var lines []string
+	if category != "" {
+		lines = append(lines, "Category: "+category)
+	}
+	if framework != "" {
+		lines = append(lines, "Framework: "+framework)
+	}
+	return lines
+}
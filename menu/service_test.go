@@ -0,0 +1,55 @@
+package menu
+
+import (
+	"port-digger/scanner"
+	"reflect"
+	"testing"
+)
+
+func TestFormatServiceItem(t *testing.T) {
+	listener := scanner.Listener{
+		Port:        5432,
+		PID:         42,
+		ProcessName: "postgres",
+		Protocol:    "TCP",
+		Sockets:     []scanner.PortInfo{{Port: 5432, BindAddress: "127.0.0.1", Family: scanner.FamilyIPv4}},
+	}
+
+	tests := []struct {
+		name        string
+		serviceName string
+		category    string
+		want        string
+	}{
+		{"with category", "PostgreSQL", "database", " 5432 • postgres (🗄️ PostgreSQL✨) · 127.0.0.1"},
+		{"other category", "PostgreSQL", "other", " 5432 • postgres (PostgreSQL✨) · 127.0.0.1"},
+		{"no category", "PostgreSQL", "", " 5432 • postgres (PostgreSQL✨) · 127.0.0.1"},
+		{"not identified", "", "database", " 5432 • postgres · 127.0.0.1"},
+		{"same as process name", "postgres", "database", " 5432 • postgres · 127.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatServiceItem(listener, tt.serviceName, tt.category); got != tt.want {
+				t.Errorf("FormatServiceItem() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatServiceDetails(t *testing.T) {
+	tests := []struct {
+		category, framework string
+		want                []string
+	}{
+		{"web-dev-server", "Vite", []string{"Category: web-dev-server", "Framework: Vite"}},
+		{"database", "", []string{"Category: database"}},
+		{"", "", nil},
+	}
+
+	for _, tt := range tests {
+		if got := FormatServiceDetails(tt.category, tt.framework); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("FormatServiceDetails(%q, %q) = %q, want %q", tt.category, tt.framework, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Package metrics implements the counters, histograms and gauges served in
+// the Prometheus text format by the exporter command and the local API
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Default holds the scan metrics, served by the exporter and the local API.
+// The LLM metrics are kept apart in llm.Metrics, which only the API serves
+var Default = NewRegistry()
+
+// Registry is a set of metrics written together
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+// metric is one metric family
+type metric interface {
+	// write appends the HELP, TYPE and sample lines of the family
+	write(b *strings.Builder)
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds m under name; registering a name twice is a programming error
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		panic("metrics: " + name + " registered twice")
+	}
+	r.metrics[name] = m
+}
+
+// WriteText writes all metrics in the Prometheus text format, sorted by name
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.metrics))
+	for name := range r.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	families := make([]metric, len(names))
+	for i, name := range names {
+		families[i] = r.metrics[name]
+	}
+	r.mu.Unlock()
+
+	// Gauge functions may be slow (e.g. a port scan), so they run unlocked
+	var b strings.Builder
+	for _, m := range families {
+		m.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the metrics of registries, in order, for Prometheus to scrape
+func Handler(registries ...*Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		for _, reg := range registries {
+			if err := reg.WriteText(w); err != nil {
+				return
+			}
+		}
+	})
+}
+
+// series is the set of label values of one time series, joined by "\xff"
+type series string
+
+func seriesOf(labelNames, labelValues []string) series {
+	if len(labelValues) != len(labelNames) {
+		panic(fmt.Sprintf("metrics: got %d label values for labels %v", len(labelValues), labelNames))
+	}
+	return series(strings.Join(labelValues, "\xff"))
+}
+
+// sortedSeries returns the keys of m in a stable order
+func sortedSeries[V any](m map[series]V) []series {
+	keys := make([]series, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// writeHeader writes the HELP and TYPE lines of a family
+func writeHeader(b *strings.Builder, name, help, kind string) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, kind)
+}
+
+// writeSample writes one sample line, e.g. name{a="1",b="2"} 3
+func writeSample(b *strings.Builder, name string, labelNames, labelValues []string, value float64) {
+	b.WriteString(name)
+	if len(labelNames) > 0 {
+		b.WriteByte('{')
+		for i, label := range labelNames {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", label, escapeLabel(labelValues[i]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(formatValue(value))
+	b.WriteByte('\n')
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Counter is a monotonically increasing value per label combination
+type Counter struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	values map[series]float64
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, values: make(map[series]float64)}
+	r.register(name, c)
+	return c
+}
+
+// Inc adds 1 to the series with labelValues
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative, to the series with labelValues
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter " + c.name + " decreased")
+	}
+	key := seriesOf(c.labels, labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+// Value returns the current value of the series with labelValues
+func (c *Counter) Value(labelValues ...string) float64 {
+	key := seriesOf(c.labels, labelValues)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *Counter) write(b *strings.Builder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(b, c.name, c.help, "counter")
+	if len(c.labels) == 0 && len(c.values) == 0 {
+		// An unlabelled counter exists from the start
+		writeSample(b, c.name, nil, nil, 0)
+	}
+	for _, key := range sortedSeries(c.values) {
+		writeSample(b, c.name, c.labels, splitSeries(key, len(c.labels)), c.values[key])
+	}
+}
+
+func splitSeries(key series, n int) []string {
+	if n == 0 {
+		return nil
+	}
+	return strings.Split(string(key), "\xff")
+}
+
+// DefaultBuckets are the histogram upper bounds in seconds for scans and
+// LLM requests, from a fast /proc read to a slow API call
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Histogram counts observations into cumulative buckets per label combination
+type Histogram struct {
+	name, help string
+	labels     []string
+	buckets    []float64
+
+	mu     sync.Mutex
+	values map[series]*histogramSeries
+}
+
+type histogramSeries struct {
+	counts []uint64 // Per bucket, not cumulative; the last one is +Inf
+	sum    float64
+	count  uint64
+}
+
+// NewHistogram registers a histogram with sorted bucket upper bounds
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{name: name, help: help, labels: labels, buckets: buckets, values: make(map[series]*histogramSeries)}
+	r.register(name, h)
+	return h
+}
+
+// Observe records v in the series with labelValues
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := seriesOf(h.labels, labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s := h.values[key]
+	if s == nil {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets)+1)}
+		h.values[key] = s
+	}
+	s.counts[sort.SearchFloat64s(h.buckets, v)]++
+	s.sum += v
+	s.count++
+}
+
+// Count returns how many values were observed in the series with labelValues
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	key := seriesOf(h.labels, labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s := h.values[key]; s != nil {
+		return s.count
+	}
+	return 0
+}
+
+func (h *Histogram) write(b *strings.Builder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(b, h.name, h.help, "histogram")
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+	for _, key := range sortedSeries(h.values) {
+		s := h.values[key]
+		values := splitSeries(key, len(h.labels))
+		var cumulative uint64
+		for i, count := range s.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(h.buckets) {
+				le = h.buckets[i]
+			}
+			writeSample(b, h.name+"_bucket", bucketLabels, append(append([]string{}, values...), formatValue(le)), float64(cumulative))
+		}
+		writeSample(b, h.name+"_sum", h.labels, values, s.sum)
+		writeSample(b, h.name+"_count", h.labels, values, float64(s.count))
+	}
+}
+
+// Sample is one value of a gauge function
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// GaugeFunc computes its samples when the metrics are written
+type GaugeFunc struct {
+	name, help string
+	labels     []string
+	collect    func() []Sample
+}
+
+// NewGaugeFunc registers a gauge whose samples come from collect at scrape time
+func (r *Registry) NewGaugeFunc(name, help string, collect func() []Sample, labels ...string) *GaugeFunc {
+	g := &GaugeFunc{name: name, help: help, labels: labels, collect: collect}
+	r.register(name, g)
+	return g
+}
+
+func (g *GaugeFunc) write(b *strings.Builder) {
+	samples := g.collect()
+	writeHeader(b, g.name, g.help, "gauge")
+	sort.Slice(samples, func(i, j int) bool {
+		return seriesOf(g.labels, samples[i].LabelValues) < seriesOf(g.labels, samples[j].LabelValues)
+	})
+	for _, s := range samples {
+		seriesOf(g.labels, s.LabelValues) // Validates the label count
+		writeSample(b, g.name, g.labels, s.LabelValues, s.Value)
+	}
+}
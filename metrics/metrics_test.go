@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	r := NewRegistry()
+	scans := r.NewCounter("test_scans_total", "Scans by backend.", "backend")
+	errors := r.NewCounter("test_errors_total", "Errors.")
+	duration := r.NewHistogram("test_duration_seconds", "Scan time.", []float64{0.1, 1}, "backend")
+	r.NewGaugeFunc("test_listening", "Listening sockets.", func() []Sample {
+		return []Sample{
+			{LabelValues: []string{"5432", `say "hi"\n`}, Value: 1},
+			{LabelValues: []string{"3000", "node"}, Value: 2},
+		}
+	}, "port", "process")
+
+	scans.Inc("procfs")
+	scans.Add(2, "lsof")
+	duration.Observe(0.05, "procfs")
+	duration.Observe(0.1, "procfs")
+	duration.Observe(3, "procfs")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_duration_seconds Scan time.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{backend="procfs",le="0.1"} 2
+test_duration_seconds_bucket{backend="procfs",le="1"} 2
+test_duration_seconds_bucket{backend="procfs",le="+Inf"} 3
+test_duration_seconds_sum{backend="procfs"} 3.15
+test_duration_seconds_count{backend="procfs"} 3
+# HELP test_errors_total Errors.
+# TYPE test_errors_total counter
+test_errors_total 0
+# HELP test_listening Listening sockets.
+# TYPE test_listening gauge
+test_listening{port="3000",process="node"} 2
+test_listening{port="5432",process="say \"hi\"\\n"} 1
+# HELP test_scans_total Scans by backend.
+# TYPE test_scans_total counter
+test_scans_total{backend="lsof"} 2
+test_scans_total{backend="procfs"} 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText() =\n%s\nwant\n%s", got, want)
+	}
+
+	errors.Inc()
+	if errors.Value() != 1 || scans.Value("lsof") != 2 || duration.Count("procfs") != 3 || duration.Count("ss") != 0 {
+		t.Error("Value() or Count() does not match the recorded values")
+	}
+}
+
+func TestRegistry_Misuse(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(r *Registry)
+	}{
+		{"duplicate name", func(r *Registry) {
+			r.NewCounter("dup", "")
+			r.NewCounter("dup", "")
+		}},
+		{"wrong label count", func(r *Registry) {
+			r.NewCounter("c", "", "a", "b").Inc("only-one")
+		}},
+		{"negative counter", func(r *Registry) {
+			r.NewCounter("c", "").Add(-1)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected a panic")
+				}
+			}()
+			tt.fn(NewRegistry())
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	first, second := NewRegistry(), NewRegistry()
+	first.NewCounter("first_total", "First.").Inc()
+	second.NewCounter("second_total", "Second.")
+
+	rec := httptest.NewRecorder()
+	Handler(first, second).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(string(body), "first_total 1\n") || !strings.Contains(string(body), "second_total 0\n") {
+		t.Errorf("body =\n%s", body)
+	}
+}
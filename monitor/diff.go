@@ -0,0 +1,65 @@
+package monitor
+
+import (
+	"port-digger/scanner"
+	"reflect"
+)
+
+// Diff lists how the listeners changed between two scans
+type Diff struct {
+	Added   []scanner.Listener
+	Removed []scanner.Listener
+	Changed []scanner.Listener // New state of listeners whose process or sockets changed
+}
+
+// Empty reports whether nothing changed
+func (d Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// listenerKey identifies the same listener across scans
+type listenerKey struct {
+	pid      int
+	port     int
+	protocol string
+}
+
+func keyOf(l scanner.Listener) listenerKey {
+	return listenerKey{pid: l.PID, port: l.Port, protocol: l.Protocol}
+}
+
+// Compare returns the changes from prev to next. Both are expected in
+// scanner.GroupListeners order, and the result keeps that order
+func Compare(prev, next []scanner.Listener) Diff {
+	before := make(map[listenerKey]scanner.Listener, len(prev))
+	for _, l := range prev {
+		before[keyOf(l)] = l
+	}
+	after := make(map[listenerKey]bool, len(next))
+
+	var d Diff
+	for _, l := range next {
+		key := keyOf(l)
+		after[key] = true
+		old, ok := before[key]
+		switch {
+		case !ok:
+			d.Added = append(d.Added, l)
+		case !sameListener(old, l):
+			d.Changed = append(d.Changed, l)
+		}
+	}
+	for _, l := range prev {
+		if !after[keyOf(l)] {
+			d.Removed = append(d.Removed, l)
+		}
+	}
+	return d
+}
+
+// sameListener reports whether two listeners with the same key look the same
+func sameListener(a, b scanner.Listener) bool {
+	return a.ProcessName == b.ProcessName &&
+		a.Command == b.Command &&
+		reflect.DeepEqual(a.Sockets, b.Sockets)
+}
@@ -0,0 +1,101 @@
+package monitor
+
+import (
+	"port-digger/scanner"
+	"testing"
+)
+
+func listener(pid, port int, addresses ...string) scanner.Listener {
+	l := scanner.Listener{Port: port, PID: pid, ProcessName: "node", Command: "node", Protocol: "TCP"}
+	for _, address := range addresses {
+		l.Sockets = append(l.Sockets, scanner.PortInfo{Port: port, PID: pid, ProcessName: "node", Protocol: "TCP", BindAddress: address, Family: scanner.FamilyIPv4})
+	}
+	return l
+}
+
+func ports(ls []scanner.Listener) []int {
+	var out []int
+	for _, l := range ls {
+		out = append(out, l.Port)
+	}
+	return out
+}
+
+func TestCompare(t *testing.T) {
+	renamed := listener(3, 5432, "127.0.0.1")
+	renamed.Command = "postgres -D /data"
+
+	tests := []struct {
+		name        string
+		prev, next  []scanner.Listener
+		added       []int
+		removed     []int
+		changed     []int
+		wantIsEmpty bool
+	}{
+		{
+			name:        "identical",
+			prev:        []scanner.Listener{listener(1, 3000, "0.0.0.0")},
+			next:        []scanner.Listener{listener(1, 3000, "0.0.0.0")},
+			wantIsEmpty: true,
+		},
+		{
+			name:  "first snapshot",
+			next:  []scanner.Listener{listener(1, 3000), listener(2, 8080)},
+			added: []int{3000, 8080},
+		},
+		{
+			name:    "added and removed",
+			prev:    []scanner.Listener{listener(1, 3000), listener(2, 8080)},
+			next:    []scanner.Listener{listener(2, 8080), listener(4, 9000)},
+			added:   []int{9000},
+			removed: []int{3000},
+		},
+		{
+			name:    "restarted on the same port",
+			prev:    []scanner.Listener{listener(1, 3000)},
+			next:    []scanner.Listener{listener(5, 3000)},
+			added:   []int{3000},
+			removed: []int{3000},
+		},
+		{
+			name:    "new bind address",
+			prev:    []scanner.Listener{listener(1, 3000, "127.0.0.1")},
+			next:    []scanner.Listener{listener(1, 3000, "127.0.0.1", "0.0.0.0")},
+			changed: []int{3000},
+		},
+		{
+			name:    "command line resolved",
+			prev:    []scanner.Listener{listener(3, 5432, "127.0.0.1")},
+			next:    []scanner.Listener{renamed},
+			changed: []int{5432},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := Compare(tt.prev, tt.next)
+			if d.Empty() != tt.wantIsEmpty {
+				t.Errorf("Empty() = %v, want %v: %+v", d.Empty(), tt.wantIsEmpty, d)
+			}
+			for _, check := range []struct {
+				what      string
+				got, want []int
+			}{
+				{"Added", ports(d.Added), tt.added},
+				{"Removed", ports(d.Removed), tt.removed},
+				{"Changed", ports(d.Changed), tt.changed},
+			} {
+				if len(check.got) != len(check.want) {
+					t.Errorf("%s ports = %v, want %v", check.what, check.got, check.want)
+					continue
+				}
+				for i := range check.want {
+					if check.got[i] != check.want[i] {
+						t.Errorf("%s ports = %v, want %v", check.what, check.got, check.want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package monitor
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SystemIdleTime returns the time since the last keyboard or mouse input:
+// HIDIdleTime from ioreg on macOS, xprintidle on Linux desktops
+func SystemIdleTime() (time.Duration, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		output, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+		if err != nil {
+			return 0, fmt.Errorf("ioreg failed: %w", err)
+		}
+		return parseHIDIdleTime(string(output))
+	case "linux":
+		output, err := exec.Command("xprintidle").Output()
+		if err != nil {
+			return 0, fmt.Errorf("xprintidle failed: %w", err)
+		}
+		return parseXprintidle(string(output))
+	default:
+		return 0, fmt.Errorf("idle time not supported on %s", runtime.GOOS)
+	}
+}
+
+// parseHIDIdleTime extracts the idle time from ioreg output
+// Example: `    | |   "HIDIdleTime" = 1234567890` (nanoseconds)
+func parseHIDIdleTime(output string) (time.Duration, error) {
+	for _, line := range strings.Split(output, "\n") {
+		_, value, ok := strings.Cut(line, `"HIDIdleTime" =`)
+		if !ok {
+			continue
+		}
+		ns, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid HIDIdleTime: %w", err)
+		}
+		return time.Duration(ns), nil
+	}
+	return 0, fmt.Errorf("no HIDIdleTime in ioreg output")
+}
+
+// parseXprintidle parses the milliseconds printed by xprintidle
+func parseXprintidle(output string) (time.Duration, error) {
+	ms, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid xprintidle output: %w", err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
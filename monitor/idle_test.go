@@ -0,0 +1,36 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseHIDIdleTime(t *testing.T) {
+	output := `+-o IOHIDSystem  <class IOHIDSystem, id 0x100000123, registered, matched, active, busy 0 (0 ms), retain 24>
+    | {
+    |   "HIDIdleTime" = 42500000000
+    |   "HIDParameters" = {"HIDKeyboardModifierMappingPairs"=()}
+    | }
+`
+	got, err := parseHIDIdleTime(output)
+	if err != nil {
+		t.Fatalf("parseHIDIdleTime() error = %v", err)
+	}
+	if got != 42500*time.Millisecond {
+		t.Errorf("parseHIDIdleTime() = %v, want 42.5s", got)
+	}
+
+	if _, err := parseHIDIdleTime("+-o IOHIDSystem\n"); err == nil {
+		t.Error("parseHIDIdleTime() expected error without HIDIdleTime")
+	}
+}
+
+func TestParseXprintidle(t *testing.T) {
+	got, err := parseXprintidle("1500\n")
+	if err != nil || got != 1500*time.Millisecond {
+		t.Errorf("parseXprintidle() = %v, %v; want 1.5s", got, err)
+	}
+	if _, err := parseXprintidle("couldn't open display\n"); err == nil {
+		t.Error("parseXprintidle() expected error for non-numeric output")
+	}
+}
@@ -0,0 +1,114 @@
+package monitor
+
+import (
+	"context"
+	"port-digger/logger"
+	"port-digger/scanner"
+	"time"
+)
+
+// Clock is the time source of a Poller, replaceable in tests
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// ScanFunc returns the current listening ports, e.g. scanner.ScanPorts
+type ScanFunc func() ([]scanner.PortInfo, error)
+
+// IdleFunc returns how long the user has been idle, e.g. SystemIdleTime
+type IdleFunc func() (time.Duration, error)
+
+// ChangeFunc receives the new listeners and what changed since the last call
+type ChangeFunc func(listeners []scanner.Listener, diff Diff)
+
+// Options configures a Poller
+type Options struct {
+	Interval    time.Duration // Time between scans while the user is active
+	IdleAfter   time.Duration // Idle time after which scans back off; 0 disables backoff
+	MaxInterval time.Duration // Upper bound for the backed-off interval
+}
+
+// Poller rescans ports in the background and reports changed snapshots
+type Poller struct {
+	scan  ScanFunc
+	opts  Options
+	Clock Clock
+	Idle  IdleFunc // nil means the user is never considered idle
+
+	prev    []scanner.Listener
+	started bool
+	wait    time.Duration
+}
+
+// NewPoller returns a Poller using the real clock and system idle time
+func NewPoller(scan ScanFunc, opts Options) *Poller {
+	if opts.MaxInterval < opts.Interval {
+		opts.MaxInterval = opts.Interval
+	}
+	return &Poller{
+		scan:  scan,
+		opts:  opts,
+		Clock: realClock{},
+		Idle:  SystemIdleTime,
+		wait:  opts.Interval,
+	}
+}
+
+// Run scans until ctx is done, calling onChange with the first snapshot and
+// afterwards only when listeners were added, removed or changed
+func (p *Poller) Run(ctx context.Context, onChange ChangeFunc) {
+	for {
+		p.Poll(onChange)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.Clock.After(p.nextInterval()):
+		}
+	}
+}
+
+// Poll performs one scan and calls onChange if the snapshot changed
+// A failed scan keeps the previous snapshot
+func (p *Poller) Poll(onChange ChangeFunc) {
+	ports, err := p.scan()
+	if err != nil {
+		logger.Error("Background scan failed: %v", err)
+		return
+	}
+
+	listeners := scanner.GroupListeners(ports)
+	diff := Compare(p.prev, listeners)
+	first := !p.started
+	p.prev, p.started = listeners, true
+
+	if first || !diff.Empty() {
+		logger.Debug("Listeners changed: %d added, %d removed, %d changed",
+			len(diff.Added), len(diff.Removed), len(diff.Changed))
+		onChange(listeners, diff)
+	}
+}
+
+// nextInterval returns the wait before the next scan: the configured interval
+// while the user is active, doubling up to MaxInterval while idle
+func (p *Poller) nextInterval() time.Duration {
+	if p.opts.IdleAfter <= 0 || p.Idle == nil {
+		return p.opts.Interval
+	}
+
+	idle, err := p.Idle()
+	if err != nil || idle < p.opts.IdleAfter {
+		p.wait = p.opts.Interval
+		return p.wait
+	}
+
+	p.wait *= 2
+	if p.wait > p.opts.MaxInterval {
+		p.wait = p.opts.MaxInterval
+	}
+	return p.wait
+}
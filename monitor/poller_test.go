@@ -0,0 +1,170 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"port-digger/scanner"
+	"testing"
+	"time"
+)
+
+// fakeClock hands out a tick channel per wait and records the durations
+type fakeClock struct {
+	waits chan time.Duration
+	tick  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{waits: make(chan time.Duration, 10), tick: make(chan time.Time)}
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits <- d
+	return c.tick
+}
+
+// fakeScanner returns the queued results in order, repeating the last one
+type fakeScanner struct {
+	results []scanResult
+	calls   int
+}
+
+type scanResult struct {
+	ports []scanner.PortInfo
+	err   error
+}
+
+func (s *fakeScanner) scan() ([]scanner.PortInfo, error) {
+	r := s.results[min(s.calls, len(s.results)-1)]
+	s.calls++
+	return r.ports, r.err
+}
+
+func port(pid, number int) scanner.PortInfo {
+	return scanner.PortInfo{Port: number, PID: pid, ProcessName: "node", Protocol: "TCP", BindAddress: "127.0.0.1", Family: scanner.FamilyIPv4}
+}
+
+func TestPoller_OnlyReportsChanges(t *testing.T) {
+	fs := &fakeScanner{results: []scanResult{
+		{ports: []scanner.PortInfo{port(1, 3000)}},
+		{ports: []scanner.PortInfo{port(1, 3000)}},
+		{err: errors.New("lsof vanished")},
+		{ports: []scanner.PortInfo{port(1, 3000), port(2, 8080)}},
+	}}
+	p := NewPoller(fs.scan, Options{Interval: 10 * time.Second})
+	p.Idle = nil
+
+	var diffs []Diff
+	onChange := func(listeners []scanner.Listener, diff Diff) { diffs = append(diffs, diff) }
+
+	for i := 0; i < 4; i++ {
+		p.Poll(onChange)
+	}
+
+	if fs.calls != 4 {
+		t.Errorf("scanned %d times, want 4", fs.calls)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("onChange called %d times, want 2 (first snapshot and the new port): %+v", len(diffs), diffs)
+	}
+	if len(diffs[0].Added) != 1 || len(diffs[1].Added) != 1 || diffs[1].Added[0].Port != 8080 {
+		t.Errorf("diffs = %+v", diffs)
+	}
+}
+
+func TestPoller_FirstEmptySnapshotIsReported(t *testing.T) {
+	fs := &fakeScanner{results: []scanResult{{ports: nil}}}
+	p := NewPoller(fs.scan, Options{Interval: time.Second})
+
+	calls := 0
+	p.Poll(func([]scanner.Listener, Diff) { calls++ })
+	p.Poll(func([]scanner.Listener, Diff) { calls++ })
+	if calls != 1 {
+		t.Errorf("onChange called %d times, want 1", calls)
+	}
+}
+
+func TestPoller_IdleBackoff(t *testing.T) {
+	idle := time.Duration(0)
+	p := NewPoller(nil, Options{Interval: 10 * time.Second, IdleAfter: 5 * time.Minute, MaxInterval: 60 * time.Second})
+	p.Idle = func() (time.Duration, error) { return idle, nil }
+
+	var got []time.Duration
+	step := func() { got = append(got, p.nextInterval()) }
+
+	step()
+	idle = 10 * time.Minute
+	step()
+	step()
+	step()
+	step()
+	idle = time.Second // user is back
+	step()
+
+	want := []time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 60 * time.Second, 60 * time.Second, 10 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("intervals = %v, want %v", got, want)
+		}
+	}
+
+	// Unknown idle time never backs off
+	p.Idle = func() (time.Duration, error) { return 0, errors.New("no display") }
+	if d := p.nextInterval(); d != 10*time.Second {
+		t.Errorf("nextInterval() with idle error = %v, want 10s", d)
+	}
+}
+
+func TestPoller_Run(t *testing.T) {
+	fs := &fakeScanner{results: []scanResult{
+		{ports: []scanner.PortInfo{port(1, 3000)}},
+		{ports: []scanner.PortInfo{port(1, 3000)}},
+		{ports: nil},
+	}}
+	clock := newFakeClock()
+	p := NewPoller(fs.scan, Options{Interval: 10 * time.Second})
+	p.Clock = clock
+	p.Idle = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan Diff, 10)
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx, func(_ []scanner.Listener, diff Diff) { changes <- diff })
+		close(done)
+	}()
+
+	expectWait := func() {
+		t.Helper()
+		select {
+		case d := <-clock.waits:
+			if d != 10*time.Second {
+				t.Errorf("waited %v, want 10s", d)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("poller did not wait for the next tick")
+		}
+	}
+
+	expectWait()
+	clock.tick <- time.Time{}
+	expectWait()
+	clock.tick <- time.Time{}
+	expectWait()
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	close(changes)
+
+	var got []Diff
+	for d := range changes {
+		got = append(got, d)
+	}
+	if len(got) != 2 || len(got[0].Added) != 1 || len(got[1].Removed) != 1 {
+		t.Errorf("changes = %+v, want the first snapshot and the removal", got)
+	}
+}
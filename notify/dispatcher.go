@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"fmt"
+	"port-digger/logger"
+	"port-digger/monitor"
+	"strings"
+	"sync"
+)
+
+// defaultMaxPerBatch is how many events of one scan are notified one by one
+// before they are summarised in a single notification
+const defaultMaxPerBatch = 3
+
+// Options configures a Dispatcher
+type Options struct {
+	Events      []Kind // Kinds notified when no rule matches
+	Rules       []Rule
+	MaxPerBatch int // 0 uses defaultMaxPerBatch
+}
+
+// Dispatcher turns snapshot diffs into rate-limited notifications
+type Dispatcher struct {
+	mu         sync.Mutex
+	notifier   Notifier
+	limiter    *Limiter
+	opts       Options
+	primed     bool
+	suppressed int
+}
+
+// NewDispatcher returns a Dispatcher; a nil limiter means no rate limit
+func NewDispatcher(notifier Notifier, limiter *Limiter, opts Options) *Dispatcher {
+	if opts.MaxPerBatch <= 0 {
+		opts.MaxPerBatch = defaultMaxPerBatch
+	}
+	return &Dispatcher{notifier: notifier, limiter: limiter, opts: opts}
+}
+
+// Handle notifies about the changes in diff. The first diff is the initial
+// snapshot, where everything is new, and is not notified
+func (d *Dispatcher) Handle(diff monitor.Diff) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.primed {
+		d.primed = true
+		return
+	}
+
+	var events []Event
+	for _, e := range Events(diff) {
+		if wanted(e, d.opts.Rules, d.opts.Events) {
+			events = append(events, e)
+		}
+	}
+
+	if len(events) > d.opts.MaxPerBatch {
+		title, message := summarize(events)
+		d.send(title, message, len(events))
+		return
+	}
+	for _, e := range events {
+		title, message := Format(e)
+		d.send(title, message, 1)
+	}
+}
+
+// send shows one notification covering count events, unless rate limited
+func (d *Dispatcher) send(title, message string, count int) {
+	if d.limiter != nil && !d.limiter.Allow() {
+		d.suppressed += count
+		logger.Debug("Notification suppressed by rate limit: %s", title)
+		return
+	}
+
+	if d.suppressed > 0 {
+		message += fmt.Sprintf(" (+%d earlier changes not shown)", d.suppressed)
+		d.suppressed = 0
+	}
+	if err := d.notifier.Notify(title, message); err != nil {
+		logger.Error("Failed to show notification %q: %v", title, err)
+	}
+}
+
+// summarize describes many events at once, e.g. "5 port changes" /
+// "3 opened: 3000, 3001, 8080; 2 closed: 5432, 6379"
+func summarize(events []Event) (string, string) {
+	ports := make(map[Kind][]string)
+	for _, e := range events {
+		ports[e.Kind] = append(ports[e.Kind], fmt.Sprint(e.Listener.Port))
+	}
+
+	var parts []string
+	for _, kind := range []struct {
+		kind  Kind
+		label string
+	}{
+		{Opened, "opened"},
+		{Closed, "closed"},
+		{Replaced, "taken over"},
+	} {
+		if list := ports[kind.kind]; len(list) > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s: %s", len(list), kind.label, strings.Join(list, ", ")))
+		}
+	}
+	return fmt.Sprintf("%d port changes", len(events)), strings.Join(parts, "; ")
+}
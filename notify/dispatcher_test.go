@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"fmt"
+	"port-digger/monitor"
+	"port-digger/scanner"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var allKinds = []Kind{Opened, Closed, Replaced}
+
+func TestDispatcher_SkipsInitialSnapshot(t *testing.T) {
+	n := &MemoryNotifier{}
+	d := NewDispatcher(n, nil, Options{Events: allKinds})
+
+	d.Handle(monitor.Diff{Added: []scanner.Listener{listener("node", 1, 3000, "127.0.0.1")}})
+	if len(n.Sent()) != 0 {
+		t.Fatalf("initial snapshot notified: %+v", n.Sent())
+	}
+
+	d.Handle(monitor.Diff{Removed: []scanner.Listener{listener("node", 1, 3000, "127.0.0.1")}})
+	want := []Notification{{"Port 3000 closed", "node (PID 1) stopped listening"}}
+	if got := n.Sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sent() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDispatcher_AppliesRules(t *testing.T) {
+	n := &MemoryNotifier{}
+	d := NewDispatcher(n, nil, Options{
+		Events: allKinds,
+		Rules:  []Rule{{Port: 9222, Notify: false}},
+	})
+	d.Handle(monitor.Diff{})
+
+	d.Handle(monitor.Diff{Added: []scanner.Listener{
+		listener("chrome", 5, 9222, "127.0.0.1"),
+		listener("node", 1, 3000, "127.0.0.1"),
+	}})
+	if got := n.Sent(); len(got) != 1 || got[0].Title != "Port 3000 opened" {
+		t.Errorf("Sent() = %+v, want only port 3000", got)
+	}
+}
+
+func TestDispatcher_SummarizesStorms(t *testing.T) {
+	n := &MemoryNotifier{}
+	d := NewDispatcher(n, nil, Options{Events: allKinds, MaxPerBatch: 2})
+	d.Handle(monitor.Diff{})
+
+	var added []scanner.Listener
+	for i := 0; i < 4; i++ {
+		added = append(added, listener("node", 10+i, 3000+i, "127.0.0.1"))
+	}
+	d.Handle(monitor.Diff{Added: added, Removed: []scanner.Listener{listener("redis-server", 3, 6379, "127.0.0.1")}})
+
+	want := []Notification{{"5 port changes", "4 opened: 3000, 3001, 3002, 3003; 1 closed: 6379"}}
+	if got := n.Sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sent() = %+v, want %+v", got, want)
+	}
+}
+
+func TestDispatcher_RateLimit(t *testing.T) {
+	now := time.Unix(1_790_000_000, 0)
+	limiter := NewLimiter(1, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	n := &MemoryNotifier{}
+	d := NewDispatcher(n, limiter, Options{Events: allKinds})
+	d.Handle(monitor.Diff{})
+
+	// A crash loop: the same server goes up and down on every scan
+	for i := 0; i < 4; i++ {
+		l := listener("node", 100+i, 3000, "127.0.0.1")
+		d.Handle(monitor.Diff{Added: []scanner.Listener{l}})
+		d.Handle(monitor.Diff{Removed: []scanner.Listener{l}})
+	}
+	if got := len(n.Sent()); got != 1 {
+		t.Fatalf("sent %d notifications within one minute, want 1", got)
+	}
+
+	now = now.Add(time.Minute)
+	d.Handle(monitor.Diff{Added: []scanner.Listener{listener("node", 200, 3000, "127.0.0.1")}})
+	sent := n.Sent()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d notifications, want 2", len(sent))
+	}
+	if want := fmt.Sprintf("node (PID 200) is listening on 127.0.0.1 (+%d earlier changes not shown)", 7); sent[1].Message != want {
+		t.Errorf("message = %q, want %q", sent[1].Message, want)
+	}
+}
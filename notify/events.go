@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"fmt"
+	"path"
+	"port-digger/monitor"
+	"port-digger/scanner"
+	"strings"
+)
+
+// Kind is the type of a port change
+type Kind string
+
+const (
+	Opened   Kind = "open"    // A new listener appeared
+	Closed   Kind = "close"   // A listener went away
+	Replaced Kind = "replace" // A different process now listens on the port
+)
+
+// Event is one change worth notifying about
+type Event struct {
+	Kind     Kind
+	Listener scanner.Listener // The new listener, or the closed one for Closed
+	Previous scanner.Listener // Former owner of the port, set for Replaced
+}
+
+// portKey identifies a port regardless of which process holds it
+type portKey struct {
+	port     int
+	protocol string
+}
+
+// Events turns a snapshot diff into events. A port that was released by one
+// process and taken by another in the same diff is reported as Replaced
+func Events(diff monitor.Diff) []Event {
+	removed := make(map[portKey]scanner.Listener)
+	for _, l := range diff.Removed {
+		removed[portKey{l.Port, l.Protocol}] = l
+	}
+
+	var events []Event
+	taken := make(map[portKey]bool)
+	for _, l := range diff.Added {
+		key := portKey{l.Port, l.Protocol}
+		if prev, ok := removed[key]; ok && !taken[key] {
+			taken[key] = true
+			events = append(events, Event{Kind: Replaced, Listener: l, Previous: prev})
+			continue
+		}
+		events = append(events, Event{Kind: Opened, Listener: l})
+	}
+	for _, l := range diff.Removed {
+		if !taken[portKey{l.Port, l.Protocol}] {
+			events = append(events, Event{Kind: Closed, Listener: l})
+		}
+	}
+	return events
+}
+
+// Rule decides whether matching events are notified. The first matching
+// rule wins; events matching no rule use the default event list
+type Rule struct {
+	Port    int    // 0 matches any port
+	Process string // Glob on the process name, e.g. "Google Chrome*"; "" matches any
+	Events  []Kind // Empty matches all kinds
+	Exposed bool   // Only match listeners reachable from other machines
+	Notify  bool   // false silences matching events
+}
+
+// matches reports whether the rule applies to e
+func (r Rule) matches(e Event) bool {
+	if r.Port != 0 && r.Port != e.Listener.Port {
+		return false
+	}
+	if r.Process != "" {
+		if ok, err := path.Match(r.Process, e.Listener.ProcessName); err != nil || !ok {
+			return false
+		}
+	}
+	if r.Exposed && !e.Listener.Exposed() {
+		return false
+	}
+	if len(r.Events) == 0 {
+		return true
+	}
+	for _, kind := range r.Events {
+		if kind == e.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// wanted reports whether e should be notified under rules, falling back to defaults
+func wanted(e Event, rules []Rule, defaults []Kind) bool {
+	for _, r := range rules {
+		if r.matches(e) {
+			return r.Notify
+		}
+	}
+	for _, kind := range defaults {
+		if kind == e.Kind {
+			return true
+		}
+	}
+	return false
+}
+
+// describe returns "node (PID 4242)"
+func describe(l scanner.Listener) string {
+	return fmt.Sprintf("%s (PID %d)", l.ProcessName, l.PID)
+}
+
+// portLabel returns "Port 3000" or "UDP port 5353"
+func portLabel(l scanner.Listener) string {
+	if l.IsUDP() {
+		return fmt.Sprintf("UDP port %d", l.Port)
+	}
+	return fmt.Sprintf("Port %d", l.Port)
+}
+
+// Format returns the notification title and message for e
+func Format(e Event) (string, string) {
+	l := e.Listener
+	switch e.Kind {
+	case Opened:
+		message := fmt.Sprintf("%s is listening on %s", describe(l), strings.Join(l.BindAddresses(), ", "))
+		if l.Exposed() {
+			message += " (reachable from the network)"
+		}
+		return portLabel(l) + " opened", message
+	case Closed:
+		return portLabel(l) + " closed", describe(l) + " stopped listening"
+	default:
+		return portLabel(l) + " taken over", fmt.Sprintf("%s replaced %s", describe(l), describe(e.Previous))
+	}
+}
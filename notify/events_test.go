@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"port-digger/monitor"
+	"port-digger/scanner"
+	"testing"
+)
+
+func listener(name string, pid, port int, address string) scanner.Listener {
+	return scanner.Listener{
+		Port: port, PID: pid, ProcessName: name, Protocol: "TCP",
+		Sockets: []scanner.PortInfo{{Port: port, PID: pid, ProcessName: name, Protocol: "TCP", BindAddress: address, Family: scanner.FamilyIPv4}},
+	}
+}
+
+func TestEvents(t *testing.T) {
+	node := listener("node", 1, 3000, "127.0.0.1")
+	vite := listener("vite", 2, 3000, "127.0.0.1")
+	redis := listener("redis-server", 3, 6379, "127.0.0.1")
+	nginx := listener("nginx", 4, 8080, "0.0.0.0")
+
+	events := Events(monitor.Diff{
+		Added:   []scanner.Listener{vite, nginx},
+		Removed: []scanner.Listener{node, redis},
+	})
+
+	want := []struct {
+		kind Kind
+		pid  int
+		prev int
+	}{
+		{Replaced, 2, 1},
+		{Opened, 4, 0},
+		{Closed, 3, 0},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("Events() = %+v, want %d events", events, len(want))
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Kind != w.kind || e.Listener.PID != w.pid || e.Previous.PID != w.prev {
+			t.Errorf("events[%d] = %s PID %d (prev %d), want %s PID %d (prev %d)",
+				i, e.Kind, e.Listener.PID, e.Previous.PID, w.kind, w.pid, w.prev)
+		}
+	}
+}
+
+func TestWanted(t *testing.T) {
+	chrome := Event{Kind: Opened, Listener: listener("Google Chrome Helper", 9, 9222, "127.0.0.1")}
+	dev := Event{Kind: Closed, Listener: listener("node", 1, 3000, "127.0.0.1")}
+	db := Event{Kind: Closed, Listener: listener("postgres", 7, 5432, "127.0.0.1")}
+
+	rules := []Rule{
+		{Process: "Google Chrome*", Notify: false},
+		{Port: 5432, Events: []Kind{Closed}, Notify: true},
+		{Port: 3000, Events: []Kind{Opened}, Notify: false},
+		{Events: []Kind{Closed}, Exposed: true, Notify: true},
+	}
+	defaults := []Kind{Opened, Replaced}
+
+	tests := []struct {
+		name  string
+		event Event
+		want  bool
+	}{
+		{"process glob silences", chrome, false},
+		{"port rule enables a kind off by default", db, true},
+		{"rule for another kind falls through to defaults", dev, false},
+		{"default kinds without a rule", Event{Kind: Opened, Listener: listener("vite", 2, 5173, "127.0.0.1")}, true},
+		{"exposed-only rule", Event{Kind: Closed, Listener: listener("nginx", 4, 8080, "0.0.0.0")}, true},
+		{"exposed-only rule skips loopback", Event{Kind: Closed, Listener: listener("vite", 2, 5173, "127.0.0.1")}, false},
+	}
+	for _, tt := range tests {
+		if got := wanted(tt.event, rules, defaults); got != tt.want {
+			t.Errorf("%s: wanted() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	udp := scanner.Listener{Port: 5353, PID: 312, ProcessName: "mDNSResponder", Protocol: "UDP",
+		Sockets: []scanner.PortInfo{{Port: 5353, Protocol: "UDP", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4}}}
+
+	tests := []struct {
+		name        string
+		event       Event
+		wantTitle   string
+		wantMessage string
+	}{
+		{
+			name:        "opened on loopback",
+			event:       Event{Kind: Opened, Listener: listener("node", 1, 3000, "127.0.0.1")},
+			wantTitle:   "Port 3000 opened",
+			wantMessage: "node (PID 1) is listening on 127.0.0.1",
+		},
+		{
+			name:        "opened on all interfaces",
+			event:       Event{Kind: Opened, Listener: listener("nginx", 4, 8080, "0.0.0.0")},
+			wantTitle:   "Port 8080 opened",
+			wantMessage: "nginx (PID 4) is listening on 0.0.0.0 (reachable from the network)",
+		},
+		{
+			name:        "closed UDP",
+			event:       Event{Kind: Closed, Listener: udp},
+			wantTitle:   "UDP port 5353 closed",
+			wantMessage: "mDNSResponder (PID 312) stopped listening",
+		},
+		{
+			name:        "replaced",
+			event:       Event{Kind: Replaced, Listener: listener("vite", 2, 3000, "127.0.0.1"), Previous: listener("node", 1, 3000, "127.0.0.1")},
+			wantTitle:   "Port 3000 taken over",
+			wantMessage: "vite (PID 2) replaced node (PID 1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, message := Format(tt.event)
+			if title != tt.wantTitle || message != tt.wantMessage {
+				t.Errorf("Format() = %q, %q; want %q, %q", title, message, tt.wantTitle, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket: up to burst notifications at once, refilled at
+// one token per interval
+type Limiter struct {
+	mu       sync.Mutex
+	burst    float64
+	interval time.Duration
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+// NewLimiter returns a full bucket; a burst below 1 allows no notifications
+func NewLimiter(burst int, interval time.Duration) *Limiter {
+	return &Limiter{
+		burst:    float64(burst),
+		interval: interval,
+		tokens:   float64(burst),
+		now:      time.Now,
+	}
+}
+
+// Allow takes a token if one is available
+func (l *Limiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() && l.interval > 0 {
+		l.tokens += float64(now.Sub(l.last)) / float64(l.interval)
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	l.last = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
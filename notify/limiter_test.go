@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(1_790_000_000, 0)
+	l := NewLimiter(2, 30*time.Second)
+	l.now = func() time.Time { return now }
+
+	if !l.Allow() || !l.Allow() {
+		t.Fatal("burst of 2 not allowed")
+	}
+	if l.Allow() {
+		t.Error("third notification allowed within the burst")
+	}
+
+	now = now.Add(15 * time.Second)
+	if l.Allow() {
+		t.Error("allowed after half a refill interval")
+	}
+	now = now.Add(15 * time.Second)
+	if !l.Allow() {
+		t.Error("not allowed after a full refill interval")
+	}
+
+	// A long pause refills to the burst size, not beyond
+	now = now.Add(time.Hour)
+	allowed := 0
+	for i := 0; i < 5; i++ {
+		if l.Allow() {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("allowed %d after a long pause, want the burst of 2", allowed)
+	}
+}
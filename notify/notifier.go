@@ -0,0 +1,127 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Notifier shows a desktop notification
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+// runFunc executes a command, replaceable in tests
+type runFunc func(name string, args ...string) error
+
+func runCommand(name string, args ...string) error {
+	return exec.Command(name, args...).Run()
+}
+
+// appName is shown as the notification source where the platform supports it
+const appName = "Port Digger"
+
+// osascriptScript displays its two arguments, so titles never need quoting
+const osascriptScript = `on run argv
+display notification (item 2 of argv) with title (item 1 of argv)
+end run`
+
+// OSAScriptNotifier shows macOS notifications through osascript
+type OSAScriptNotifier struct {
+	run runFunc
+}
+
+// NewOSAScriptNotifier returns a macOS notifier
+func NewOSAScriptNotifier() *OSAScriptNotifier {
+	return &OSAScriptNotifier{run: runCommand}
+}
+
+// Notify passes title and message as script arguments rather than splicing them into the script
+func (n *OSAScriptNotifier) Notify(title, message string) error {
+	if err := n.run("osascript", "-e", osascriptScript, title, message); err != nil {
+		return fmt.Errorf("osascript failed: %w", err)
+	}
+	return nil
+}
+
+// DesktopNotifier shows freedesktop notifications through notify-send, or by
+// calling the D-Bus notification service with gdbus when notify-send is missing
+type DesktopNotifier struct {
+	run      runFunc
+	lookPath func(file string) (string, error)
+}
+
+// NewDesktopNotifier returns a Linux desktop notifier
+func NewDesktopNotifier() *DesktopNotifier {
+	return &DesktopNotifier{run: runCommand, lookPath: exec.LookPath}
+}
+
+func (n *DesktopNotifier) Notify(title, message string) error {
+	if _, err := n.lookPath("notify-send"); err == nil {
+		if err := n.run("notify-send", "--app-name", appName, "--", title, message); err != nil {
+			return fmt.Errorf("notify-send failed: %w", err)
+		}
+		return nil
+	}
+
+	// org.freedesktop.Notifications.Notify(app_name, replaces_id, app_icon,
+	// summary, body, actions, hints, expire_timeout)
+	err := n.run("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		gvariantString(appName), "0", gvariantString(""),
+		gvariantString(title), gvariantString(message),
+		"[]", "{}", "5000")
+	if err != nil {
+		return fmt.Errorf("gdbus notification failed: %w", err)
+	}
+	return nil
+}
+
+// gvariantString quotes s as a GVariant text-format string literal for gdbus
+func gvariantString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+// Notification is one notification recorded by a MemoryNotifier
+type Notification struct {
+	Title   string
+	Message string
+}
+
+// MemoryNotifier records notifications instead of showing them
+type MemoryNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (n *MemoryNotifier) Notify(title, message string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, Notification{Title: title, Message: message})
+	return nil
+}
+
+// Sent returns the notifications recorded so far
+func (n *MemoryNotifier) Sent() []Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Notification(nil), n.sent...)
+}
+
+// Default returns the notifier for the current platform, or nil if there is none
+func Default() Notifier {
+	switch runtime.GOOS {
+	case "darwin":
+		return NewOSAScriptNotifier()
+	case "linux":
+		return NewDesktopNotifier()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// recorder captures the commands a notifier runs
+type recorder struct {
+	calls [][]string
+	err   error
+}
+
+func (r *recorder) run(name string, args ...string) error {
+	r.calls = append(r.calls, append([]string{name}, args...))
+	return r.err
+}
+
+func TestOSAScriptNotifier(t *testing.T) {
+	rec := &recorder{}
+	n := &OSAScriptNotifier{run: rec.run}
+
+	// Quotes must reach osascript as data, not as script source
+	if err := n.Notify(`Port 3000 "opened"`, `node's server`); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	want := []string{"osascript", "-e", osascriptScript, `Port 3000 "opened"`, `node's server`}
+	if len(rec.calls) != 1 || !reflect.DeepEqual(rec.calls[0], want) {
+		t.Errorf("ran %q, want %q", rec.calls, want)
+	}
+
+	rec.err = errors.New("exit status 1")
+	if err := n.Notify("a", "b"); err == nil {
+		t.Error("Notify() expected error when osascript fails")
+	}
+}
+
+func TestDesktopNotifier(t *testing.T) {
+	tests := []struct {
+		name      string
+		available bool
+		want      []string
+	}{
+		{
+			name:      "notify-send",
+			available: true,
+			want:      []string{"notify-send", "--app-name", "Port Digger", "--", "Port 3000 opened", "-node's server"},
+		},
+		{
+			name:      "gdbus fallback",
+			available: false,
+			want: []string{"gdbus", "call", "--session",
+				"--dest", "org.freedesktop.Notifications",
+				"--object-path", "/org/freedesktop/Notifications",
+				"--method", "org.freedesktop.Notifications.Notify",
+				"'Port Digger'", "0", "''", "'Port 3000 opened'", `'-node\'s server'`, "[]", "{}", "5000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recorder{}
+			n := &DesktopNotifier{run: rec.run, lookPath: func(file string) (string, error) {
+				if tt.available {
+					return "/usr/bin/" + file, nil
+				}
+				return "", errors.New("not found")
+			}}
+			if err := n.Notify("Port 3000 opened", "-node's server"); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+			if len(rec.calls) != 1 || !reflect.DeepEqual(rec.calls[0], tt.want) {
+				t.Errorf("ran %q, want %q", rec.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestGVariantString(t *testing.T) {
+	tests := map[string]string{
+		"":         "''",
+		"plain":    "'plain'",
+		"it's":     `'it\'s'`,
+		`C:\path`:  `'C:\\path'`,
+		`"quoted"`: `'"quoted"'`,
+	}
+	for in, want := range tests {
+		if got := gvariantString(in); got != want {
+			t.Errorf("gvariantString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestMemoryNotifier(t *testing.T) {
+	n := &MemoryNotifier{}
+	n.Notify("a", "1")
+	n.Notify("b", "2")
+	want := []Notification{{"a", "1"}, {"b", "2"}}
+	if got := n.Sent(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sent() = %+v, want %+v", got, want)
+	}
+}
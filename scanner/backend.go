@@ -0,0 +1,155 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"port-digger/logger"
+	"port-digger/metrics"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scan metrics, labelled by backend
+var (
+	scanDuration = metrics.Default.NewHistogram("port_digger_scan_duration_seconds",
+		"Time taken by scanner backends to list sockets.", metrics.DefaultBuckets, "backend")
+	scanErrors = metrics.Default.NewCounter("port_digger_scan_errors_total",
+		"Failed scans by backend; \"none\" counts scans with no usable backend.", "backend")
+)
+
+// Backend lists listening sockets using one particular system facility
+// All backends must return the same []PortInfo for the same system, except
+// that Command may only hold the process name when argv is not available
+type Backend interface {
+	// Name is the short identifier used in config and flags, e.g. "lsof"
+	Name() string
+	// Available reports whether the backend can run on this system
+	Available() bool
+	// Scan returns all listening ports seen by this backend
+	Scan() ([]PortInfo, error)
+}
+
+// AutoBackend selects the first available backend in preference order
+const AutoBackend = "auto"
+
+// commandRunner runs an external command and returns its stdout
+// Backends hold one so tests can substitute canned command output
+type commandRunner func(name string, args ...string) ([]byte, error)
+
+// runCommand is the default commandRunner
+func runCommand(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).Output()
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Backend)
+	order      []string // preference order for automatic selection
+	preferred  string   // backend forced via SetBackend, tried first
+)
+
+func init() {
+	// Fastest and most detailed sources first
+	Register(newProcfsBackend(defaultProcRoot))
+	Register(newSSBackend())
+	Register(newLsofBackend())
+	Register(newNetstatBackend())
+}
+
+// Register adds a backend to the registry
+// Backends registered later have lower priority in automatic selection
+func Register(b Backend) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[b.Name()]; !exists {
+		order = append(order, b.Name())
+	}
+	registry[b.Name()] = b
+}
+
+// Lookup returns the registered backend with the given name
+func Lookup(name string) (Backend, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	b, ok := registry[name]
+	return b, ok
+}
+
+// BackendNames returns the names of all registered backends, sorted
+func BackendNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetBackend forces the named backend to be tried first
+// An empty name or "auto" restores automatic selection
+func SetBackend(name string) error {
+	if name == AutoBackend {
+		name = ""
+	}
+	if name != "" {
+		if _, ok := Lookup(name); !ok {
+			return fmt.Errorf("unknown scanner backend %q (available: %v)", name, BackendNames())
+		}
+	}
+
+	registryMu.Lock()
+	preferred = name
+	registryMu.Unlock()
+	return nil
+}
+
+// candidates returns backends in the order ScanPorts should try them
+func candidates() []Backend {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	list := make([]Backend, 0, len(order))
+	if b, ok := registry[preferred]; ok {
+		list = append(list, b)
+	}
+	for _, name := range order {
+		if name != preferred {
+			list = append(list, registry[name])
+		}
+	}
+	return list
+}
+
+// scanWith tries each backend in turn, falling back when one is missing or fails
+func scanWith(backends []Backend) ([]PortInfo, error) {
+	var errs []error
+	for _, b := range backends {
+		if !b.Available() {
+			logger.Debug("Scanner backend %s not available, skipping", b.Name())
+			continue
+		}
+
+		start := time.Now()
+		ports, err := b.Scan()
+		scanDuration.Observe(time.Since(start).Seconds(), b.Name())
+		if err != nil {
+			scanErrors.Inc(b.Name())
+			logger.Error("Scanner backend %s failed, falling back: %v", b.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", b.Name(), err))
+			continue
+		}
+		return ports, nil
+	}
+
+	scanErrors.Inc("none")
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no scanner backend available")
+	}
+	return nil, errors.Join(errs...)
+}
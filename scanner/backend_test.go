@@ -0,0 +1,211 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fakeBackend is a Backend with canned results
+type fakeBackend struct {
+	name      string
+	available bool
+	ports     []PortInfo
+	err       error
+	calls     int
+}
+
+func (b *fakeBackend) Name() string    { return b.name }
+func (b *fakeBackend) Available() bool { return b.available }
+func (b *fakeBackend) Scan() ([]PortInfo, error) {
+	b.calls++
+	return b.ports, b.err
+}
+
+// cannedRunner returns testdata/backends/<command>.txt for any invocation,
+// or <command>-udp.txt when the arguments select UDP sockets only
+func cannedRunner(t *testing.T) commandRunner {
+	t.Helper()
+	return func(name string, args ...string) ([]byte, error) {
+		file := name + ".txt"
+		for _, arg := range args {
+			if arg == "-iUDP" {
+				file = name + "-udp.txt"
+			}
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "backends", file))
+		if err != nil {
+			return nil, fmt.Errorf("no canned output for %s %s: %w", name, strings.Join(args, " "), err)
+		}
+		return data, nil
+	}
+}
+
+// sortedForCompare orders ports and drops Command, which only procfs fills with argv
+func sortedForCompare(ports []PortInfo) []PortInfo {
+	out := make([]PortInfo, len(ports))
+	copy(out, ports)
+	for i := range out {
+		out[i].Command = ""
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Port != b.Port {
+			return a.Port < b.Port
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		return a.PID < b.PID
+	})
+	return out
+}
+
+func TestBackends_IdenticalOutput(t *testing.T) {
+	tcp := "" +
+		"   0: 00000000:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 31001 1 0 100 0 0 10 0\n" +
+		"   1: 0100007F:1538 00000000:0000 0A 00000000:00000000 00:00000000 00000000   999        0 31002 1 0 100 0 0 10 0\n" +
+		"   2: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 31005 1 0 100 0 0 10 0\n"
+	tcp6 := "" +
+		"   0: 00000000000000000000000000000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 32001 1 0 100 0 0 10 0\n"
+	udp := "" +
+		"  12: 00000000:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000    65        0 33001 2 0 0\n" +
+		"  13: 0100007F:C3CB 0100007F:0035 01 00000000:00000000 00:00000000 00000000  1000        0 33002 2 0 0\n"
+	root := writeFakeProcfs(t, map[string]string{"tcp": tcp, "tcp6": tcp6, "udp": udp}, []fakeProcess{
+		{pid: 312, comm: "mDNSResponder", cmdline: []string{"/usr/sbin/mDNSResponder"}, sockets: []string{"33001"}},
+		{pid: 4242, comm: "node", cmdline: []string{"node", "server.js"}, sockets: []string{"31001", "32001", "33002"}},
+		{pid: 777, comm: "postgres", cmdline: []string{"postgres", "-D", "/data"}, sockets: []string{"31002"}},
+		{pid: 1200, comm: "nginx", cmdline: []string{"nginx: master process"}, sockets: []string{"31005"}},
+		{pid: 1201, comm: "nginx", cmdline: []string{"nginx: worker process"}, sockets: []string{"31005"}},
+	})
+
+	run := cannedRunner(t)
+	backends := []Backend{
+		newProcfsBackend(root),
+		&ssBackend{run: run},
+		&lsofBackend{run: run},
+		&netstatBackend{run: run},
+	}
+
+	want := []PortInfo{
+		{Port: 3000, ProcessName: "node", PID: 4242, Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 3000, ProcessName: "node", PID: 4242, Protocol: "TCP6", BindAddress: "::", Family: FamilyIPv6},
+		{Port: 5353, ProcessName: "mDNSResponder", PID: 312, Protocol: "UDP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 5432, ProcessName: "postgres", PID: 777, Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+		{Port: 8080, ProcessName: "nginx", PID: 1200, Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 8080, ProcessName: "nginx", PID: 1201, Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+	}
+
+	for _, b := range backends {
+		t.Run(b.Name(), func(t *testing.T) {
+			ports, err := b.Scan()
+			if err != nil {
+				t.Fatalf("%s.Scan() error = %v", b.Name(), err)
+			}
+			if got := sortedForCompare(ports); !reflect.DeepEqual(got, want) {
+				t.Errorf("%s.Scan() =\n%+v\nwant\n%+v", b.Name(), got, want)
+			}
+		})
+	}
+}
+
+func TestScanWith_Fallback(t *testing.T) {
+	good := []PortInfo{{Port: 3000, ProcessName: "node", PID: 1, Protocol: "TCP"}}
+
+	missing := &fakeBackend{name: "missing", available: false}
+	broken := &fakeBackend{name: "broken", available: true, err: errors.New("boom")}
+	working := &fakeBackend{name: "working", available: true, ports: good}
+	unused := &fakeBackend{name: "unused", available: true}
+
+	ports, err := scanWith([]Backend{missing, broken, working, unused})
+	if err != nil {
+		t.Fatalf("scanWith() error = %v", err)
+	}
+	if !reflect.DeepEqual(ports, good) {
+		t.Errorf("scanWith() = %+v, want %+v", ports, good)
+	}
+	if missing.calls != 0 {
+		t.Error("unavailable backend was scanned")
+	}
+	if broken.calls != 1 || working.calls != 1 || unused.calls != 0 {
+		t.Errorf("calls: broken=%d working=%d unused=%d, want 1 1 0", broken.calls, working.calls, unused.calls)
+	}
+}
+
+func TestScanWith_AllFail(t *testing.T) {
+	_, err := scanWith([]Backend{
+		&fakeBackend{name: "a", available: true, err: errors.New("first")},
+		&fakeBackend{name: "b", available: true, err: errors.New("second")},
+	})
+	if err == nil {
+		t.Fatal("scanWith() expected error when all backends fail")
+	}
+	if !strings.Contains(err.Error(), "a: first") || !strings.Contains(err.Error(), "b: second") {
+		t.Errorf("scanWith() error = %v, want both backend errors", err)
+	}
+
+	if _, err := scanWith([]Backend{&fakeBackend{name: "x"}}); err == nil {
+		t.Error("scanWith() expected error when no backend is available")
+	}
+}
+
+func TestScanWith_Metrics(t *testing.T) {
+	scans, errs, none := scanDuration.Count("m-broken"), scanErrors.Value("m-broken"), scanErrors.Value("none")
+
+	scanWith([]Backend{
+		&fakeBackend{name: "m-broken", available: true, err: errors.New("boom")},
+		&fakeBackend{name: "m-working", available: true},
+	})
+	scanWith([]Backend{&fakeBackend{name: "m-broken", available: true, err: errors.New("boom")}})
+
+	if got := scanDuration.Count("m-broken") - scans; got != 2 {
+		t.Errorf("m-broken scans observed = %d, want 2", got)
+	}
+	if got := scanDuration.Count("m-working"); got != 1 {
+		t.Errorf("m-working scans observed = %d, want 1", got)
+	}
+	if got := scanErrors.Value("m-broken") - errs; got != 2 {
+		t.Errorf("m-broken errors = %v, want 2", got)
+	}
+	if got := scanErrors.Value("none") - none; got != 1 {
+		t.Errorf("scans without a working backend = %v, want 1", got)
+	}
+}
+
+func TestSetBackend(t *testing.T) {
+	defer SetBackend(AutoBackend)
+
+	if err := SetBackend("no-such-backend"); err == nil {
+		t.Error("SetBackend() expected error for unknown backend")
+	}
+
+	if err := SetBackend("lsof"); err != nil {
+		t.Fatalf("SetBackend(lsof) error = %v", err)
+	}
+	list := candidates()
+	if list[0].Name() != "lsof" {
+		t.Errorf("candidates()[0] = %s, want lsof", list[0].Name())
+	}
+	if len(list) != len(BackendNames()) {
+		t.Errorf("candidates() has %d backends, want %d", len(list), len(BackendNames()))
+	}
+
+	if err := SetBackend(AutoBackend); err != nil {
+		t.Fatalf("SetBackend(auto) error = %v", err)
+	}
+	if got := candidates()[0].Name(); got != "procfs" {
+		t.Errorf("candidates()[0] = %s, want procfs", got)
+	}
+}
+
+func TestBackendNames(t *testing.T) {
+	want := []string{"lsof", "netstat", "procfs", "ss"}
+	if got := BackendNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("BackendNames() = %v, want %v", got, want)
+	}
+}
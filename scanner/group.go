@@ -0,0 +1,144 @@
+package scanner
+
+import (
+	"sort"
+	"strings"
+)
+
+// Listener is one process listening on one port. All of its sockets for that
+// port (IPv4 and IPv6, or the same address on several fds) are merged into a
+// single logical entry so the menu shows one item per service
+type Listener struct {
+	Port        int
+	PID         int
+	ProcessName string
+	Command     string
+	Protocol    string     // Transport without family suffix, "TCP" or "UDP"
+	Sockets     []PortInfo // One per distinct bind address, IPv4 first
+}
+
+// listenerKey identifies a Listener: SO_REUSEPORT listeners in different
+// processes stay separate, as do different ports of the same process
+type listenerKey struct {
+	pid      int
+	port     int
+	protocol string
+}
+
+// baseProtocol strips the family suffix, "TCP6" -> "TCP"
+func baseProtocol(protocol string) string {
+	return strings.TrimSuffix(protocol, "6")
+}
+
+// GroupListeners collapses ports by (PID, port, transport) into Listeners
+// sorted by port, then PID
+func GroupListeners(ports []PortInfo) []Listener {
+	index := make(map[listenerKey]int)
+	var listeners []Listener
+
+	for _, p := range ports {
+		key := listenerKey{pid: p.PID, port: p.Port, protocol: baseProtocol(p.Protocol)}
+
+		i, ok := index[key]
+		if !ok {
+			i = len(listeners)
+			index[key] = i
+			listeners = append(listeners, Listener{
+				Port:        p.Port,
+				PID:         p.PID,
+				ProcessName: p.ProcessName,
+				Command:     p.Command,
+				Protocol:    key.protocol,
+			})
+		}
+
+		l := &listeners[i]
+		// Prefer a full command line over a bare process name
+		if len(p.Command) > len(l.Command) {
+			l.Command = p.Command
+		}
+		if !l.hasSocket(p) {
+			l.Sockets = append(l.Sockets, p)
+		}
+	}
+
+	for i := range listeners {
+		sockets := listeners[i].Sockets
+		sort.SliceStable(sockets, func(a, b int) bool {
+			return sockets[a].Family < sockets[b].Family // "IPv4" < "IPv6"
+		})
+	}
+
+	sort.SliceStable(listeners, func(i, j int) bool {
+		if listeners[i].Port != listeners[j].Port {
+			return listeners[i].Port < listeners[j].Port
+		}
+		return listeners[i].PID < listeners[j].PID
+	})
+
+	return listeners
+}
+
+// hasSocket reports whether an equivalent socket was already merged
+func (l *Listener) hasSocket(p PortInfo) bool {
+	for _, s := range l.Sockets {
+		if s.BindAddress == p.BindAddress && s.Family == p.Family {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUDP reports whether the listener is a UDP socket
+func (l Listener) IsUDP() bool {
+	return l.Protocol == "UDP"
+}
+
+// BindAddresses returns the distinct bind addresses in socket order
+func (l Listener) BindAddresses() []string {
+	addresses := make([]string, 0, len(l.Sockets))
+	for _, s := range l.Sockets {
+		addresses = append(addresses, s.BindAddress)
+	}
+	return addresses
+}
+
+// Families returns the distinct address families, e.g. ["IPv4", "IPv6"]
+func (l Listener) Families() []string {
+	var families []string
+	for _, s := range l.Sockets {
+		if len(families) == 0 || families[len(families)-1] != s.Family {
+			families = append(families, s.Family)
+		}
+	}
+	return families
+}
+
+// Exposed reports whether any of the sockets is reachable from other machines
+func (l Listener) Exposed() bool {
+	for _, s := range l.Sockets {
+		if s.Exposed() {
+			return true
+		}
+	}
+	return false
+}
+
+// Primary returns the socket to use for actions like opening a browser:
+// a wildcard bind if there is one, then a loopback bind, then the first socket
+func (l Listener) Primary() PortInfo {
+	for _, s := range l.Sockets {
+		if s.IsWildcard() {
+			return s
+		}
+	}
+	for _, s := range l.Sockets {
+		if s.IsLoopback() {
+			return s
+		}
+	}
+	if len(l.Sockets) > 0 {
+		return l.Sockets[0]
+	}
+	return PortInfo{Port: l.Port, ProcessName: l.ProcessName, PID: l.PID, Command: l.Command, Protocol: l.Protocol}
+}
@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupListeners_DualStack(t *testing.T) {
+	ports := []PortInfo{
+		{Port: 3000, ProcessName: "node", PID: 42, Command: "node", Protocol: "TCP6", BindAddress: "::", Family: FamilyIPv6},
+		{Port: 3000, ProcessName: "node", PID: 42, Command: "node server.js", Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+	}
+
+	got := GroupListeners(ports)
+	if len(got) != 1 {
+		t.Fatalf("GroupListeners() returned %d listeners, want 1: %+v", len(got), got)
+	}
+
+	l := got[0]
+	if l.Port != 3000 || l.PID != 42 || l.Protocol != "TCP" {
+		t.Errorf("listener = %+v", l)
+	}
+	if l.Command != "node server.js" {
+		t.Errorf("Command = %q, want the longer command line", l.Command)
+	}
+	if want := []string{"0.0.0.0", "::"}; !reflect.DeepEqual(l.BindAddresses(), want) {
+		t.Errorf("BindAddresses() = %v, want %v", l.BindAddresses(), want)
+	}
+	if want := []string{FamilyIPv4, FamilyIPv6}; !reflect.DeepEqual(l.Families(), want) {
+		t.Errorf("Families() = %v, want %v", l.Families(), want)
+	}
+	if !l.Exposed() {
+		t.Error("Exposed() = false for wildcard listener")
+	}
+	if p := l.Primary(); p.BindAddress != "0.0.0.0" {
+		t.Errorf("Primary().BindAddress = %q, want 0.0.0.0", p.BindAddress)
+	}
+}
+
+func TestGroupListeners_DuplicateFDs(t *testing.T) {
+	ports := []PortInfo{
+		{Port: 5432, ProcessName: "postgres", PID: 7, Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+		{Port: 5432, ProcessName: "postgres", PID: 7, Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+		{Port: 5432, ProcessName: "postgres", PID: 7, Protocol: "TCP6", BindAddress: "::1", Family: FamilyIPv6},
+	}
+
+	got := GroupListeners(ports)
+	if len(got) != 1 {
+		t.Fatalf("GroupListeners() returned %d listeners, want 1", len(got))
+	}
+	if want := []string{"127.0.0.1", "::1"}; !reflect.DeepEqual(got[0].BindAddresses(), want) {
+		t.Errorf("BindAddresses() = %v, want %v", got[0].BindAddresses(), want)
+	}
+	if got[0].Exposed() {
+		t.Error("Exposed() = true for loopback-only listener")
+	}
+	if p := got[0].Primary(); p.BindAddress != "127.0.0.1" {
+		t.Errorf("Primary().BindAddress = %q, want 127.0.0.1", p.BindAddress)
+	}
+}
+
+func TestGroupListeners_ReusePortMultiPID(t *testing.T) {
+	ports := []PortInfo{
+		{Port: 8080, ProcessName: "nginx", PID: 1201, Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 8080, ProcessName: "nginx", PID: 1200, Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 8080, ProcessName: "nginx", PID: 1202, Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+	}
+
+	got := GroupListeners(ports)
+	if len(got) != 3 {
+		t.Fatalf("GroupListeners() returned %d listeners, want 3", len(got))
+	}
+	for i, pid := range []int{1200, 1201, 1202} {
+		if got[i].PID != pid || got[i].Port != 8080 || len(got[i].Sockets) != 1 {
+			t.Errorf("listeners[%d] = %+v, want PID %d on 8080", i, got[i], pid)
+		}
+	}
+}
+
+func TestGroupListeners_MultiplePorts(t *testing.T) {
+	ports := []PortInfo{
+		{Port: 7000, ProcessName: "ControlCenter", PID: 652, Protocol: "TCP6", BindAddress: "::", Family: FamilyIPv6},
+		{Port: 5000, ProcessName: "ControlCenter", PID: 652, Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 7000, ProcessName: "ControlCenter", PID: 652, Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 5000, ProcessName: "ControlCenter", PID: 652, Protocol: "TCP6", BindAddress: "::", Family: FamilyIPv6},
+	}
+
+	got := GroupListeners(ports)
+	if len(got) != 2 {
+		t.Fatalf("GroupListeners() returned %d listeners, want 2", len(got))
+	}
+	if got[0].Port != 5000 || got[1].Port != 7000 {
+		t.Errorf("ports = %d, %d; want 5000, 7000", got[0].Port, got[1].Port)
+	}
+	for _, l := range got {
+		if want := []string{"0.0.0.0", "::"}; !reflect.DeepEqual(l.BindAddresses(), want) {
+			t.Errorf("port %d BindAddresses() = %v, want %v", l.Port, l.BindAddresses(), want)
+		}
+	}
+}
+
+func TestGroupListeners_Empty(t *testing.T) {
+	if got := GroupListeners(nil); len(got) != 0 {
+		t.Errorf("GroupListeners(nil) = %+v, want empty", got)
+	}
+}
+
+func TestListener_PrimaryWithoutSockets(t *testing.T) {
+	l := Listener{Port: 9000, PID: 3, ProcessName: "go", Protocol: "TCP"}
+	p := l.Primary()
+	if p.Port != 9000 || p.PID != 3 || p.BindAddress != "" {
+		t.Errorf("Primary() = %+v", p)
+	}
+}
+
+func TestGroupListeners_SeparatesTransports(t *testing.T) {
+	ports := []PortInfo{
+		{Port: 53, ProcessName: "dnsmasq", PID: 80, Protocol: "UDP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+		{Port: 53, ProcessName: "dnsmasq", PID: 80, Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+		{Port: 53, ProcessName: "dnsmasq", PID: 80, Protocol: "UDP6", BindAddress: "::1", Family: FamilyIPv6},
+	}
+
+	got := GroupListeners(ports)
+	if len(got) != 2 {
+		t.Fatalf("GroupListeners() returned %d listeners, want 2: %+v", len(got), got)
+	}
+	var udp int
+	for _, l := range got {
+		if l.IsUDP() {
+			udp++
+			if want := []string{"127.0.0.1", "::1"}; !reflect.DeepEqual(l.BindAddresses(), want) {
+				t.Errorf("UDP BindAddresses() = %v, want %v", l.BindAddresses(), want)
+			}
+		}
+	}
+	if udp != 1 {
+		t.Errorf("got %d UDP listeners, want 1", udp)
+	}
+}
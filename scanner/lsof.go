@@ -0,0 +1,231 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"port-digger/logger"
+	"strconv"
+	"strings"
+)
+
+// lsofBackend scans with lsof, available on macOS and most Linux systems
+type lsofBackend struct {
+	run commandRunner
+}
+
+func newLsofBackend() *lsofBackend {
+	return &lsofBackend{run: runCommand}
+}
+
+func (b *lsofBackend) Name() string { return "lsof" }
+
+func (b *lsofBackend) Available() bool {
+	_, err := exec.LookPath("lsof")
+	return err == nil
+}
+
+// unescapeLsofString decodes hex escape sequences like \x20 to actual characters
+// lsof escapes special characters in command names using \xHH notation
+func unescapeLsofString(s string) string {
+	if !strings.Contains(s, "\\x") {
+		return s
+	}
+
+	var result strings.Builder
+	i := 0
+	for i < len(s) {
+		if i+3 < len(s) && s[i] == '\\' && s[i+1] == 'x' {
+			// Parse the two hex digits
+			hexStr := s[i+2 : i+4]
+			if val, err := strconv.ParseInt(hexStr, 16, 32); err == nil {
+				result.WriteByte(byte(val))
+				i += 4
+				continue
+			}
+		}
+		result.WriteByte(s[i])
+		i++
+	}
+	return result.String()
+}
+
+// lsofRecord is one socket described by lsof -F field output
+type lsofRecord struct {
+	PID      int
+	Command  string
+	UID      int
+	User     string
+	FD       string // e.g. "21" (the access mode is a separate field in -F output)
+	Type     string // "IPv4" or "IPv6"
+	Protocol string // "TCP" or "UDP"
+	Name     string // bind address, e.g. "127.0.0.1:8080", "*:3000" or "[::1]:3000"
+}
+
+// parseLsofFields parses lsof -F output into one record per file
+// Each line starts with a field identifier character: 'p' begins a process
+// set (followed by c, u, L), 'f' begins a file set (followed by t, P, n).
+// Unknown identifiers are ignored so extra -F fields do not break parsing
+func parseLsofFields(r io.Reader) ([]lsofRecord, error) {
+	var records []lsofRecord
+	var proc, file lsofRecord
+	inFile := false
+
+	flush := func() {
+		if inFile && proc.PID > 0 && file.Name != "" {
+			rec := file
+			rec.PID, rec.Command, rec.UID, rec.User = proc.PID, proc.Command, proc.UID, proc.User
+			records = append(records, rec)
+		}
+		file = lsofRecord{}
+		inFile = false
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		id, value := line[0], line[1:]
+
+		switch id {
+		case 'p':
+			flush()
+			proc = lsofRecord{}
+			// A malformed PID leaves proc.PID at 0, which drops the set's files
+			if pid, err := strconv.Atoi(value); err == nil && pid > 0 {
+				proc.PID = pid
+			}
+		case 'c':
+			proc.Command = unescapeLsofString(value)
+		case 'u':
+			if uid, err := strconv.Atoi(value); err == nil {
+				proc.UID = uid
+			}
+		case 'L':
+			proc.User = value
+		case 'f':
+			flush()
+			file.FD = value
+			inFile = true
+		case 't':
+			file.Type = value
+		case 'P':
+			file.Protocol = value
+		case 'n':
+			file.Name = value
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading lsof output: %w", err)
+	}
+	return records, nil
+}
+
+// portInfo converts a record into a PortInfo
+// Connected sockets ("a->b") and names without a numeric port are rejected
+func (rec lsofRecord) portInfo() (*PortInfo, error) {
+	if strings.Contains(rec.Name, "->") {
+		return nil, fmt.Errorf("connected socket %q", rec.Name)
+	}
+	if rec.Protocol != "TCP" && rec.Protocol != "UDP" {
+		return nil, fmt.Errorf("unsupported protocol %q", rec.Protocol)
+	}
+
+	host, port, err := splitHostPort(rec.Name)
+	if err != nil {
+		return nil, err
+	}
+	if port <= 0 || port > 65535 {
+		return nil, fmt.Errorf("port %d out of range", port)
+	}
+
+	protocol, family := rec.Protocol, FamilyIPv4
+	if rec.Type == "IPv6" {
+		protocol, family = protocol+"6", FamilyIPv6
+	}
+
+	return &PortInfo{
+		Port:        port,
+		ProcessName: rec.Command,
+		PID:         rec.PID,
+		Command:     rec.Command,
+		Protocol:    protocol,
+		BindAddress: normalizeBindAddress(host, family),
+		Family:      family,
+	}, nil
+}
+
+// parseLsofOutput parses lsof -F output into ports, skipping unusable records
+func parseLsofOutput(output []byte) ([]PortInfo, error) {
+	records, err := parseLsofFields(bytes.NewReader(output))
+	if err != nil {
+		return nil, err
+	}
+
+	ports := []PortInfo{}
+	for _, rec := range records {
+		info, err := rec.portInfo()
+		if err != nil {
+			continue
+		}
+		ports = append(ports, *info)
+	}
+	return ports, nil
+}
+
+// lsofSelections are the socket selections for listening TCP and bound UDP
+// sockets. They need separate runs since a TCP state filter hides UDP files
+var lsofSelections = [][]string{
+	{"-iTCP", "-sTCP:LISTEN"},
+	{"-iUDP"},
+}
+
+// Scan executes lsof to get all listening TCP ports and bound UDP sockets
+func (b *lsofBackend) Scan() ([]PortInfo, error) {
+	ports := []PortInfo{}
+	for _, selection := range lsofSelections {
+		found, err := b.scanSelection(selection)
+		if err != nil {
+			return nil, err
+		}
+		ports = append(ports, found...)
+	}
+	return ports, nil
+}
+
+// scanSelection executes lsof for one socket selection
+func (b *lsofBackend) scanSelection(selection []string) ([]PortInfo, error) {
+	// Execute: lsof +c 0 <selection> -nP -F pcuLftPn
+	// +c 0 shows full command name without truncation
+	// -F selects machine-readable output: PID, command, UID, login, fd, type, protocol, name
+	cmdArgs := append(append([]string{"+c", "0"}, selection...), "-nP", "-F", "pcuLftPn")
+	command := append([]string{"lsof"}, cmdArgs...)
+
+	logger.Debug("Executing lsof command: lsof %s", strings.Join(cmdArgs, " "))
+
+	output, err := b.run("lsof", cmdArgs...)
+	if err != nil {
+		// lsof returns exit code 1 if no ports found - not an error
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			logger.LogScanQuery(b.Name(), command, 0, nil)
+			return []PortInfo{}, nil
+		}
+		logger.LogScanQuery(b.Name(), command, 0, err)
+		return nil, fmt.Errorf("lsof command failed: %w", err)
+	}
+
+	ports, err := parseLsofOutput(output)
+	if err != nil {
+		logger.LogScanQuery(b.Name(), command, 0, err)
+		return nil, err
+	}
+
+	logger.LogScanQuery(b.Name(), command, len(ports), nil)
+	return ports, nil
+}
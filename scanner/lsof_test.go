@@ -0,0 +1,266 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnescapeLsofString(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "no escape sequences",
+			input: "node",
+			want:  "node",
+		},
+		{
+			name:  "space escaped as \\x20",
+			input: "Antigravity\\x20Helper\\x20(Plugin)",
+			want:  "Antigravity Helper (Plugin)",
+		},
+		{
+			name:  "multiple escape sequences",
+			input: "My\\x20App\\x20Name",
+			want:  "My App Name",
+		},
+		{
+			name:  "parentheses and spaces",
+			input: "App\\x20(Helper)",
+			want:  "App (Helper)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := unescapeLsofString(tt.input)
+			if got != tt.want {
+				t.Errorf("unescapeLsofString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLsofFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []lsofRecord
+	}{
+		{
+			name:   "single socket",
+			output: "p12345\ncnode\nu501\nLdev\nf23\ntIPv4\nPTCP\nn*:3000\n",
+			want: []lsofRecord{
+				{PID: 12345, Command: "node", UID: 501, User: "dev", FD: "23", Type: "IPv4", Protocol: "TCP", Name: "*:3000"},
+			},
+		},
+		{
+			name:   "command name with spaces",
+			output: "p4940\ncAntigravity Helper (Plugin)\nu501\nLmcpp\nf26\ntIPv4\nPTCP\nn127.0.0.1:59531\n",
+			want: []lsofRecord{
+				{PID: 4940, Command: "Antigravity Helper (Plugin)", UID: 501, User: "mcpp", FD: "26", Type: "IPv4", Protocol: "TCP", Name: "127.0.0.1:59531"},
+			},
+		},
+		{
+			name:   "escaped command name",
+			output: "p4940\ncMy\\x20App\nf26\ntIPv4\nPTCP\nn127.0.0.1:59531\n",
+			want: []lsofRecord{
+				{PID: 4940, Command: "My App", FD: "26", Type: "IPv4", Protocol: "TCP", Name: "127.0.0.1:59531"},
+			},
+		},
+		{
+			name:   "several files and processes",
+			output: "p1\ncsshd\nf3\ntIPv4\nPTCP\nn*:22\nf4\ntIPv6\nPTCP\nn*:22\np2\ncpostgres\nf6\ntIPv6\nPTCP\nn[::1]:5432\n",
+			want: []lsofRecord{
+				{PID: 1, Command: "sshd", FD: "3", Type: "IPv4", Protocol: "TCP", Name: "*:22"},
+				{PID: 1, Command: "sshd", FD: "4", Type: "IPv6", Protocol: "TCP", Name: "*:22"},
+				{PID: 2, Command: "postgres", FD: "6", Type: "IPv6", Protocol: "TCP", Name: "[::1]:5432"},
+			},
+		},
+		{
+			name:   "unknown fields are ignored",
+			output: "p7\ncredis-server\ng7\nR1\nf6\nar\nl \ntIPv4\nPTCP\nn127.0.0.1:6379\nTST=LISTEN\nTQR=0\n",
+			want: []lsofRecord{
+				{PID: 7, Command: "redis-server", FD: "6", Type: "IPv4", Protocol: "TCP", Name: "127.0.0.1:6379"},
+			},
+		},
+		{
+			name:   "malformed PID drops its files",
+			output: "pabc\ncbad\nf1\ntIPv4\nPTCP\nn*:1\np9\ncgood\nf2\ntIPv4\nPTCP\nn*:2\n",
+			want: []lsofRecord{
+				{PID: 9, Command: "good", FD: "2", Type: "IPv4", Protocol: "TCP", Name: "*:2"},
+			},
+		},
+		{
+			name:   "file without name is skipped",
+			output: "p9\ncgood\nf2\ntIPv4\nPTCP\n",
+			want:   nil,
+		},
+		{
+			name:   "empty output",
+			output: "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLsofFields(strings.NewReader(tt.output))
+			if err != nil {
+				t.Fatalf("parseLsofFields() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseLsofFields() returned %d records, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("records[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLsofRecord_PortInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		rec     lsofRecord
+		want    *PortInfo
+		wantErr bool
+	}{
+		{
+			name: "IPv4 wildcard",
+			rec:  lsofRecord{PID: 1, Command: "node", Type: "IPv4", Protocol: "TCP", Name: "*:3000"},
+			want: &PortInfo{Port: 3000, ProcessName: "node", PID: 1, Command: "node", Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		},
+		{
+			name: "IPv6 loopback",
+			rec:  lsofRecord{PID: 2, Command: "node", Type: "IPv6", Protocol: "TCP", Name: "[::1]:3000"},
+			want: &PortInfo{Port: 3000, ProcessName: "node", PID: 2, Command: "node", Protocol: "TCP6", BindAddress: "::1", Family: FamilyIPv6},
+		},
+		{
+			name: "UDP IPv6 wildcard",
+			rec:  lsofRecord{PID: 5, Command: "mDNSResponder", Type: "IPv6", Protocol: "UDP", Name: "*:5353"},
+			want: &PortInfo{Port: 5353, ProcessName: "mDNSResponder", PID: 5, Command: "mDNSResponder", Protocol: "UDP6", BindAddress: "::", Family: FamilyIPv6},
+		},
+		{
+			name:    "connected UDP socket",
+			rec:     lsofRecord{PID: 6, Command: "node", Type: "IPv4", Protocol: "UDP", Name: "127.0.0.1:50123->127.0.0.1:53"},
+			wantErr: true,
+		},
+		{
+			name:    "unsupported protocol",
+			rec:     lsofRecord{PID: 7, Command: "x", Type: "IPv4", Protocol: "SCTP", Name: "*:9"},
+			wantErr: true,
+		},
+		{
+			name:    "connected socket",
+			rec:     lsofRecord{PID: 3, Command: "curl", Type: "IPv4", Protocol: "TCP", Name: "127.0.0.1:50000->127.0.0.1:3000"},
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric port",
+			rec:     lsofRecord{PID: 4, Command: "x", Type: "IPv4", Protocol: "TCP", Name: "*:http"},
+			wantErr: true,
+		},
+		{
+			name:    "port out of range",
+			rec:     lsofRecord{PID: 4, Command: "x", Type: "IPv4", Protocol: "TCP", Name: "*:70000"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.rec.portInfo()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("portInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got != *tt.want {
+				t.Errorf("portInfo() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestParseLsofOutput_Corpus(t *testing.T) {
+	tests := []struct {
+		file      string
+		wantCount int
+		wantFirst PortInfo
+	}{
+		{
+			file:      "darwin.txt",
+			wantCount: 10,
+			wantFirst: PortInfo{Port: 49152, ProcessName: "rapportd", PID: 431, Command: "rapportd", Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		},
+		{
+			file:      "linux.txt",
+			wantCount: 9,
+			wantFirst: PortInfo{Port: 48271, ProcessName: ".anthropic_stdi", PID: 129, Command: ".anthropic_stdi", Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "lsof", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ports, err := parseLsofOutput(data)
+			if err != nil {
+				t.Fatalf("parseLsofOutput() error = %v", err)
+			}
+			if len(ports) != tt.wantCount {
+				t.Errorf("parseLsofOutput() returned %d ports, want %d", len(ports), tt.wantCount)
+			}
+			if len(ports) > 0 && ports[0] != tt.wantFirst {
+				t.Errorf("ports[0] = %+v, want %+v", ports[0], tt.wantFirst)
+			}
+			for i, p := range ports {
+				if p.PID <= 0 || p.Port <= 0 || p.ProcessName == "" {
+					t.Errorf("ports[%d] incomplete: %+v", i, p)
+				}
+			}
+		})
+	}
+}
+
+func FuzzParseLsofFields(f *testing.F) {
+	for _, file := range []string{"darwin.txt", "linux.txt"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "lsof", file))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+	f.Add([]byte("p1\ncx\nf1\ntIPv6\nPTCP\nn[::1]:1->[::1]:2\n"))
+	f.Add([]byte("p\nc\nf\nt\nP\nn:\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ports, err := parseLsofOutput(data)
+		if err != nil {
+			return // only scanner I/O errors, e.g. overlong lines
+		}
+		for _, p := range ports {
+			if p.PID <= 0 {
+				t.Errorf("non-positive PID in %+v", p)
+			}
+			if p.Port <= 0 || p.Port > 65535 {
+				t.Errorf("port out of range in %+v", p)
+			}
+			switch p.Protocol {
+			case "TCP", "TCP6", "UDP", "UDP6":
+			default:
+				t.Errorf("unexpected protocol in %+v", p)
+			}
+		}
+	})
+}
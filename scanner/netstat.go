@@ -0,0 +1,232 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"port-digger/logger"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// netstatBackend scans with macOS netstat, which reports owning PIDs with -v
+type netstatBackend struct {
+	run commandRunner
+}
+
+func newNetstatBackend() *netstatBackend {
+	return &netstatBackend{run: runCommand}
+}
+
+func (b *netstatBackend) Name() string { return "netstat" }
+
+// Available is limited to macOS since only BSD netstat prints PIDs with -anv
+func (b *netstatBackend) Available() bool {
+	if runtime.GOOS != "darwin" {
+		return false
+	}
+	_, err := exec.LookPath("netstat")
+	return err == nil
+}
+
+// netstatPIDColumn finds the data column holding the PID from the header line
+// Older macOS prints a "pid" column, newer releases a "process:pid" column.
+// "Local Address" and "Foreign Address" are two header words but one data field
+func netstatPIDColumn(header string) (int, error) {
+	for i, name := range strings.Fields(header) {
+		if name == "pid" || name == "process:pid" {
+			return i - 2, nil
+		}
+	}
+	return 0, fmt.Errorf("no pid column in netstat header")
+}
+
+// splitNetstatAddr splits "127.0.0.1.8080" or "*.3000" at the last dot
+func splitNetstatAddr(addr string) (string, int, error) {
+	dotIdx := strings.LastIndex(addr, ".")
+	if dotIdx == -1 {
+		return "", 0, fmt.Errorf("no port in address %q", addr)
+	}
+
+	port, err := strconv.Atoi(addr[dotIdx+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port number: %w", err)
+	}
+	return addr[:dotIdx], port, nil
+}
+
+// parseNetstatLine parses a single socket line of `netstat -anv` output
+// Example: "tcp4  0  0  127.0.0.1.8080  *.*  LISTEN  131072 131072  1234  0 0x0100 0x00000006"
+// UDP lines have an empty (state) column, so their PID sits one field earlier.
+// ProcessName is left as reported (empty for the older pid-only format)
+func parseNetstatLine(line string, pidColumn int) (*PortInfo, error) {
+	fields := strings.Fields(line)
+
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("invalid line format: not enough fields")
+	}
+
+	protocol, family := "", ""
+	switch fields[0] {
+	case "tcp4":
+		protocol, family = "TCP", FamilyIPv4
+	case "tcp6", "tcp46":
+		protocol, family = "TCP6", FamilyIPv6
+	case "udp4":
+		protocol, family = "UDP", FamilyIPv4
+	case "udp6", "udp46":
+		protocol, family = "UDP6", FamilyIPv6
+	default:
+		return nil, fmt.Errorf("not a TCP or UDP socket")
+	}
+
+	if strings.HasPrefix(protocol, "UDP") {
+		// Bound but unconnected UDP sockets have no foreign address
+		if fields[4] != "*.*" {
+			return nil, fmt.Errorf("connected UDP socket")
+		}
+		pidColumn--
+	} else if fields[5] != "LISTEN" {
+		return nil, fmt.Errorf("not listening")
+	}
+
+	if len(fields) <= pidColumn {
+		return nil, fmt.Errorf("invalid line format: not enough fields")
+	}
+
+	host, port, err := splitNetstatAddr(fields[3])
+	if err != nil {
+		return nil, err
+	}
+
+	// "1234" or "process:1234"
+	name := ""
+	pidStr := fields[pidColumn]
+	if colonIdx := strings.LastIndex(pidStr, ":"); colonIdx != -1 {
+		name, pidStr = pidStr[:colonIdx], pidStr[colonIdx+1:]
+	}
+	pid, err := strconv.Atoi(pidStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PID: %w", err)
+	}
+
+	return &PortInfo{
+		Port:        port,
+		ProcessName: name,
+		PID:         pid,
+		Command:     name,
+		Protocol:    protocol,
+		BindAddress: normalizeBindAddress(host, family),
+		Family:      family,
+	}, nil
+}
+
+// parseNetstatOutput parses the full output of netstat -anv
+func parseNetstatOutput(output []byte) ([]PortInfo, error) {
+	ports := []PortInfo{}
+	pidColumn := -1
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if strings.HasPrefix(line, "Proto") {
+			col, err := netstatPIDColumn(line)
+			if err != nil {
+				return nil, err
+			}
+			pidColumn = col
+			continue
+		}
+		if pidColumn < 0 {
+			continue // banner lines before the header
+		}
+
+		info, err := parseNetstatLine(line, pidColumn)
+		if err != nil {
+			continue
+		}
+		ports = append(ports, *info)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading netstat output: %w", err)
+	}
+	if pidColumn < 0 {
+		return nil, fmt.Errorf("no header in netstat output")
+	}
+	return ports, nil
+}
+
+// lookupProcessNames maps PIDs to executable names with a single ps call
+// Uses: ps -o pid=,comm= -p <pid>,<pid>,...
+func lookupProcessNames(run commandRunner, pids []int) map[int]string {
+	names := make(map[int]string)
+	if len(pids) == 0 {
+		return names
+	}
+
+	output, err := run("ps", "-o", "pid=,comm=", "-p", joinPIDs(pids))
+	if err != nil && len(output) == 0 {
+		return names
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		// macOS reports the full executable path
+		comm := strings.Join(fields[1:], " ")
+		names[pid] = filepath.Base(comm)
+	}
+	return names
+}
+
+// Scan executes netstat to get all listening TCP ports and bound UDP sockets
+func (b *netstatBackend) Scan() ([]PortInfo, error) {
+	// Execute: netstat -anv
+	// -v adds the owning PID column
+	cmdArgs := []string{"-anv"}
+	command := append([]string{"netstat"}, cmdArgs...)
+
+	output, err := b.run("netstat", cmdArgs...)
+	if err != nil {
+		logger.LogScanQuery(b.Name(), command, 0, err)
+		return nil, fmt.Errorf("netstat command failed: %w", err)
+	}
+
+	ports, err := parseNetstatOutput(output)
+	if err != nil {
+		logger.LogScanQuery(b.Name(), command, 0, err)
+		return nil, err
+	}
+
+	// netstat truncates or omits process names; resolve them like lsof +c 0 would
+	var pids []int
+	seen := make(map[int]bool)
+	for _, p := range ports {
+		if !seen[p.PID] {
+			seen[p.PID] = true
+			pids = append(pids, p.PID)
+		}
+	}
+	names := lookupProcessNames(b.run, pids)
+	for i := range ports {
+		if name, ok := names[ports[i].PID]; ok {
+			ports[i].ProcessName = name
+			ports[i].Command = name
+		}
+	}
+
+	logger.LogScanQuery(b.Name(), command, len(ports), nil)
+	return ports, nil
+}
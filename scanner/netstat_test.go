@@ -0,0 +1,136 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestNetstatPIDColumn(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    int
+		wantErr bool
+	}{
+		{
+			name:   "pid column",
+			header: "Proto Recv-Q Send-Q  Local Address          Foreign Address        (state)     rhiwat shiwat    pid   epid  state    options",
+			want:   8,
+		},
+		{
+			name:   "process:pid column",
+			header: "Proto Recv-Q Send-Q  Local Address          Foreign Address        (state)      rxbytes      txbytes  rhiwat  shiwat  process:pid  state  options",
+			want:   10,
+		},
+		{
+			name:    "no pid column",
+			header:  "Proto Recv-Q Send-Q  Local Address          Foreign Address        (state)",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := netstatPIDColumn(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("netstatPIDColumn() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("netstatPIDColumn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNetstatLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		pidColumn int
+		want      *PortInfo
+		wantErr   bool
+	}{
+		{
+			name:      "IPv4 listener",
+			line:      "tcp4       0      0  127.0.0.1.8080         *.*                    LISTEN      131072 131072   1234      0 0x0100 0x00000006",
+			pidColumn: 8,
+			want:      &PortInfo{Port: 8080, PID: 1234, Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+		},
+		{
+			name:      "dual-stack listener",
+			line:      "tcp46      0      0  *.5000                 *.*                    LISTEN      131072 131072    555      0 0x0100 0x00000006",
+			pidColumn: 8,
+			want:      &PortInfo{Port: 5000, PID: 555, Protocol: "TCP6", BindAddress: "::", Family: FamilyIPv6},
+		},
+		{
+			name:      "IPv6 loopback",
+			line:      "tcp6       0      0  ::1.3000               *.*                    LISTEN      131072 131072   4242      0 0x0100 0x00000006",
+			pidColumn: 8,
+			want:      &PortInfo{Port: 3000, PID: 4242, Protocol: "TCP6", BindAddress: "::1", Family: FamilyIPv6},
+		},
+		{
+			name:      "process:pid format",
+			line:      "tcp4       0      0  127.0.0.1.7000         *.*                    LISTEN             0            0  131072  131072  ControlCe:612  00002 00000006",
+			pidColumn: 10,
+			want:      &PortInfo{Port: 7000, ProcessName: "ControlCe", PID: 612, Command: "ControlCe", Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+		},
+		{
+			name:      "established connection",
+			line:      "tcp4       0      0  127.0.0.1.5432         127.0.0.1.61000        ESTABLISHED 408300 146988    777      0 0x0102 0x00000008",
+			pidColumn: 8,
+			wantErr:   true,
+		},
+		{
+			name:      "UDP socket",
+			line:      "udp4       0      0  *.5353                 *.*                                 786896   9216    312      0 0x0000 0x00000000",
+			pidColumn: 8,
+			want:      &PortInfo{Port: 5353, PID: 312, Protocol: "UDP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		},
+		{
+			name:      "dual-stack UDP socket",
+			line:      "udp46      0      0  *.5353                 *.*                                 786896   9216    312      0 0x0000 0x00000000",
+			pidColumn: 8,
+			want:      &PortInfo{Port: 5353, PID: 312, Protocol: "UDP6", BindAddress: "::", Family: FamilyIPv6},
+		},
+		{
+			name:      "connected UDP socket",
+			line:      "udp4       0      0  127.0.0.1.50123        127.0.0.1.53                       786896   9216   4242      0 0x0000 0x00000000",
+			pidColumn: 8,
+			wantErr:   true,
+		},
+		{
+			name:      "banner line",
+			line:      "Active Internet connections (including servers)",
+			pidColumn: 8,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseNetstatLine(tt.line, tt.pidColumn)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseNetstatLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if *got != *tt.want {
+				t.Errorf("parseNetstatLine() = %+v, want %+v", *got, *tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupProcessNames(t *testing.T) {
+	names := lookupProcessNames(cannedRunner(t), []int{4242, 777})
+	if names[4242] != "node" {
+		t.Errorf("names[4242] = %q, want node", names[4242])
+	}
+	if names[777] != "postgres" {
+		t.Errorf("names[777] = %q, want postgres", names[777])
+	}
+
+	if got := lookupProcessNames(cannedRunner(t), nil); len(got) != 0 {
+		t.Errorf("lookupProcessNames(nil) = %v, want empty", got)
+	}
+}
@@ -0,0 +1,458 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcessInfo describes a process owning one or more listening sockets
+type ProcessInfo struct {
+	PID        int
+	PPID       int
+	ParentName string // Short name of the parent process, empty if unknown
+	User       string
+	StartTime  time.Time // Zero if unknown
+	CPU        float64   // Percent of one core averaged over the process lifetime, like ps %cpu
+	RSS        int64     // Resident set size in bytes
+	Command    string    // Full command line
+	Cwd        string    // Working directory, empty if not readable
+}
+
+// Uptime returns how long the process has been running at now, or 0 if unknown
+func (p ProcessInfo) Uptime(now time.Time) time.Duration {
+	if p.StartTime.IsZero() || now.Before(p.StartTime) {
+		return 0
+	}
+	return now.Sub(p.StartTime)
+}
+
+// processCacheTTL is how long looked-up process info is reused; long enough to
+// cover one menu build, short enough that CPU and RSS stay current
+const processCacheTTL = 5 * time.Second
+
+// clockTicks is USER_HZ, the unit of the time fields in /proc/<pid>/stat.
+// It is 100 on every mainstream Linux architecture
+const clockTicks = 100
+
+type cachedProcess struct {
+	info    ProcessInfo
+	fetched time.Time
+}
+
+// startTimeFunc returns the start time of a running process cheaply, without
+// a full lookup; ok is false when the process is gone
+type startTimeFunc func(pid int) (start time.Time, ok bool)
+
+// processCache holds recent lookups so repeated menu builds do not respawn ps
+// Entries belong to one process instance, identified by PID and start time:
+// where startTime is set, a hit also needs the start time to still match, so a
+// recycled PID never gets the previous process's details. Without it (ps
+// systems) the TTL bounds how long that can happen
+type processCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	now       func() time.Time
+	startTime startTimeFunc // nil when start times cannot be read cheaply
+	entries   map[int]cachedProcess
+}
+
+func newProcessCache(ttl time.Duration, startTime startTimeFunc) *processCache {
+	return &processCache{
+		ttl:       ttl,
+		now:       time.Now,
+		startTime: startTime,
+		entries:   make(map[int]cachedProcess),
+	}
+}
+
+// get returns fresh cached info for pid if it still describes the same process
+func (c *processCache) get(pid int) (ProcessInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[pid]
+	if !ok {
+		return ProcessInfo{}, false
+	}
+	if c.now().Sub(entry.fetched) > c.ttl {
+		delete(c.entries, pid)
+		return ProcessInfo{}, false
+	}
+	if c.startTime != nil {
+		if start, ok := c.startTime(pid); !ok || !start.Equal(entry.info.StartTime) {
+			// Exited, or the PID now belongs to another process
+			delete(c.entries, pid)
+			return ProcessInfo{}, false
+		}
+	}
+	return entry.info, true
+}
+
+// put stores info, replacing any entry of an earlier process with the same
+// PID, and drops expired entries so PIDs seen once do not stay forever
+func (c *processCache) put(info ProcessInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for pid, entry := range c.entries {
+		if now.Sub(entry.fetched) > c.ttl {
+			delete(c.entries, pid)
+		}
+	}
+	c.entries[info.PID] = cachedProcess{info: info, fetched: now}
+}
+
+// processLookup fetches info for many PIDs at once
+type processLookup func(pids []int) (map[int]ProcessInfo, error)
+
+var defaultProcessCache = newProcessCache(processCacheTTL, defaultStartTime())
+
+// defaultStartTime validates cached entries through procfs where it exists
+func defaultStartTime() startTimeFunc {
+	if !procfsAvailable(defaultProcRoot) {
+		return nil
+	}
+	return func(pid int) (time.Time, bool) {
+		return procfsStartTime(defaultProcRoot, pid)
+	}
+}
+
+// LookupProcesses returns process info for all pids with one batched ps and
+// lsof run, or by reading procfs on Linux. PIDs that have exited are missing
+// from the result
+func LookupProcesses(pids []int) (map[int]ProcessInfo, error) {
+	lookup := func(pids []int) (map[int]ProcessInfo, error) {
+		return lookupProcessesPS(runCommand, pids)
+	}
+	if procfsAvailable(defaultProcRoot) {
+		lookup = func(pids []int) (map[int]ProcessInfo, error) {
+			return lookupProcessesProcfs(defaultProcRoot, pids, time.Now())
+		}
+	}
+	return lookupCached(defaultProcessCache, lookup, pids)
+}
+
+// lookupCached serves pids from cache and fetches the rest in one lookup
+func lookupCached(cache *processCache, lookup processLookup, pids []int) (map[int]ProcessInfo, error) {
+	infos := make(map[int]ProcessInfo, len(pids))
+	seen := make(map[int]bool, len(pids))
+	var missing []int
+	for _, pid := range pids {
+		if seen[pid] {
+			continue
+		}
+		seen[pid] = true
+		if info, ok := cache.get(pid); ok {
+			infos[pid] = info
+		} else {
+			missing = append(missing, pid)
+		}
+	}
+	if len(missing) == 0 {
+		return infos, nil
+	}
+
+	fetched, err := lookup(missing)
+	for pid, info := range fetched {
+		cache.put(info)
+		infos[pid] = info
+	}
+	return infos, err
+}
+
+// joinPIDs formats pids as a comma-separated list for ps -p
+func joinPIDs(pids []int) string {
+	pidStrs := make([]string, len(pids))
+	for i, pid := range pids {
+		pidStrs[i] = strconv.Itoa(pid)
+	}
+	return strings.Join(pidStrs, ",")
+}
+
+// psLstartLayout is the layout of the ps lstart column, e.g. "Fri Oct 16 09:05:31 2026"
+const psLstartLayout = "Mon Jan _2 15:04:05 2006"
+
+// lookupProcessesPS runs a single ps for all pids, then one ps for the parent
+// names and one lsof for the working directories
+func lookupProcessesPS(run commandRunner, pids []int) (map[int]ProcessInfo, error) {
+	if len(pids) == 0 {
+		return map[int]ProcessInfo{}, nil
+	}
+
+	// ps exits non-zero when some of the PIDs are gone; keep whatever it printed
+	output, err := run("ps", "-o", "pid=,ppid=,user=,lstart=,%cpu=,rss=,command=", "-p", joinPIDs(pids))
+	if err != nil && len(output) == 0 {
+		return nil, fmt.Errorf("ps failed: %w", err)
+	}
+	infos := parsePSOutput(output)
+
+	var ppids []int
+	seen := make(map[int]bool)
+	for _, info := range infos {
+		if info.PPID > 0 && !seen[info.PPID] {
+			seen[info.PPID] = true
+			ppids = append(ppids, info.PPID)
+		}
+	}
+	parents := lookupProcessNames(run, ppids)
+	cwds := lookupCwdsLsof(run, pids)
+	for pid, info := range infos {
+		info.ParentName = parents[info.PPID]
+		info.Cwd = cwds[pid]
+		infos[pid] = info
+	}
+	return infos, nil
+}
+
+// lookupCwdsLsof returns the working directories of pids from one lsof run
+// Missing lsof or unreadable processes just leave entries out
+func lookupCwdsLsof(run commandRunner, pids []int) map[int]string {
+	cwds := make(map[int]string)
+	if len(pids) == 0 {
+		return cwds
+	}
+
+	// -a ANDs the selections: only the cwd descriptor of the given PIDs
+	output, err := run("lsof", "-a", "-d", "cwd", "-p", joinPIDs(pids), "-nP", "-F", "pfn")
+	if err != nil && len(output) == 0 {
+		return cwds
+	}
+	records, _ := parseLsofFields(bytes.NewReader(output))
+	for _, rec := range records {
+		if rec.FD == "cwd" {
+			cwds[rec.PID] = rec.Name
+		}
+	}
+	return cwds
+}
+
+// parsePSOutput parses `ps -o pid=,ppid=,user=,lstart=,%cpu=,rss=,command=` output
+// Example: "  4242     1 dev      Fri Oct 16 09:05:31 2026   0.3  52344 node server.js"
+func parsePSOutput(output []byte) map[int]ProcessInfo {
+	infos := make(map[int]ProcessInfo)
+
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		info, err := parsePSLine(scanner.Text())
+		if err != nil {
+			continue
+		}
+		infos[info.PID] = info
+	}
+	return infos
+}
+
+// parsePSLine parses one line of parsePSOutput input
+func parsePSLine(line string) (ProcessInfo, error) {
+	// pid, ppid, user, 5 lstart fields, %cpu, rss, then the command with its spacing intact
+	fields, command := splitFields(line, 10)
+	if len(fields) < 10 {
+		return ProcessInfo{}, fmt.Errorf("invalid line format: not enough fields")
+	}
+
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return ProcessInfo{}, fmt.Errorf("invalid PID: %w", err)
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ProcessInfo{}, fmt.Errorf("invalid PPID: %w", err)
+	}
+
+	info := ProcessInfo{PID: pid, PPID: ppid, User: fields[2], Command: command}
+	if start, err := time.ParseInLocation(psLstartLayout, strings.Join(fields[3:8], " "), time.Local); err == nil {
+		info.StartTime = start
+	}
+	if cpu, err := strconv.ParseFloat(fields[8], 64); err == nil {
+		info.CPU = cpu
+	}
+	if rss, err := strconv.ParseInt(fields[9], 10, 64); err == nil {
+		info.RSS = rss * 1024 // ps reports KiB
+	}
+	return info, nil
+}
+
+// splitFields returns the first n whitespace-separated fields of s and the
+// untouched remainder after them
+func splitFields(s string, n int) ([]string, string) {
+	var fields []string
+	rest := strings.TrimLeft(s, " \t")
+	for len(fields) < n && rest != "" {
+		end := strings.IndexAny(rest, " \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = strings.TrimLeft(rest[end:], " \t")
+	}
+	return fields, strings.TrimSpace(rest)
+}
+
+// readBootTime reads the boot time from the btime line of <root>/stat
+func readBootTime(root string) (time.Time, error) {
+	data, err := os.ReadFile(filepath.Join(root, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid btime: %w", err)
+			}
+			return time.Unix(secs, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no btime in %s", filepath.Join(root, "stat"))
+}
+
+// procStat holds the fields of /proc/<pid>/stat we use
+type procStat struct {
+	ppid      int
+	cpuTicks  int64 // utime + stime
+	startTick int64 // Start time in ticks since boot
+	rssPages  int64
+}
+
+// parseProcStat parses /proc/<pid>/stat. The comm field may contain spaces and
+// parentheses, so fields are counted from the last ')'
+func parseProcStat(data string) (procStat, error) {
+	end := strings.LastIndexByte(data, ')')
+	if end < 0 {
+		return procStat{}, fmt.Errorf("invalid stat: no comm field")
+	}
+	// fields[0] is field 3 (state) in proc(5) numbering
+	fields := strings.Fields(data[end+1:])
+	if len(fields) < 22 {
+		return procStat{}, fmt.Errorf("invalid stat: not enough fields")
+	}
+
+	var st procStat
+	var err error
+	if st.ppid, err = strconv.Atoi(fields[1]); err != nil {
+		return procStat{}, fmt.Errorf("invalid ppid: %w", err)
+	}
+	utime, err1 := strconv.ParseInt(fields[11], 10, 64)
+	stime, err2 := strconv.ParseInt(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return procStat{}, fmt.Errorf("invalid cpu times")
+	}
+	st.cpuTicks = utime + stime
+	if st.startTick, err = strconv.ParseInt(fields[19], 10, 64); err != nil {
+		return procStat{}, fmt.Errorf("invalid starttime: %w", err)
+	}
+	if st.rssPages, err = strconv.ParseInt(fields[21], 10, 64); err != nil {
+		return procStat{}, fmt.Errorf("invalid rss: %w", err)
+	}
+	return st, nil
+}
+
+// procStartTime converts the start ticks of a process to a time
+func procStartTime(boot time.Time, st procStat) time.Time {
+	return boot.Add(time.Duration(st.startTick) * time.Second / clockTicks)
+}
+
+// procfsStartTime reads the start time of pid from a procfs tree
+func procfsStartTime(root string, pid int) (time.Time, bool) {
+	boot, err := readBootTime(root)
+	if err != nil {
+		return time.Time{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return time.Time{}, false
+	}
+	st, err := parseProcStat(string(data))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return procStartTime(boot, st), true
+}
+
+// readProcUID returns the real UID from <root>/<pid>/status
+func readProcUID(root string, pid int) (string, error) {
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "status"))
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if value, ok := strings.CutPrefix(line, "Uid:"); ok {
+			if fields := strings.Fields(value); len(fields) > 0 {
+				return fields[0], nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no Uid in status of %d", pid)
+}
+
+// lookupProcessesProcfs reads process info for pids from a procfs tree
+func lookupProcessesProcfs(root string, pids []int, now time.Time) (map[int]ProcessInfo, error) {
+	infos := make(map[int]ProcessInfo)
+	if len(pids) == 0 {
+		return infos, nil
+	}
+
+	boot, err := readBootTime(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read boot time: %w", err)
+	}
+	pageSize := int64(os.Getpagesize())
+	userNames := make(map[string]string)
+
+	for _, pid := range pids {
+		data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "stat"))
+		if err != nil {
+			continue // exited
+		}
+		st, err := parseProcStat(string(data))
+		if err != nil {
+			continue
+		}
+
+		info := ProcessInfo{
+			PID:       pid,
+			PPID:      st.ppid,
+			StartTime: procStartTime(boot, st),
+			RSS:       st.rssPages * pageSize,
+			Command:   readProcCmdline(root, pid),
+		}
+		if info.Command == "" {
+			// Kernel threads and zombies have an empty cmdline
+			info.Command = readProcComm(root, pid)
+		}
+		if st.ppid > 0 {
+			info.ParentName = readProcComm(root, st.ppid)
+		}
+		// Readable only for our own processes unless running as root
+		if cwd, err := os.Readlink(filepath.Join(root, strconv.Itoa(pid), "cwd")); err == nil {
+			info.Cwd = cwd
+		}
+		if elapsed := now.Sub(info.StartTime).Seconds(); elapsed > 0 {
+			info.CPU = float64(st.cpuTicks) / clockTicks / elapsed * 100
+		}
+
+		if uid, err := readProcUID(root, pid); err == nil {
+			name, ok := userNames[uid]
+			if !ok {
+				name = uid
+				if u, err := user.LookupId(uid); err == nil {
+					name = u.Username
+				}
+				userNames[uid] = name
+			}
+			info.User = name
+		}
+
+		infos[pid] = info
+	}
+	return infos, nil
+}
@@ -0,0 +1,331 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePSLine(t *testing.T) {
+	start := time.Date(2026, time.October, 6, 9, 5, 31, 0, time.Local)
+
+	tests := []struct {
+		name    string
+		line    string
+		want    ProcessInfo
+		wantErr bool
+	}{
+		{
+			name: "full line",
+			line: "  4242     1 dev      Tue Oct  6 09:05:31 2026   0.3  52344 node server.js --port 3000",
+			want: ProcessInfo{PID: 4242, PPID: 1, User: "dev", StartTime: start, CPU: 0.3, RSS: 52344 * 1024, Command: "node server.js --port 3000"},
+		},
+		{
+			name: "command spacing is kept",
+			line: "   777     1 postgres Tue Oct  6 09:05:31 2026  12.0   8000 /Applications/Postgres App.app/bin/postgres  -D  /data",
+			want: ProcessInfo{PID: 777, PPID: 1, User: "postgres", StartTime: start, CPU: 12, RSS: 8000 * 1024, Command: "/Applications/Postgres App.app/bin/postgres  -D  /data"},
+		},
+		{
+			name: "unparseable start time",
+			line: "  10   2 root  Di  6 Okt 09:05:31 2026   0.0      0 [kworker/0:1]",
+			want: ProcessInfo{PID: 10, PPID: 2, User: "root", RSS: 0, Command: "[kworker/0:1]"},
+		},
+		{
+			name:    "not enough fields",
+			line:    "4242 1 dev",
+			wantErr: true,
+		},
+		{
+			name:    "non-numeric PID",
+			line:    "PID PPID USER Tue Oct  6 09:05:31 2026 0.0 0 x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePSLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parsePSLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parsePSLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupProcessesPS(t *testing.T) {
+	var calls []string
+	run := func(name string, args ...string) ([]byte, error) {
+		call := name + " " + strings.Join(args, " ")
+		calls = append(calls, call)
+		switch {
+		case strings.Contains(call, "pid=,comm="):
+			return []byte("    1 /sbin/launchd\n"), nil
+		case name == "lsof":
+			// PID 1's cwd is not readable
+			return []byte("p4242\nfcwd\nn/Users/dev/my app\n"), nil
+		default:
+			// PID 9 has exited: ps prints the others and exits 1
+			return []byte("    1     0 root     Tue Oct  6 09:05:31 2026   0.0  12000 /sbin/launchd\n" +
+				" 4242     1 dev      Tue Oct  6 09:05:31 2026   0.3  52344 node server.js\n"), errors.New("exit status 1")
+		}
+	}
+
+	infos, err := lookupProcessesPS(run, []int{1, 4242, 9})
+	if err != nil {
+		t.Fatalf("lookupProcessesPS() error = %v", err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("ran %d commands, want 3 regardless of PID count: %q", len(calls), calls)
+	}
+	if !strings.HasSuffix(calls[0], "-p 1,4242,9") {
+		t.Errorf("ps args = %q, want all PIDs in one -p", calls[0])
+	}
+	if len(infos) != 2 || infos[4242].Command != "node server.js" || infos[1].User != "root" {
+		t.Errorf("lookupProcessesPS() = %+v", infos)
+	}
+	if node := infos[4242]; node.ParentName != "launchd" || node.Cwd != "/Users/dev/my app" {
+		t.Errorf("node ParentName = %q, Cwd = %q", node.ParentName, node.Cwd)
+	}
+	if infos[1].ParentName != "" || infos[1].Cwd != "" {
+		t.Errorf("init = %+v, want no parent or cwd", infos[1])
+	}
+
+	failing := func(name string, args ...string) ([]byte, error) { return nil, errors.New("not found") }
+	if _, err := lookupProcessesPS(failing, []int{1}); err == nil {
+		t.Error("lookupProcessesPS() expected error when ps fails without output")
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	// comm with spaces and a ')' must not shift the fields
+	data := "4242 (node (worker) 1) S 1 4242 4242 0 -1 4194304 100 0 0 0 250 50 0 0 20 0 11 0 360000 1000000 300 18446744073709551615"
+	st, err := parseProcStat(data)
+	if err != nil {
+		t.Fatalf("parseProcStat() error = %v", err)
+	}
+	want := procStat{ppid: 1, cpuTicks: 300, startTick: 360000, rssPages: 300}
+	if st != want {
+		t.Errorf("parseProcStat() = %+v, want %+v", st, want)
+	}
+
+	for _, bad := range []string{"", "4242 node S 1", "4242 (node) S 1 2 3"} {
+		if _, err := parseProcStat(bad); err == nil {
+			t.Errorf("parseProcStat(%q) expected error", bad)
+		}
+	}
+}
+
+func TestLookupProcessesProcfs(t *testing.T) {
+	root := writeFakeProcfs(t, nil, []fakeProcess{
+		{pid: 1, comm: "systemd"},
+		{pid: 4242, comm: "node", cmdline: []string{"node", "server.js"}},
+		{pid: 2, comm: "kthreadd"},
+	})
+	if err := os.Symlink("/srv/app", filepath.Join(root, "4242", "cwd")); err != nil {
+		t.Fatal(err)
+	}
+	boot := time.Unix(1_790_000_000, 0)
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("stat", "cpu  1 2 3 4\nbtime "+strconv.FormatInt(boot.Unix(), 10)+"\nprocesses 100\n")
+	// Started 3600s after boot, 300 ticks (3s) of CPU, 300 pages resident
+	writeFile("4242/stat", "4242 (node) S 1 4242 4242 0 -1 0 0 0 0 0 250 50 0 0 20 0 11 0 360000 1000000 300 0")
+	writeFile("4242/status", "Name:\tnode\nUid:\t0\t0\t0\t0\n")
+	writeFile("2/stat", "2 (kthreadd) S 0 0 0 0 -1 0 0 0 0 0 0 0 0 0 20 0 1 0 1 0 0 0")
+
+	now := boot.Add(3600*time.Second + 100*time.Second)
+	infos, err := lookupProcessesProcfs(root, []int{4242, 2, 99}, now)
+	if err != nil {
+		t.Fatalf("lookupProcessesProcfs() error = %v", err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("lookupProcessesProcfs() returned %d processes, want 2: %+v", len(infos), infos)
+	}
+
+	node := infos[4242]
+	if node.PPID != 1 || node.ParentName != "systemd" || node.Command != "node server.js" || node.User != "root" || node.Cwd != "/srv/app" {
+		t.Errorf("node = %+v", node)
+	}
+	if want := boot.Add(3600 * time.Second); !node.StartTime.Equal(want) {
+		t.Errorf("StartTime = %v, want %v", node.StartTime, want)
+	}
+	if node.Uptime(now) != 100*time.Second {
+		t.Errorf("Uptime() = %v, want 100s", node.Uptime(now))
+	}
+	if node.CPU != 3 {
+		t.Errorf("CPU = %v, want 3 (3s over 100s)", node.CPU)
+	}
+	if node.RSS != 300*int64(os.Getpagesize()) {
+		t.Errorf("RSS = %d, want 300 pages", node.RSS)
+	}
+	if infos[2].Command != "kthreadd" || infos[2].ParentName != "" || infos[2].Cwd != "" {
+		t.Errorf("kernel thread = %+v, want comm fallback and no parent or cwd", infos[2])
+	}
+}
+
+func TestLookupCached(t *testing.T) {
+	now := time.Unix(1_790_000_000, 0)
+	cache := newProcessCache(5*time.Second, nil)
+	cache.now = func() time.Time { return now }
+
+	started := map[int]time.Time{1: now.Add(-time.Hour), 2: now.Add(-time.Minute)}
+	var lookups [][]int
+	lookup := func(pids []int) (map[int]ProcessInfo, error) {
+		lookups = append(lookups, pids)
+		infos := make(map[int]ProcessInfo)
+		for _, pid := range pids {
+			infos[pid] = ProcessInfo{PID: pid, StartTime: started[pid], Command: fmt.Sprintf("cmd-%d", pid)}
+		}
+		return infos, nil
+	}
+
+	if _, err := lookupCached(cache, lookup, []int{1, 2, 2}); err != nil {
+		t.Fatal(err)
+	}
+	infos, _ := lookupCached(cache, lookup, []int{1, 2, 3})
+	if len(lookups) != 2 || len(lookups[1]) != 1 || lookups[1][0] != 3 {
+		t.Errorf("lookups = %v, want [[1 2] [3]]", lookups)
+	}
+	if len(infos) != 3 || infos[2].Command != "cmd-2" {
+		t.Errorf("lookupCached() = %+v", infos)
+	}
+
+	// After the TTL everything is fetched again; a recycled PID replaces the old entry
+	now = now.Add(6 * time.Second)
+	started[2] = now
+	infos, _ = lookupCached(cache, lookup, []int{2})
+	if len(lookups) != 3 || !infos[2].StartTime.Equal(now) {
+		t.Errorf("expired entry not refetched: lookups = %v, info = %+v", lookups, infos[2])
+	}
+	// Storing prunes the entries that expired, here those of PIDs 1 and 3
+	if len(cache.entries) != 1 {
+		t.Errorf("stale entries kept: %d entries, want 1", len(cache.entries))
+	}
+}
+
+func TestLookupCached_RecycledPID(t *testing.T) {
+	now := time.Unix(1_790_000_000, 0)
+	started := map[int]time.Time{7: now.Add(-time.Hour)}
+	running := func(pid int) (time.Time, bool) {
+		start, ok := started[pid]
+		return start, ok
+	}
+	cache := newProcessCache(5*time.Second, running)
+	cache.now = func() time.Time { return now }
+
+	var lookups int
+	lookup := func(pids []int) (map[int]ProcessInfo, error) {
+		lookups++
+		infos := make(map[int]ProcessInfo)
+		for _, pid := range pids {
+			if start, ok := started[pid]; ok {
+				infos[pid] = ProcessInfo{PID: pid, StartTime: start, Command: fmt.Sprintf("cmd-%d-%d", pid, lookups)}
+			}
+		}
+		return infos, nil
+	}
+
+	lookupCached(cache, lookup, []int{7})
+	if infos, _ := lookupCached(cache, lookup, []int{7}); lookups != 1 || infos[7].Command != "cmd-7-1" {
+		t.Errorf("same process not served from cache: lookups = %d, info = %+v", lookups, infos[7])
+	}
+
+	// Within the TTL, PID 7 exits and is reused by another process
+	now = now.Add(time.Second)
+	started[7] = now
+	infos, _ := lookupCached(cache, lookup, []int{7})
+	if lookups != 2 || infos[7].Command != "cmd-7-2" || !infos[7].StartTime.Equal(now) {
+		t.Errorf("recycled PID got the old details: lookups = %d, info = %+v", lookups, infos[7])
+	}
+
+	// An exited process is not served either
+	delete(started, 7)
+	if infos, _ := lookupCached(cache, lookup, []int{7}); len(infos) != 0 {
+		t.Errorf("exited process served from cache: %+v", infos)
+	}
+}
+
+func TestProcfsStartTime(t *testing.T) {
+	root := writeFakeProcfs(t, nil, []fakeProcess{{pid: 4242, comm: "node"}})
+	boot := time.Unix(1_790_000_000, 0)
+	for path, content := range map[string]string{
+		"stat":      "btime " + strconv.FormatInt(boot.Unix(), 10) + "\n",
+		"4242/stat": "4242 (node) S 1 4242 4242 0 -1 0 0 0 0 0 250 50 0 0 20 0 11 0 360000 1000000 300 0",
+	} {
+		if err := os.WriteFile(filepath.Join(root, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if start, ok := procfsStartTime(root, 4242); !ok || !start.Equal(boot.Add(3600*time.Second)) {
+		t.Errorf("procfsStartTime(4242) = %v, %v", start, ok)
+	}
+	if _, ok := procfsStartTime(root, 99); ok {
+		t.Error("procfsStartTime() found an exited process")
+	}
+}
+
+// benchmarkPIDs returns up to n PIDs of running processes
+func benchmarkPIDs(b *testing.B, n int) []int {
+	b.Helper()
+	output, err := runCommand("ps", "-A", "-o", "pid=")
+	if err != nil {
+		b.Skipf("ps unavailable: %v", err)
+	}
+	var pids []int
+	for _, field := range strings.Fields(string(output)) {
+		if pid, err := strconv.Atoi(field); err == nil && len(pids) < n {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// BenchmarkProcessCommands_PerPID is the old menu path: one ps per listener
+func BenchmarkProcessCommands_PerPID(b *testing.B) {
+	pids := benchmarkPIDs(b, 40)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, pid := range pids {
+			GetFullCommand(pid)
+		}
+	}
+}
+
+func BenchmarkProcessCommands_BatchedPS(b *testing.B) {
+	pids := benchmarkPIDs(b, 40)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := lookupProcessesPS(runCommand, pids); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProcessCommands_Procfs(b *testing.B) {
+	if !procfsAvailable(defaultProcRoot) {
+		b.Skip("procfs unavailable")
+	}
+	pids := benchmarkPIDs(b, 40)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := lookupProcessesProcfs(defaultProcRoot, pids, time.Now()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package scanner
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"port-digger/logger"
+	"strconv"
+	"strings"
+)
+
+// defaultProcRoot is where the kernel mounts procfs on Linux
+const defaultProcRoot = "/proc"
+
+// Socket states of the "st" column in /proc/net/{tcp,udp}
+const (
+	tcpListenState = "0A" // TCP_LISTEN
+	udpUnconnState = "07" // TCP_CLOSE, used for bound but unconnected UDP sockets
+)
+
+// procTable describes one procfs socket table
+type procTable struct {
+	file     string // File under <root>/net, e.g. "tcp6"
+	protocol string // PortInfo.Protocol for its sockets, e.g. "TCP6"
+	family   string // FamilyIPv4 or FamilyIPv6
+	state    string // Value of the "st" column for listening sockets
+}
+
+// procTables lists the socket tables read by the procfs backend
+var procTables = []procTable{
+	{"tcp", "TCP", FamilyIPv4, tcpListenState},
+	{"tcp6", "TCP6", FamilyIPv6, tcpListenState},
+	{"udp", "UDP", FamilyIPv4, udpUnconnState},
+	{"udp6", "UDP6", FamilyIPv6, udpUnconnState},
+}
+
+// procSocket is a listening socket read from a /proc/net socket table
+type procSocket struct {
+	IP       net.IP
+	Port     int
+	Inode    string
+	Protocol string // "TCP", "TCP6", "UDP" or "UDP6"
+	Family   string // FamilyIPv4 or FamilyIPv6
+}
+
+// procfsBackend reads the kernel socket tables directly, Linux only
+type procfsBackend struct {
+	root string
+}
+
+func newProcfsBackend(root string) *procfsBackend {
+	return &procfsBackend{root: root}
+}
+
+func (b *procfsBackend) Name() string { return "procfs" }
+
+func (b *procfsBackend) Available() bool { return procfsAvailable(b.root) }
+
+func (b *procfsBackend) Scan() ([]PortInfo, error) { return scanProcfs(b.root) }
+
+// procfsAvailable reports whether the procfs socket tables can be read
+func procfsAvailable(root string) bool {
+	_, err := os.Stat(filepath.Join(root, "net", "tcp"))
+	return err == nil
+}
+
+// parseProcAddr decodes a "0100007F:1F90" style address from /proc/net/tcp
+// The IP is stored as native-endian 32-bit words, the port as big-endian hex
+func parseProcAddr(s string) (net.IP, int, error) {
+	colonIdx := strings.LastIndex(s, ":")
+	if colonIdx == -1 {
+		return nil, 0, fmt.Errorf("no port in address %q", s)
+	}
+
+	port, err := strconv.ParseUint(s[colonIdx+1:], 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid port in address %q: %w", s, err)
+	}
+
+	raw, err := hex.DecodeString(s[:colonIdx])
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid IP in address %q: %w", s, err)
+	}
+	if len(raw) != net.IPv4len && len(raw) != net.IPv6len {
+		return nil, 0, fmt.Errorf("unexpected IP length %d in address %q", len(raw), s)
+	}
+
+	// Each 4-byte word is little-endian on the architectures we care about
+	ip := make(net.IP, len(raw))
+	for i := 0; i < len(raw); i += 4 {
+		ip[i], ip[i+1], ip[i+2], ip[i+3] = raw[i+3], raw[i+2], raw[i+1], raw[i]
+	}
+
+	return ip, int(port), nil
+}
+
+// parseProcNetLine parses one row of /proc/net/{tcp,udp}{,6}
+// Example: "   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12345 1 ..."
+// Returns nil without error for sockets that are not listening, including
+// connected UDP sockets, which share the unconnected state but have a peer
+func parseProcNetLine(line string, table procTable) (*procSocket, error) {
+	fields := strings.Fields(line)
+
+	// sl, local, remote, st, tx:rx, tr:when, retrnsmt, uid, timeout, inode
+	if len(fields) < 10 {
+		return nil, fmt.Errorf("invalid line format: not enough fields")
+	}
+
+	// Skip header line
+	if fields[0] == "sl" {
+		return nil, fmt.Errorf("header line")
+	}
+
+	if fields[3] != table.state {
+		return nil, nil
+	}
+
+	ip, port, err := parseProcAddr(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	_, remotePort, err := parseProcAddr(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	if remotePort != 0 {
+		return nil, nil
+	}
+
+	return &procSocket{
+		IP:       ip,
+		Port:     port,
+		Inode:    fields[9],
+		Protocol: table.protocol,
+		Family:   table.family,
+	}, nil
+}
+
+// readProcNet reads the listening sockets from one procfs socket table
+// A missing table (e.g. IPv6 disabled) yields no sockets rather than an error
+func readProcNet(path string, table procTable) ([]procSocket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var sockets []procSocket
+	lines := bufio.NewScanner(f)
+	for lines.Scan() {
+		sock, err := parseProcNetLine(lines.Text(), table)
+		if err != nil || sock == nil {
+			continue
+		}
+		sockets = append(sockets, *sock)
+	}
+
+	if err := lines.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return sockets, nil
+}
+
+// socketInode extracts the inode from a "socket:[12345]" fd link target
+func socketInode(link string) (string, bool) {
+	if !strings.HasPrefix(link, "socket:[") || !strings.HasSuffix(link, "]") {
+		return "", false
+	}
+	return link[len("socket:[") : len(link)-1], true
+}
+
+// mapSocketOwners walks <root>/<pid>/fd and maps socket inodes to the PIDs
+// holding them. Processes we may not inspect are skipped, like lsof does
+func mapSocketOwners(root string, wanted map[string]bool) (map[string][]int, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := make(map[string][]int)
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue // not a process directory
+		}
+
+		fdDir := filepath.Join(root, entry.Name(), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
+			continue // permission denied or process exited
+		}
+
+		seen := make(map[string]bool)
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil {
+				continue
+			}
+			inode, ok := socketInode(link)
+			if !ok || !wanted[inode] || seen[inode] {
+				continue
+			}
+			seen[inode] = true
+			owners[inode] = append(owners[inode], pid)
+		}
+	}
+	return owners, nil
+}
+
+// readProcComm returns the process name from <root>/<pid>/comm
+func readProcComm(root string, pid int) string {
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "comm"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readProcCmdline returns the full command line from <root>/<pid>/cmdline
+// Arguments are NUL-separated in procfs and joined with spaces here
+func readProcCmdline(root string, pid int) string {
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return ""
+	}
+	args := strings.Split(strings.TrimRight(string(data), "\x00"), "\x00")
+	return strings.TrimSpace(strings.Join(args, " "))
+}
+
+// scanProcfs lists listening TCP and UDP ports by reading the procfs tree at root
+func scanProcfs(root string) ([]PortInfo, error) {
+	var tables []string
+	for _, table := range procTables {
+		tables = append(tables, filepath.Join(root, "net", table.file))
+	}
+
+	var sockets []procSocket
+	for _, table := range procTables {
+		found, err := readProcNet(filepath.Join(root, "net", table.file), table)
+		if err != nil {
+			err = fmt.Errorf("failed to read %s table: %w", table.file, err)
+			logger.LogScanQuery("procfs", tables, 0, err)
+			return nil, err
+		}
+		sockets = append(sockets, found...)
+	}
+
+	wanted := make(map[string]bool, len(sockets))
+	for _, sock := range sockets {
+		wanted[sock.Inode] = true
+	}
+
+	owners, err := mapSocketOwners(root, wanted)
+	if err != nil {
+		err = fmt.Errorf("failed to map socket owners: %w", err)
+		logger.LogScanQuery("procfs", tables, 0, err)
+		return nil, err
+	}
+
+	ports := []PortInfo{}
+	for _, sock := range sockets {
+		for _, pid := range owners[sock.Inode] {
+			name := readProcComm(root, pid)
+			command := readProcCmdline(root, pid)
+			if command == "" {
+				command = name // kernel threads have an empty cmdline
+			}
+
+			ports = append(ports, PortInfo{
+				Port:        sock.Port,
+				ProcessName: name,
+				PID:         pid,
+				Command:     command,
+				Protocol:    sock.Protocol,
+				BindAddress: normalizeBindAddress(sock.IP.String(), sock.Family),
+				Family:      sock.Family,
+			})
+		}
+	}
+
+	logger.LogScanQuery("procfs", tables, len(ports), nil)
+	return ports, nil
+}
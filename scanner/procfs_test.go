@@ -0,0 +1,228 @@
+package scanner
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+const procNetHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+// fakeProcess describes a process in a fake procfs tree
+type fakeProcess struct {
+	pid     int
+	comm    string
+	cmdline []string
+	sockets []string // socket inodes held open as fds
+}
+
+// writeFakeProcfs builds a minimal procfs tree under a temp dir. tables maps
+// net/ file names ("tcp", "udp6", ...) to their rows; net/tcp always exists
+func writeFakeProcfs(t *testing.T, tables map[string]string, procs []fakeProcess) string {
+	t.Helper()
+	root := t.TempDir()
+
+	mustWrite := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mustWrite(filepath.Join(root, "net", "tcp"), procNetHeader+tables["tcp"])
+	for name, rows := range tables {
+		if name != "tcp" {
+			mustWrite(filepath.Join(root, "net", name), procNetHeader+rows)
+		}
+	}
+
+	for _, p := range procs {
+		dir := filepath.Join(root, strconv.Itoa(p.pid))
+		mustWrite(filepath.Join(dir, "comm"), p.comm+"\n")
+		cmdline := ""
+		for _, arg := range p.cmdline {
+			cmdline += arg + "\x00"
+		}
+		mustWrite(filepath.Join(dir, "cmdline"), cmdline)
+
+		fdDir := filepath.Join(dir, "fd")
+		if err := os.MkdirAll(fdDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		// stdin-like non-socket fd
+		if err := os.Symlink("/dev/null", filepath.Join(fdDir, "0")); err != nil {
+			t.Fatal(err)
+		}
+		for i, inode := range p.sockets {
+			link := filepath.Join(fdDir, strconv.Itoa(i+3))
+			if err := os.Symlink("socket:["+inode+"]", link); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Non-process entries must be ignored
+	mustWrite(filepath.Join(root, "self", "comm"), "ignored\n")
+	return root
+}
+
+func TestParseProcAddr(t *testing.T) {
+	tests := []struct {
+		name     string
+		addr     string
+		wantIP   string
+		wantPort int
+		wantErr  bool
+	}{
+		{"IPv4 loopback", "0100007F:1F90", "127.0.0.1", 8080, false},
+		{"IPv4 wildcard", "00000000:0BB8", "0.0.0.0", 3000, false},
+		{"IPv4 LAN address", "0101A8C0:0016", "192.168.1.1", 22, false},
+		{"IPv6 wildcard", "00000000000000000000000000000000:1538", "::", 5432, false},
+		{"IPv6 loopback", "00000000000000000000000001000000:0BB8", "::1", 3000, false},
+		{"missing port", "0100007F", "", 0, true},
+		{"bad hex", "ZZ00007F:1F90", "", 0, true},
+		{"bad length", "00007F:1F90", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, port, err := parseProcAddr(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseProcAddr() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !ip.Equal(net.ParseIP(tt.wantIP)) {
+				t.Errorf("IP = %v, want %v", ip, tt.wantIP)
+			}
+			if port != tt.wantPort {
+				t.Errorf("Port = %v, want %v", port, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestParseProcNetLine(t *testing.T) {
+	listen := "   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12345 1 0000000000000000 100 0 0 10 0"
+	established := "   1: 0100007F:1F90 0100007F:C390 01 00000000:00000000 00:00000000 00000000  1000        0 12346 2 0000000000000000 20 4 0 18 -1"
+
+	tcp, udp := procTables[0], procTables[2]
+
+	sock, err := parseProcNetLine(listen, tcp)
+	if err != nil {
+		t.Fatalf("parseProcNetLine(listen) error = %v", err)
+	}
+	if sock == nil || sock.Port != 8080 || sock.Inode != "12345" || sock.Protocol != "TCP" || sock.Family != FamilyIPv4 {
+		t.Errorf("parseProcNetLine(listen) = %+v", sock)
+	}
+
+	sock, err = parseProcNetLine(established, tcp)
+	if err != nil || sock != nil {
+		t.Errorf("parseProcNetLine(established) = %+v, %v; want nil, nil", sock, err)
+	}
+
+	bound := "  12: 00000000:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000    65        0 4001 2 0000000000000000 0"
+	connected := "  13: 0100007F:C3CB 0100007F:0035 01 00000000:00000000 00:00000000 00000000  1000        0 4002 2 0000000000000000 0"
+
+	sock, err = parseProcNetLine(bound, udp)
+	if err != nil {
+		t.Fatalf("parseProcNetLine(bound udp) error = %v", err)
+	}
+	if sock == nil || sock.Port != 5353 || sock.Inode != "4001" || sock.Protocol != "UDP" {
+		t.Errorf("parseProcNetLine(bound udp) = %+v", sock)
+	}
+
+	sock, err = parseProcNetLine(connected, udp)
+	if err != nil || sock != nil {
+		t.Errorf("parseProcNetLine(connected udp) = %+v, %v; want nil, nil", sock, err)
+	}
+
+	if _, err := parseProcNetLine(procNetHeader, tcp); err == nil {
+		t.Error("parseProcNetLine(header) expected error")
+	}
+	if _, err := parseProcNetLine("   0: 0100007F:1F90", tcp); err == nil {
+		t.Error("parseProcNetLine(short line) expected error")
+	}
+}
+
+func TestScanProcfs(t *testing.T) {
+	tcp := "" +
+		"   0: 00000000:0BB8 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 1001 1 0 100 0 0 10 0\n" +
+		"   1: 0100007F:1538 00000000:0000 0A 00000000:00000000 00:00000000 00000000   999        0 1002 1 0 100 0 0 10 0\n" +
+		"   2: 0100007F:0BB8 0100007F:C390 01 00000000:00000000 00:00000000 00000000  1000        0 1003 2 0 20 4 0 18 -1\n" +
+		"   3: 0100007F:2382 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 1004 1 0 100 0 0 10 0\n"
+	tcp6 := "" +
+		"   0: 00000000000000000000000000000000:0BB8 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 2001 1 0 100 0 0 10 0\n"
+
+	udp := "" +
+		"  12: 00000000:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000    65        0 3001 2 0 0\n" +
+		"  13: 0100007F:C3CB 0100007F:0035 01 00000000:00000000 00:00000000 00000000  1000        0 3002 2 0 0\n"
+
+	root := writeFakeProcfs(t, map[string]string{"tcp": tcp, "tcp6": tcp6, "udp": udp}, []fakeProcess{
+		{pid: 4242, comm: "node", cmdline: []string{"node", "server.js", "--port", "3000"}, sockets: []string{"1001", "2001", "1003", "3002"}},
+		{pid: 312, comm: "avahi-daemon", cmdline: []string{"avahi-daemon: running"}, sockets: []string{"3001"}},
+		{pid: 777, comm: "postgres", cmdline: []string{"/usr/lib/postgresql/16/bin/postgres", "-D", "/var/lib/postgresql"}, sockets: []string{"1002"}},
+		{pid: 10, comm: "kworker", sockets: nil},
+		// 1004 is owned by a process we cannot see, so it is skipped
+	})
+
+	ports, err := scanProcfs(root)
+	if err != nil {
+		t.Fatalf("scanProcfs() error = %v", err)
+	}
+
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		return ports[i].Protocol < ports[j].Protocol
+	})
+
+	want := []PortInfo{
+		{Port: 3000, ProcessName: "node", PID: 4242, Command: "node server.js --port 3000", Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 3000, ProcessName: "node", PID: 4242, Command: "node server.js --port 3000", Protocol: "TCP6", BindAddress: "::", Family: FamilyIPv6},
+		{Port: 5353, ProcessName: "avahi-daemon", PID: 312, Command: "avahi-daemon: running", Protocol: "UDP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+		{Port: 5432, ProcessName: "postgres", PID: 777, Command: "/usr/lib/postgresql/16/bin/postgres -D /var/lib/postgresql", Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4},
+	}
+
+	if len(ports) != len(want) {
+		t.Fatalf("scanProcfs() returned %d ports, want %d: %+v", len(ports), len(want), ports)
+	}
+	for i := range want {
+		if ports[i] != want[i] {
+			t.Errorf("ports[%d] = %+v, want %+v", i, ports[i], want[i])
+		}
+	}
+}
+
+func TestScanProcfs_NoIPv6Table(t *testing.T) {
+	tcp := "   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 5001 1 0 100 0 0 10 0\n"
+	root := writeFakeProcfs(t, map[string]string{"tcp": tcp}, []fakeProcess{
+		{pid: 55, comm: "python3", cmdline: []string{"python3", "-m", "http.server", "8080"}, sockets: []string{"5001"}},
+	})
+
+	ports, err := scanProcfs(root)
+	if err != nil {
+		t.Fatalf("scanProcfs() error = %v", err)
+	}
+	if len(ports) != 1 || ports[0].Port != 8080 || ports[0].PID != 55 {
+		t.Errorf("scanProcfs() = %+v", ports)
+	}
+}
+
+func TestProcfsAvailable(t *testing.T) {
+	root := writeFakeProcfs(t, nil, nil)
+	if !procfsAvailable(root) {
+		t.Error("procfsAvailable() = false for fake procfs")
+	}
+	if procfsAvailable(t.TempDir()) {
+		t.Error("procfsAvailable() = true for empty dir")
+	}
+}
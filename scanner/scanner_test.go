@@ -25,127 +25,6 @@ func TestPortInfo_Validation(t *testing.T) {
 	}
 }
 
-func TestUnescapeLsofString(t *testing.T) {
-	tests := []struct {
-		name  string
-		input string
-		want  string
-	}{
-		{
-			name:  "no escape sequences",
-			input: "node",
-			want:  "node",
-		},
-		{
-			name:  "space escaped as \\x20",
-			input: "Antigravity\\x20Helper\\x20(Plugin)",
-			want:  "Antigravity Helper (Plugin)",
-		},
-		{
-			name:  "multiple escape sequences",
-			input: "My\\x20App\\x20Name",
-			want:  "My App Name",
-		},
-		{
-			name:  "parentheses and spaces",
-			input: "App\\x20(Helper)",
-			want:  "App (Helper)",
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got := unescapeLsofString(tt.input)
-			if got != tt.want {
-				t.Errorf("unescapeLsofString() = %q, want %q", got, tt.want)
-			}
-		})
-	}
-}
-
-func TestParseLsofLine(t *testing.T) {
-	tests := []struct {
-		name     string
-		line     string
-		want     *PortInfo
-		wantErr  bool
-	}{
-		{
-			name: "valid TCP line",
-			line: "node      12345 user   23u  IPv4 0x1234      0t0  TCP *:3000 (LISTEN)",
-			want: &PortInfo{
-				Port:        3000,
-				ProcessName: "node",
-				PID:         12345,
-				Command:     "node",
-				Protocol:    "TCP",
-			},
-			wantErr: false,
-		},
-		{
-			name: "valid TCP6 line",
-			line: "Python    9876 user   5u  IPv6 0x5678      0t0  TCP6 *:8080 (LISTEN)",
-			want: &PortInfo{
-				Port:        8080,
-				ProcessName: "Python",
-				PID:         9876,
-				Command:     "Python",
-				Protocol:    "TCP6",
-			},
-			wantErr: false,
-		},
-		{
-			name: "process name with escaped spaces",
-			line: "Antigravity\\x20Helper\\x20(Plugin)  4940 mcpp   26u  IPv4 0xe4f763a7847c6ef2      0t0  TCP 127.0.0.1:59531 (LISTEN)",
-			want: &PortInfo{
-				Port:        59531,
-				ProcessName: "Antigravity Helper (Plugin)",
-				PID:         4940,
-				Command:     "Antigravity Helper (Plugin)",
-				Protocol:    "TCP",
-			},
-			wantErr: false,
-		},
-		{
-			name:    "invalid line - not enough fields",
-			line:    "node 12345",
-			want:    nil,
-			wantErr: true,
-		},
-		{
-			name:    "header line",
-			line:    "COMMAND   PID USER   FD   TYPE             DEVICE SIZE/OFF NODE NAME",
-			want:    nil,
-			wantErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseLsofLine(tt.line)
-			if (err != nil) != tt.wantErr {
-				t.Errorf("parseLsofLine() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-			if tt.wantErr {
-				return
-			}
-			if got.Port != tt.want.Port {
-				t.Errorf("Port = %v, want %v", got.Port, tt.want.Port)
-			}
-			if got.ProcessName != tt.want.ProcessName {
-				t.Errorf("ProcessName = %v, want %v", got.ProcessName, tt.want.ProcessName)
-			}
-			if got.PID != tt.want.PID {
-				t.Errorf("PID = %v, want %v", got.PID, tt.want.PID)
-			}
-			if got.Protocol != tt.want.Protocol {
-				t.Errorf("Protocol = %v, want %v", got.Protocol, tt.want.Protocol)
-			}
-		})
-	}
-}
-
 func TestScanPorts(t *testing.T) {
 	// Only run on macOS (lsof behavior is OS-specific)
 	if runtime.GOOS != "darwin" {
@@ -178,3 +57,77 @@ func TestScanPorts(t *testing.T) {
 		}
 	}
 }
+
+func TestNormalizeBindAddress(t *testing.T) {
+	tests := []struct {
+		host   string
+		family string
+		want   string
+	}{
+		{"*", FamilyIPv4, "0.0.0.0"},
+		{"*", FamilyIPv6, "::"},
+		{"", FamilyIPv4, "0.0.0.0"},
+		{"0.0.0.0", FamilyIPv4, "0.0.0.0"},
+		{"127.0.0.1", FamilyIPv4, "127.0.0.1"},
+		{"127.0.0.53%lo", FamilyIPv4, "127.0.0.53"},
+		{"::1", FamilyIPv6, "::1"},
+		{"0:0:0:0:0:0:0:1", FamilyIPv6, "::1"},
+		{"fe80::1%lo0", FamilyIPv6, "fe80::1%lo0"},
+		{"192.168.1.23", FamilyIPv4, "192.168.1.23"},
+		{"localhost", FamilyIPv4, "localhost"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+"/"+tt.family, func(t *testing.T) {
+			if got := normalizeBindAddress(tt.host, tt.family); got != tt.want {
+				t.Errorf("normalizeBindAddress(%q, %q) = %q, want %q", tt.host, tt.family, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPortInfo_Exposure(t *testing.T) {
+	tests := []struct {
+		bind         string
+		wantWildcard bool
+		wantLoopback bool
+		wantExposed  bool
+	}{
+		{"0.0.0.0", true, false, true},
+		{"::", true, false, true},
+		{"127.0.0.1", false, true, false},
+		{"::1", false, true, false},
+		{"192.168.1.23", false, false, true},
+		{"", false, false, false},
+	}
+
+	for _, tt := range tests {
+		p := PortInfo{Port: 3000, BindAddress: tt.bind}
+		if got := p.IsWildcard(); got != tt.wantWildcard {
+			t.Errorf("IsWildcard(%q) = %v, want %v", tt.bind, got, tt.wantWildcard)
+		}
+		if got := p.IsLoopback(); got != tt.wantLoopback {
+			t.Errorf("IsLoopback(%q) = %v, want %v", tt.bind, got, tt.wantLoopback)
+		}
+		if got := p.Exposed(); got != tt.wantExposed {
+			t.Errorf("Exposed(%q) = %v, want %v", tt.bind, got, tt.wantExposed)
+		}
+	}
+}
+
+func TestPortInfo_Address(t *testing.T) {
+	tests := []struct {
+		info PortInfo
+		want string
+	}{
+		{PortInfo{Port: 3000, BindAddress: "127.0.0.1"}, "127.0.0.1:3000"},
+		{PortInfo{Port: 3000, BindAddress: "::1"}, "[::1]:3000"},
+		{PortInfo{Port: 80, BindAddress: "0.0.0.0"}, "0.0.0.0:80"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.info.Address(); got != tt.want {
+			t.Errorf("Address() = %q, want %q", got, tt.want)
+		}
+	}
+}
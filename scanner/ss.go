@@ -0,0 +1,154 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"port-digger/logger"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ssBackend scans with iproute2's ss, available on most Linux systems
+type ssBackend struct {
+	run commandRunner
+}
+
+func newSSBackend() *ssBackend {
+	return &ssBackend{run: runCommand}
+}
+
+func (b *ssBackend) Name() string { return "ss" }
+
+func (b *ssBackend) Available() bool {
+	_, err := exec.LookPath("ss")
+	return err == nil
+}
+
+// ssUserPattern matches one ("name",pid=123,fd=4) entry of the Process column
+var ssUserPattern = regexp.MustCompile(`\("((?:[^"\\]|\\.)*)",pid=(\d+),fd=\d+\)`)
+
+// splitHostPort splits "127.0.0.1:8080", "[::1]:3000" or "*:22" at the last colon
+func splitHostPort(addr string) (string, int, error) {
+	colonIdx := strings.LastIndex(addr, ":")
+	if colonIdx == -1 {
+		return "", 0, fmt.Errorf("no port in address %q", addr)
+	}
+
+	port, err := strconv.Atoi(addr[colonIdx+1:])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port number: %w", err)
+	}
+
+	host := strings.TrimSuffix(strings.TrimPrefix(addr[:colonIdx], "["), "]")
+	return host, port, nil
+}
+
+// parseSSLine parses a single line of `ss -ltunp` output into one PortInfo per owning process
+// Example: "tcp LISTEN 0 4096 127.0.0.1:631 0.0.0.0:* users:(("cupsd",pid=123,fd=7))"
+// The leading Netid column is optional and defaults to TCP
+func parseSSLine(line string) ([]PortInfo, error) {
+	fields := strings.Fields(line)
+
+	// Skip header line
+	if len(fields) > 0 && (fields[0] == "Netid" || fields[0] == "State") {
+		return nil, fmt.Errorf("header line")
+	}
+
+	transport := "TCP"
+	if len(fields) > 0 && (fields[0] == "tcp" || fields[0] == "udp") {
+		transport = strings.ToUpper(fields[0])
+		fields = fields[1:]
+	}
+
+	// State, Recv-Q, Send-Q, Local, Peer
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("invalid line format: not enough fields")
+	}
+
+	host, port, err := splitHostPort(fields[3])
+	if err != nil {
+		return nil, err
+	}
+
+	// Modern ss prints "*" only for dual-stack IPv6 sockets
+	protocol, family := transport, FamilyIPv4
+	if host == "*" || strings.Contains(host, ":") {
+		protocol, family = transport+"6", FamilyIPv6
+	}
+
+	// The Process column is missing for sockets of processes we cannot inspect
+	usersIdx := strings.Index(line, "users:(")
+	if usersIdx == -1 {
+		return nil, fmt.Errorf("no process information")
+	}
+
+	var ports []PortInfo
+	seen := make(map[int]bool)
+	for _, m := range ssUserPattern.FindAllStringSubmatch(line[usersIdx:], -1) {
+		pid, err := strconv.Atoi(m[2])
+		if err != nil || seen[pid] {
+			continue
+		}
+		seen[pid] = true
+
+		ports = append(ports, PortInfo{
+			Port:        port,
+			ProcessName: m[1],
+			PID:         pid,
+			Command:     m[1],
+			Protocol:    protocol,
+			BindAddress: normalizeBindAddress(host, family),
+			Family:      family,
+		})
+	}
+
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no process information")
+	}
+	return ports, nil
+}
+
+// parseSSOutput parses the full output of ss, skipping headers and unattributed sockets
+func parseSSOutput(output []byte) ([]PortInfo, error) {
+	ports := []PortInfo{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+
+	for scanner.Scan() {
+		infos, err := parseSSLine(scanner.Text())
+		if err != nil {
+			continue
+		}
+		ports = append(ports, infos...)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading ss output: %w", err)
+	}
+	return ports, nil
+}
+
+// Scan executes ss to get all listening TCP ports and bound UDP sockets
+func (b *ssBackend) Scan() ([]PortInfo, error) {
+	// Execute: ss -ltunp
+	// -l listening, -t TCP, -u UDP, -n numeric, -p owning processes
+	cmdArgs := []string{"-ltunp"}
+	command := append([]string{"ss"}, cmdArgs...)
+
+	output, err := b.run("ss", cmdArgs...)
+	if err != nil {
+		logger.LogScanQuery(b.Name(), command, 0, err)
+		return nil, fmt.Errorf("ss command failed: %w", err)
+	}
+
+	ports, err := parseSSOutput(output)
+	if err != nil {
+		logger.LogScanQuery(b.Name(), command, 0, err)
+		return nil, err
+	}
+
+	logger.LogScanQuery(b.Name(), command, len(ports), nil)
+	return ports, nil
+}
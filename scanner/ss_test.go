@@ -0,0 +1,103 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestParseSSLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []PortInfo
+		wantErr bool
+	}{
+		{
+			name: "IPv4 listener",
+			line: `LISTEN 0      4096   127.0.0.1:631   0.0.0.0:*   users:(("cupsd",pid=123,fd=7))`,
+			want: []PortInfo{{Port: 631, ProcessName: "cupsd", PID: 123, Command: "cupsd", Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4}},
+		},
+		{
+			name: "IPv6 listener",
+			line: `LISTEN 0      511    [::1]:3000      [::]:*      users:(("node",pid=4242,fd=22))`,
+			want: []PortInfo{{Port: 3000, ProcessName: "node", PID: 4242, Command: "node", Protocol: "TCP6", BindAddress: "::1", Family: FamilyIPv6}},
+		},
+		{
+			name: "dual-stack wildcard",
+			line: `LISTEN 0      128    *:22            *:*         users:(("sshd",pid=900,fd=3))`,
+			want: []PortInfo{{Port: 22, ProcessName: "sshd", PID: 900, Command: "sshd", Protocol: "TCP6", BindAddress: "::", Family: FamilyIPv6}},
+		},
+		{
+			name: "shared socket with duplicate fds",
+			line: `LISTEN 0      511    0.0.0.0:80      0.0.0.0:*   users:(("nginx",pid=11,fd=6),("nginx",pid=10,fd=6),("nginx",pid=10,fd=9))`,
+			want: []PortInfo{
+				{Port: 80, ProcessName: "nginx", PID: 11, Command: "nginx", Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+				{Port: 80, ProcessName: "nginx", PID: 10, Command: "nginx", Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4},
+			},
+		},
+		{
+			name: "process name with spaces",
+			line: `LISTEN 0      10     127.0.0.1:9222  0.0.0.0:*   users:(("Web Content",pid=55,fd=40))`,
+			want: []PortInfo{{Port: 9222, ProcessName: "Web Content", PID: 55, Command: "Web Content", Protocol: "TCP", BindAddress: "127.0.0.1", Family: FamilyIPv4}},
+		},
+		{
+			name: "interface-scoped address",
+			line: `LISTEN 0      4096   127.0.0.53%lo:53 0.0.0.0:*  users:(("systemd-resolve",pid=600,fd=14))`,
+			want: []PortInfo{{Port: 53, ProcessName: "systemd-resolve", PID: 600, Command: "systemd-resolve", Protocol: "TCP", BindAddress: "127.0.0.53", Family: FamilyIPv4}},
+		},
+		{
+			name: "Netid column",
+			line: `tcp   LISTEN 0      511    0.0.0.0:3000    0.0.0.0:*   users:(("node",pid=4242,fd=21))`,
+			want: []PortInfo{{Port: 3000, ProcessName: "node", PID: 4242, Command: "node", Protocol: "TCP", BindAddress: "0.0.0.0", Family: FamilyIPv4}},
+		},
+		{
+			name: "UDP socket",
+			line: `udp   UNCONN 0      0      0.0.0.0:5353    0.0.0.0:*   users:(("avahi-daemon",pid=312,fd=12))`,
+			want: []PortInfo{{Port: 5353, ProcessName: "avahi-daemon", PID: 312, Command: "avahi-daemon", Protocol: "UDP", BindAddress: "0.0.0.0", Family: FamilyIPv4}},
+		},
+		{
+			name: "UDP IPv6 socket",
+			line: `udp   UNCONN 0      0         [::]:5353       [::]:*   users:(("avahi-daemon",pid=312,fd=13))`,
+			want: []PortInfo{{Port: 5353, ProcessName: "avahi-daemon", PID: 312, Command: "avahi-daemon", Protocol: "UDP6", BindAddress: "::", Family: FamilyIPv6}},
+		},
+		{
+			name:    "Netid header line",
+			line:    `Netid State  Recv-Q Send-Q Local Address:Port Peer Address:Port Process`,
+			wantErr: true,
+		},
+		{
+			name:    "no process information",
+			line:    `LISTEN 0      128    0.0.0.0:22      0.0.0.0:*`,
+			wantErr: true,
+		},
+		{
+			name:    "header line",
+			line:    `State  Recv-Q Send-Q Local Address:Port Peer Address:Port Process`,
+			wantErr: true,
+		},
+		{
+			name:    "not enough fields",
+			line:    `LISTEN 0`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSSLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSSLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseSSLine() returned %d ports, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("ports[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
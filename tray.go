@@ -0,0 +1,27 @@
+package main
+
+import (
+	"port-digger/menu"
+
+	"github.com/getlantern/systray"
+)
+
+// trayItem adapts a systray menu item to menu.Item
+type trayItem struct {
+	*systray.MenuItem
+}
+
+func (t trayItem) AddSubItem(title, tooltip string) menu.Item {
+	return trayItem{t.AddSubMenuItem(title, tooltip)}
+}
+
+func (t trayItem) Clicked() <-chan struct{} {
+	return t.ClickedCh
+}
+
+// systrayTray adds top-level items to the system tray menu
+type systrayTray struct{}
+
+func (systrayTray) AddItem(title, tooltip string) menu.Item {
+	return trayItem{systray.AddMenuItem(title, tooltip)}
+}
@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"port-digger/actions"
+	"port-digger/logger"
+	"port-digger/scanner"
+	"syscall"
+	"time"
+)
+
+// Deps are the data sources and actions behind the UI, replaced in tests
+type Deps struct {
+	Scan        func() ([]scanner.PortInfo, error)
+	Processes   func(pids []int) (map[int]scanner.ProcessInfo, error)
+	ServiceName func(command string) string // Cached LLM name, "" if unknown
+	Rewrite     func(command string)        // Starts an LLM lookup in the background; may be nil
+	Kill        func(pids []int, opts actions.KillOptions) (actions.KillResult, error)
+	Open        func(address string, port int) error
+	Now         func() time.Time
+}
+
+// Options configures Run
+type Options struct {
+	Interval time.Duration // Time between scans
+	Sort     string        // Initial sort column, "" for port
+	Filter   string        // Initial filter
+}
+
+// Run shows the UI on the controlling terminal until the user quits or ctx is done
+func Run(ctx context.Context, deps Deps, opts Options) error {
+	model, err := NewModel(opts.Sort, opts.Filter)
+	if err != nil {
+		return err
+	}
+	term, err := openTerminal()
+	if err != nil {
+		return err
+	}
+	defer term.close()
+
+	app := &app{deps: deps, model: model, out: term.tty}
+	return app.loop(ctx, term, opts.Interval)
+}
+
+// app connects a Model to the terminal and the system
+type app struct {
+	deps  Deps
+	model *Model
+	out   io.Writer
+}
+
+// scanResult is the outcome of a background scan
+type scanResult struct {
+	rows []Row
+	err  error
+}
+
+func (a *app) loop(ctx context.Context, term *terminal, interval time.Duration) error {
+	keys := make(chan []Key)
+	go readKeys(term.tty, keys)
+
+	resize := make(chan os.Signal, 1)
+	signal.Notify(resize, syscall.SIGWINCH)
+	defer signal.Stop(resize)
+
+	scans := make(chan scanResult, 1)
+	statuses := make(chan string, 1)
+	scanning := true
+	go a.scan(scans)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	width, height := term.size()
+	for {
+		io.WriteString(a.out, renderFrame(a.model.View(width, height), width))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-resize:
+			width, height = term.size()
+		case <-ticker.C:
+			if !scanning {
+				scanning = true
+				go a.scan(scans)
+			}
+		case result := <-scans:
+			scanning = false
+			if result.err != nil {
+				a.model.SetStatus("Scan failed: %v", result.err)
+				continue
+			}
+			a.model.SetRows(result.rows, a.deps.Now())
+		case status := <-statuses:
+			// A kill finished; show the listeners without it
+			a.model.SetStatus("%s", status)
+			if !scanning {
+				scanning = true
+				go a.scan(scans)
+			}
+		case batch, ok := <-keys:
+			if !ok {
+				return nil // Terminal closed
+			}
+			for _, k := range batch {
+				effect := a.model.Update(k)
+				if effect.Kind == EffectQuit {
+					return nil
+				}
+				if effect.Kind == EffectRefresh && !scanning {
+					scanning = true
+					go a.scan(scans)
+				}
+				a.apply(effect, statuses)
+			}
+		}
+	}
+}
+
+// readKeys sends key presses until the terminal can no longer be read
+func readKeys(tty io.Reader, keys chan<- []Key) {
+	defer close(keys)
+	buf := make([]byte, 256)
+	for {
+		n, err := tty.Read(buf)
+		if err != nil {
+			return
+		}
+		keys <- ParseKeys(buf[:n])
+	}
+}
+
+// scan builds the rows and starts LLM lookups for commands without a name
+func (a *app) scan(results chan<- scanResult) {
+	ports, err := a.deps.Scan()
+	if err != nil {
+		results <- scanResult{err: err}
+		return
+	}
+	listeners := scanner.GroupListeners(ports)
+
+	pids := make([]int, len(listeners))
+	for i, l := range listeners {
+		pids[i] = l.PID
+	}
+	processes, err := a.deps.Processes(pids)
+	if err != nil {
+		logger.Error("TUI process lookup failed: %v", err)
+	}
+
+	rows := make([]Row, len(listeners))
+	for i, l := range listeners {
+		rows[i] = Row{Listener: l, Info: processes[l.PID]}
+		command := rows[i].Command()
+		rows[i].Service = a.deps.ServiceName(command)
+		if rows[i].Service == "未知" {
+			rows[i].Service = ""
+		} else if rows[i].Service == "" && a.deps.Rewrite != nil {
+			// Shows up after a later scan
+			a.deps.Rewrite(command)
+		}
+	}
+	results <- scanResult{rows: rows}
+}
+
+// apply carries out an effect; slow ones report through statuses when done
+func (a *app) apply(effect Effect, statuses chan<- string) {
+	row := effect.Row
+	switch effect.Kind {
+	case EffectCopy:
+		io.WriteString(a.out, osc52(effect.Text))
+		a.model.SetStatus("Copied %s", truncate(effect.Text, 60))
+	case EffectOpen:
+		if err := a.deps.Open(row.Listener.Primary().BindAddress, row.Listener.Port); err != nil {
+			a.model.SetStatus("Open failed: %v", err)
+		}
+	case EffectKill:
+		a.model.SetStatus("Stopping %s (PID %d)...", row.Listener.ProcessName, row.Listener.PID)
+		go func() {
+			statuses <- a.kill(row)
+		}()
+	}
+}
+
+// kill stops the row's process like the tray menu does, without elevation
+func (a *app) kill(row Row) string {
+	result, err := a.deps.Kill([]int{row.Listener.PID}, actions.KillOptions{
+		Grace:    actions.DefaultGrace,
+		Escalate: true,
+		Port:     row.Listener.Port,
+		Protocol: row.Listener.Protocol,
+	})
+	if err != nil {
+		logger.Error("TUI kill of PID %d failed: %v", row.Listener.PID, err)
+		return fmt.Sprintf("Kill failed: %v", err)
+	}
+	logger.Info("TUI killed PID %d on port %d", row.Listener.PID, row.Listener.Port)
+	if survivors := result.Survivors(); len(survivors) > 0 {
+		return fmt.Sprintf("PID %v still running after %s", survivors, result.Elapsed.Round(100*time.Millisecond))
+	}
+	if !result.PortFreed {
+		return fmt.Sprintf("%s exited but port %d is still in use", row.Listener.ProcessName, row.Listener.Port)
+	}
+	return fmt.Sprintf("Stopped %s (PID %d), port %d is free", row.Listener.ProcessName, row.Listener.PID, row.Listener.Port)
+}
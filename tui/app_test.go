@@ -0,0 +1,117 @@
+package tui
+
+import (
+	"errors"
+	"port-digger/actions"
+	"port-digger/scanner"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApp_Scan(t *testing.T) {
+	var rewrites []string
+	a := &app{deps: Deps{
+		Scan: func() ([]scanner.PortInfo, error) {
+			return []scanner.PortInfo{
+				{Port: 3000, PID: 4242, ProcessName: "node", Protocol: "TCP", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4},
+				{Port: 3000, PID: 4242, ProcessName: "node", Protocol: "TCP6", BindAddress: "::", Family: scanner.FamilyIPv6},
+				{Port: 5353, PID: 312, ProcessName: "mDNSResponder", Protocol: "UDP", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4},
+				{Port: 6379, PID: 77, ProcessName: "redis-server", Protocol: "TCP", BindAddress: "127.0.0.1", Family: scanner.FamilyIPv4},
+			}, nil
+		},
+		Processes: func([]int) (map[int]scanner.ProcessInfo, error) {
+			return map[int]scanner.ProcessInfo{4242: {PID: 4242, User: "alice", Command: "node vite"}}, nil
+		},
+		ServiceName: func(command string) string {
+			return map[string]string{"node vite": "Vite", "mDNSResponder": "未知"}[command]
+		},
+		Rewrite: func(command string) { rewrites = append(rewrites, command) },
+	}}
+
+	results := make(chan scanResult, 1)
+	a.scan(results)
+	result := <-results
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+
+	if len(result.rows) != 3 {
+		t.Fatalf("got %d rows, want IPv4 and IPv6 of node merged into 3", len(result.rows))
+	}
+	services := map[int]string{}
+	for _, r := range result.rows {
+		services[r.Listener.Port] = r.Service
+	}
+	if services[3000] != "Vite" || services[5353] != "" || services[6379] != "" {
+		t.Errorf("services = %v", services)
+	}
+	// Unknown names are not asked again, cached names need no request
+	if len(rewrites) != 1 || rewrites[0] != "redis-server" {
+		t.Errorf("rewrites = %v, want only redis-server", rewrites)
+	}
+}
+
+func TestApp_ScanFailure(t *testing.T) {
+	a := &app{deps: Deps{Scan: func() ([]scanner.PortInfo, error) { return nil, errors.New("boom") }}}
+	results := make(chan scanResult, 1)
+	a.scan(results)
+	if result := <-results; result.err == nil {
+		t.Error("scan error was not reported")
+	}
+}
+
+func TestApp_Kill(t *testing.T) {
+	row := testRow(3000, 4242, "node", "Vite", "alice", time.Minute)
+
+	tests := []struct {
+		name   string
+		result actions.KillResult
+		err    error
+		want   string
+	}{
+		{"freed", actions.KillResult{PortFreed: true}, nil, "Stopped node (PID 4242), port 3000 is free"},
+		{"survivor", actions.KillResult{Processes: []actions.ProcessResult{{PID: 4242}}, Elapsed: 3 * time.Second}, nil, "PID [4242] still running after 3s"},
+		{"port held", actions.KillResult{}, nil, "node exited but port 3000 is still in use"},
+		{"error", actions.KillResult{}, errors.New("operation not permitted"), "Kill failed: operation not permitted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got actions.KillOptions
+			a := &app{deps: Deps{Kill: func(pids []int, opts actions.KillOptions) (actions.KillResult, error) {
+				got = opts
+				return tt.result, tt.err
+			}}}
+			if status := a.kill(row); status != tt.want {
+				t.Errorf("kill() = %q, want %q", status, tt.want)
+			}
+			if got.Privileged || !got.Escalate || got.Port != 3000 {
+				t.Errorf("kill options = %+v, want an unprivileged escalating kill of port 3000", got)
+			}
+		})
+	}
+}
+
+func TestApp_Apply(t *testing.T) {
+	var out strings.Builder
+	var opened string
+	a := &app{
+		deps: Deps{Open: func(address string, port int) error {
+			opened = address
+			return nil
+		}},
+		model: newTestModel(t),
+		out:   &out,
+	}
+
+	a.apply(Effect{Kind: EffectCopy, Text: "3000"}, nil)
+	if out.String() != osc52("3000") || a.model.status != "Copied 3000" {
+		t.Errorf("copy wrote %q, status %q", out.String(), a.model.status)
+	}
+
+	a.apply(Effect{Kind: EffectOpen, Row: testRow(3000, 4242, "node", "", "", 0)}, nil)
+	if opened != "0.0.0.0" {
+		t.Errorf("opened %q", opened)
+	}
+}
@@ -0,0 +1,89 @@
+package tui
+
+import "unicode/utf8"
+
+// KeyType distinguishes printable runes from special keys
+type KeyType int
+
+const (
+	KeyRune KeyType = iota
+	KeyUp
+	KeyDown
+	KeyPageUp
+	KeyPageDown
+	KeyHome
+	KeyEnd
+	KeyEnter
+	KeyEscape
+	KeyBackspace
+	KeyCtrlC
+	KeyUnknown
+)
+
+// Key is one key press
+type Key struct {
+	Type KeyType
+	Rune rune // Set for KeyRune
+}
+
+// escapeSequences maps the sequences xterm-like terminals send, including
+// the application cursor mode variants used inside tmux
+var escapeSequences = map[string]KeyType{
+	"\x1b[A": KeyUp, "\x1bOA": KeyUp,
+	"\x1b[B": KeyDown, "\x1bOB": KeyDown,
+	"\x1b[5~": KeyPageUp, "\x1b[6~": KeyPageDown,
+	"\x1b[H": KeyHome, "\x1bOH": KeyHome, "\x1b[1~": KeyHome, "\x1b[7~": KeyHome,
+	"\x1b[F": KeyEnd, "\x1bOF": KeyEnd, "\x1b[4~": KeyEnd, "\x1b[8~": KeyEnd,
+}
+
+// ParseKeys decodes the bytes of one terminal read into key presses
+// A lone ESC is the Escape key; sequences that are not recognized become
+// KeyUnknown rather than a burst of runes
+func ParseKeys(input []byte) []Key {
+	var keys []Key
+	for len(input) > 0 {
+		key, size := parseKey(input)
+		keys = append(keys, key)
+		input = input[size:]
+	}
+	return keys
+}
+
+func parseKey(input []byte) (Key, int) {
+	switch b := input[0]; {
+	case b == 0x1b:
+		return parseEscape(input)
+	case b == '\r' || b == '\n':
+		return Key{Type: KeyEnter}, 1
+	case b == 0x7f || b == 0x08:
+		return Key{Type: KeyBackspace}, 1
+	case b == 0x03:
+		return Key{Type: KeyCtrlC}, 1
+	case b < 0x20:
+		return Key{Type: KeyUnknown}, 1
+	}
+
+	r, size := utf8.DecodeRune(input)
+	if r == utf8.RuneError {
+		return Key{Type: KeyUnknown}, size
+	}
+	return Key{Type: KeyRune, Rune: r}, size
+}
+
+func parseEscape(input []byte) (Key, int) {
+	if len(input) == 1 || (input[1] != '[' && input[1] != 'O') {
+		return Key{Type: KeyEscape}, 1
+	}
+	for seq, t := range escapeSequences {
+		if len(input) >= len(seq) && string(input[:len(seq)]) == seq {
+			return Key{Type: t}, len(seq)
+		}
+	}
+	// Skip an unknown CSI sequence up to its final byte
+	for i := 2; i < len(input); i++ {
+		if input[i] >= 0x40 && input[i] <= 0x7e {
+			return Key{Type: KeyUnknown}, i + 1
+		}
+	}
+	return Key{Type: KeyUnknown}, len(input)
+}
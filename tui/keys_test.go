@@ -0,0 +1,32 @@
+package tui
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Key
+	}{
+		{"runes", "q/é", []Key{press('q'), press('/'), press('é')}},
+		{"arrows", "\x1b[A\x1b[B", []Key{{Type: KeyUp}, {Type: KeyDown}}},
+		{"application cursor mode", "\x1bOA\x1bOB", []Key{{Type: KeyUp}, {Type: KeyDown}}},
+		{"paging", "\x1b[5~\x1b[6~\x1b[H\x1b[4~", []Key{{Type: KeyPageUp}, {Type: KeyPageDown}, {Type: KeyHome}, {Type: KeyEnd}}},
+		{"control keys", "\r\x7f\x03", []Key{{Type: KeyEnter}, {Type: KeyBackspace}, {Type: KeyCtrlC}}},
+		{"lone escape", "\x1b", []Key{{Type: KeyEscape}}},
+		{"escape then rune", "\x1bq", []Key{{Type: KeyEscape}, press('q')}},
+		{"unknown sequence", "\x1b[1;5Cj", []Key{{Type: KeyUnknown}, press('j')}},
+		{"other control byte", "\x01", []Key{{Type: KeyUnknown}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseKeys([]byte(tt.input)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeys(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,544 @@
+// Package tui is the interactive terminal mode: a live table of listeners
+// with keys to kill, copy, open and inspect them
+//
+// Model holds all state and turns keys into Effects without touching the
+// terminal or the system, so it can be tested on its own; App runs it
+package tui
+
+import (
+	"fmt"
+	"port-digger/menu"
+	"port-digger/scanner"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Row is one listener in the table
+type Row struct {
+	Listener scanner.Listener
+	Info     scanner.ProcessInfo // Zero if the process could not be inspected
+	Service  string              // LLM service name, "" if unknown
+}
+
+// Command returns the full command line, falling back to the process name
+func (r Row) Command() string {
+	if r.Info.Command != "" {
+		return r.Info.Command
+	}
+	if r.Listener.Command != "" {
+		return r.Listener.Command
+	}
+	return r.Listener.ProcessName
+}
+
+// key identifies a row across rescans
+type rowKey struct {
+	port     int
+	pid      int
+	protocol string
+}
+
+func (r Row) key() rowKey {
+	return rowKey{r.Listener.Port, r.Listener.PID, r.Listener.Protocol}
+}
+
+// EffectKind is something the model asks the app to do
+type EffectKind int
+
+const (
+	EffectNone EffectKind = iota
+	EffectQuit
+	EffectRefresh
+	EffectKill // Kill Row's process after the user confirmed
+	EffectCopy // Copy Text to the clipboard
+	EffectOpen // Open Row's port in a browser
+)
+
+// Effect is returned by Update for the app to carry out
+type Effect struct {
+	Kind EffectKind
+	Row  Row
+	Text string
+}
+
+// mode decides how keys are interpreted and what is drawn
+type mode int
+
+const (
+	modeTable mode = iota
+	modeFilter
+	modeConfirmKill
+	modeDetails
+	modeHelp
+)
+
+// sortColumns are the keys of lessFuncs in the order "s" cycles through them
+var sortColumns = []string{"port", "process", "service", "pid", "user", "uptime"}
+
+// Model is the state of the terminal UI
+type Model struct {
+	rows    []Row // As scanned
+	visible []Row // Filtered and sorted
+
+	sortBy   string
+	reverse  bool
+	filter   string
+	selected int // Index into visible
+	offset   int // First visible row on screen
+
+	mode    mode
+	killing Row // The row the kill prompt asks about
+	status  string
+	now     time.Time
+	scanned bool // Rows were set at least once
+}
+
+// NewModel returns a model sorted by sortBy ("" means port) and filtered by filter
+func NewModel(sortBy, filter string) (*Model, error) {
+	if sortBy == "" {
+		sortBy = "port"
+	}
+	if !validSort(sortBy) {
+		return nil, fmt.Errorf("unknown sort column %q: use %s", sortBy, strings.Join(sortColumns, ", "))
+	}
+	return &Model{sortBy: sortBy, filter: filter, now: time.Now()}, nil
+}
+
+func validSort(column string) bool {
+	for _, c := range sortColumns {
+		if c == column {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRows replaces the listeners after a scan, keeping the selected row
+// selected if it still exists
+func (m *Model) SetRows(rows []Row, now time.Time) {
+	var selected *rowKey
+	if row, ok := m.Selected(); ok {
+		key := row.key()
+		selected = &key
+	}
+	m.rows, m.now, m.scanned = rows, now, true
+	m.refilter(selected)
+}
+
+// SetStatus shows a message in the status line until the next key press
+func (m *Model) SetStatus(format string, args ...any) {
+	m.status = fmt.Sprintf(format, args...)
+}
+
+// Selected returns the highlighted row
+func (m *Model) Selected() (Row, bool) {
+	if m.selected < 0 || m.selected >= len(m.visible) {
+		return Row{}, false
+	}
+	return m.visible[m.selected], true
+}
+
+// refilter recomputes the visible rows and moves the selection to keep
+func (m *Model) refilter(keep *rowKey) {
+	m.visible = m.visible[:0]
+	needle := strings.ToLower(m.filter)
+	for _, r := range m.rows {
+		if needle == "" || strings.Contains(strings.ToLower(searchText(r)), needle) {
+			m.visible = append(m.visible, r)
+		}
+	}
+	m.sort()
+
+	if keep != nil {
+		for i, r := range m.visible {
+			if r.key() == *keep {
+				m.selected = i
+				return
+			}
+		}
+	}
+	m.selected = min(m.selected, len(m.visible)-1)
+	m.selected = max(m.selected, 0)
+}
+
+// searchText is what the filter matches against
+func searchText(r Row) string {
+	return strings.Join([]string{
+		strconv.Itoa(r.Listener.Port),
+		strconv.Itoa(r.Listener.PID),
+		r.Listener.ProcessName,
+		r.Service,
+		r.Info.User,
+		r.Command(),
+		strings.Join(r.Listener.BindAddresses(), " "),
+	}, " ")
+}
+
+// lessFuncs order rows by each sort column
+var lessFuncs = map[string]func(a, b Row, now time.Time) bool{
+	"port":    func(a, b Row, _ time.Time) bool { return a.Listener.Port < b.Listener.Port },
+	"process": func(a, b Row, _ time.Time) bool { return lowerLess(a.Listener.ProcessName, b.Listener.ProcessName) },
+	"service": func(a, b Row, _ time.Time) bool { return lowerLess(a.Service, b.Service) },
+	"pid":     func(a, b Row, _ time.Time) bool { return a.Listener.PID < b.Listener.PID },
+	"user":    func(a, b Row, _ time.Time) bool { return a.Info.User < b.Info.User },
+	// Longest running first
+	"uptime": func(a, b Row, now time.Time) bool { return a.Info.Uptime(now) > b.Info.Uptime(now) },
+}
+
+func lowerLess(a, b string) bool {
+	return strings.ToLower(a) < strings.ToLower(b)
+}
+
+func (m *Model) sort() {
+	less := lessFuncs[m.sortBy]
+	sort.SliceStable(m.visible, func(i, j int) bool {
+		a, b := m.visible[i], m.visible[j]
+		if m.reverse {
+			a, b = b, a
+		}
+		if less(a, b, m.now) {
+			return true
+		}
+		if less(b, a, m.now) {
+			return false
+		}
+		// Ties keep a stable order by port, then PID
+		if a.Listener.Port != b.Listener.Port {
+			return a.Listener.Port < b.Listener.Port
+		}
+		return a.Listener.PID < b.Listener.PID
+	})
+}
+
+// Update applies a key press and returns what the app should do
+func (m *Model) Update(k Key) Effect {
+	m.status = ""
+	if k.Type == KeyCtrlC {
+		return Effect{Kind: EffectQuit}
+	}
+
+	switch m.mode {
+	case modeFilter:
+		return m.updateFilter(k)
+	case modeConfirmKill:
+		// A refresh may have moved the selection since the prompt opened, so
+		// the row shown in the prompt is killed
+		m.mode = modeTable
+		if k.Rune == 'y' || k.Rune == 'Y' {
+			return Effect{Kind: EffectKill, Row: m.killing}
+		}
+		m.status = "Kill cancelled"
+		return Effect{}
+	case modeDetails, modeHelp:
+		// Any key closes the overlay; q still quits
+		m.mode = modeTable
+		if k.Rune == 'q' {
+			return Effect{Kind: EffectQuit}
+		}
+		return Effect{}
+	}
+	return m.updateTable(k)
+}
+
+func (m *Model) updateFilter(k Key) Effect {
+	switch k.Type {
+	case KeyEnter:
+		m.mode = modeTable
+	case KeyEscape:
+		m.mode, m.filter = modeTable, ""
+		m.refilter(m.selectedKey())
+	case KeyBackspace:
+		if m.filter != "" {
+			_, size := utf8.DecodeLastRuneInString(m.filter)
+			m.filter = m.filter[:len(m.filter)-size]
+			m.refilter(m.selectedKey())
+		}
+	case KeyRune:
+		m.filter += string(k.Rune)
+		m.refilter(m.selectedKey())
+	}
+	return Effect{}
+}
+
+func (m *Model) selectedKey() *rowKey {
+	if row, ok := m.Selected(); ok {
+		key := row.key()
+		return &key
+	}
+	return nil
+}
+
+// pageSize is how many rows PgUp and PgDn move
+const pageSize = 10
+
+func (m *Model) updateTable(k Key) Effect {
+	switch k.Type {
+	case KeyUp:
+		m.move(-1)
+	case KeyDown:
+		m.move(1)
+	case KeyPageUp:
+		m.move(-pageSize)
+	case KeyPageDown:
+		m.move(pageSize)
+	case KeyHome:
+		m.selected = 0
+	case KeyEnd:
+		m.selected = max(len(m.visible)-1, 0)
+	case KeyEnter:
+		m.openOverlay(modeDetails)
+	case KeyEscape:
+		if m.filter != "" {
+			m.filter = ""
+			m.refilter(m.selectedKey())
+		}
+	case KeyRune:
+		return m.updateRune(k.Rune)
+	}
+	return Effect{}
+}
+
+func (m *Model) updateRune(r rune) Effect {
+	switch r {
+	case 'q':
+		return Effect{Kind: EffectQuit}
+	case 'k':
+		m.move(-1)
+	case 'j':
+		m.move(1)
+	case 'g':
+		m.selected = 0
+	case 'G':
+		m.selected = max(len(m.visible)-1, 0)
+	case '/':
+		m.mode = modeFilter
+	case 's':
+		m.cycleSort()
+	case 'S':
+		m.reverse = !m.reverse
+		m.refilter(m.selectedKey())
+	case 'r':
+		m.status = "Refreshing..."
+		return Effect{Kind: EffectRefresh}
+	case 'd':
+		m.openOverlay(modeDetails)
+	case '?':
+		m.mode = modeHelp
+	case 'x':
+		if row, ok := m.Selected(); ok {
+			m.mode, m.killing = modeConfirmKill, row
+		}
+	case 'c':
+		if row, ok := m.Selected(); ok {
+			return Effect{Kind: EffectCopy, Row: row, Text: strconv.Itoa(row.Listener.Port)}
+		}
+	case 'C':
+		if row, ok := m.Selected(); ok {
+			return Effect{Kind: EffectCopy, Row: row, Text: row.Command()}
+		}
+	case 'o':
+		if row, ok := m.Selected(); ok {
+			if row.Listener.IsUDP() {
+				m.status = "UDP sockets cannot be opened in a browser"
+				return Effect{}
+			}
+			return Effect{Kind: EffectOpen, Row: row}
+		}
+	}
+	return Effect{}
+}
+
+func (m *Model) openOverlay(next mode) {
+	if _, ok := m.Selected(); ok {
+		m.mode = next
+	}
+}
+
+func (m *Model) move(delta int) {
+	m.selected = max(0, min(m.selected+delta, len(m.visible)-1))
+}
+
+func (m *Model) cycleSort() {
+	for i, c := range sortColumns {
+		if c == m.sortBy {
+			m.sortBy = sortColumns[(i+1)%len(sortColumns)]
+			break
+		}
+	}
+	m.refilter(m.selectedKey())
+}
+
+// Screen is a rendered frame: plain text lines, one of which is highlighted
+type Screen struct {
+	Lines     []string
+	Highlight int // Index into Lines, -1 for none
+}
+
+// column is one table column; width 0 takes the remaining space
+type column struct {
+	title string
+	width int
+	value func(r Row, now time.Time) string
+}
+
+var columns = []column{
+	{"PORT", 5, func(r Row, _ time.Time) string { return strconv.Itoa(r.Listener.Port) }},
+	{"PROTO", 5, func(r Row, _ time.Time) string { return r.Listener.Protocol }},
+	{"BIND", 18, func(r Row, _ time.Time) string {
+		addresses := r.Listener.BindAddresses()
+		for i, a := range addresses {
+			addresses[i] = menu.FormatBindAddress(a)
+		}
+		return strings.Join(addresses, ",")
+	}},
+	{"PROCESS", 16, func(r Row, _ time.Time) string { return r.Listener.ProcessName }},
+	{"SERVICE", 16, func(r Row, _ time.Time) string { return r.Service }},
+	{"PID", 7, func(r Row, _ time.Time) string { return strconv.Itoa(r.Listener.PID) }},
+	{"USER", 10, func(r Row, _ time.Time) string { return r.Info.User }},
+	{"UPTIME", 7, func(r Row, now time.Time) string {
+		if up := r.Info.Uptime(now); up > 0 {
+			return menu.FormatUptime(up)
+		}
+		return ""
+	}},
+	{"COMMAND", 0, func(r Row, _ time.Time) string { return r.Command() }},
+}
+
+// View renders the model for a terminal of width x height cells
+func (m *Model) View(width, height int) Screen {
+	lines := []string{truncate(m.titleLine(), width)}
+	switch m.mode {
+	case modeDetails:
+		return m.overlay(lines, m.detailLines(), width, height)
+	case modeHelp:
+		return m.overlay(lines, helpLines, width, height)
+	}
+
+	lines = append(lines, formatRow(width, func(c column) string { return c.title }))
+
+	// Title, header and status line surround the table
+	body := max(height-3, 1)
+	if m.selected < m.offset {
+		m.offset = m.selected
+	}
+	if m.selected >= m.offset+body {
+		m.offset = m.selected - body + 1
+	}
+	m.offset = max(0, min(m.offset, len(m.visible)-body))
+
+	highlight := -1
+	for i := m.offset; i < len(m.visible) && i < m.offset+body; i++ {
+		row := m.visible[i]
+		if i == m.selected {
+			highlight = len(lines)
+		}
+		lines = append(lines, formatRow(width, func(c column) string { return c.value(row, m.now) }))
+	}
+	if !m.scanned {
+		lines = append(lines, "  Scanning...")
+	} else if len(m.visible) == 0 && m.filter != "" {
+		lines = append(lines, "  No listeners match the filter")
+	} else if len(m.visible) == 0 {
+		lines = append(lines, "  No listeners")
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, truncate(m.statusLine(), width))
+	return Screen{Lines: lines, Highlight: highlight}
+}
+
+// overlay shows text instead of the table
+func (m *Model) overlay(lines, text []string, width, height int) Screen {
+	lines = append(lines, "")
+	for _, line := range text {
+		lines = append(lines, truncate("  "+line, width))
+	}
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines[:max(height-1, 1)], "Press any key to return")
+	return Screen{Lines: lines, Highlight: -1}
+}
+
+func (m *Model) titleLine() string {
+	direction := "↑"
+	if m.reverse {
+		direction = "↓"
+	}
+	title := fmt.Sprintf("Port Digger · %d of %d listeners · sort: %s %s", len(m.visible), len(m.rows), m.sortBy, direction)
+	if m.filter != "" || m.mode == modeFilter {
+		title += " · filter: " + m.filter
+	}
+	return title
+}
+
+func (m *Model) statusLine() string {
+	switch m.mode {
+	case modeFilter:
+		return "/" + m.filter + "▏  Enter keep · Esc clear"
+	case modeConfirmKill:
+		row := m.killing
+		return fmt.Sprintf("Kill %s (PID %d) on port %d? [y/N]", row.Listener.ProcessName, row.Listener.PID, row.Listener.Port)
+	}
+	if m.status != "" {
+		return m.status
+	}
+	return "q quit · / filter · s sort · x kill · c copy · o open · Enter details · ? help"
+}
+
+func (m *Model) detailLines() []string {
+	row, _ := m.Selected()
+	lines := []string{
+		fmt.Sprintf("Port %d/%s · %s", row.Listener.Port, row.Listener.Protocol, strings.Join(row.Listener.BindAddresses(), ", ")),
+	}
+	if row.Service != "" {
+		lines = append(lines, "Service: "+row.Service)
+	}
+	lines = append(lines, "Command: "+row.Command(), "")
+	return append(lines, menu.FormatDetails(row.Info, m.now)...)
+}
+
+var helpLines = []string{
+	"↑/k ↓/j     Move the selection",
+	"g G         First or last row, PgUp/PgDn by page",
+	"/           Filter by port, process, service, user, command or address",
+	"Esc         Clear the filter",
+	"s S         Next sort column, reverse the order",
+	"Enter d     Show the full command and process details",
+	"x           Kill the selected process (asks first)",
+	"c C         Copy the port or the full command",
+	"o           Open the port in a browser",
+	"r           Rescan now",
+	"q           Quit",
+}
+
+// formatRow lays out one table line, truncating cells to their column width
+func formatRow(width int, cell func(c column) string) string {
+	var b strings.Builder
+	for _, c := range columns {
+		if c.width == 0 {
+			b.WriteString(cell(c))
+			break
+		}
+		text := truncate(cell(c), c.width)
+		b.WriteString(text)
+		b.WriteString(strings.Repeat(" ", c.width-utf8.RuneCountInString(text)+1))
+	}
+	return truncate(strings.TrimRight(b.String(), " "), width)
+}
+
+// truncate shortens s to width runes, marking the cut with "…"
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:width-1]) + "…"
+}
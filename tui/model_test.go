@@ -0,0 +1,350 @@
+package tui
+
+import (
+	"port-digger/scanner"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+
+// testRow builds a row for a TCP listener on 0.0.0.0
+func testRow(port, pid int, process, service, user string, uptime time.Duration) Row {
+	sock := scanner.PortInfo{Port: port, PID: pid, ProcessName: process, Protocol: "TCP", BindAddress: "0.0.0.0", Family: scanner.FamilyIPv4}
+	return Row{
+		Listener: scanner.Listener{Port: port, PID: pid, ProcessName: process, Protocol: "TCP", Sockets: []scanner.PortInfo{sock}},
+		Info:     scanner.ProcessInfo{PID: pid, User: user, Command: process + " --serve", StartTime: testNow.Add(-uptime)},
+		Service:  service,
+	}
+}
+
+func testRows() []Row {
+	return []Row{
+		testRow(5432, 812, "postgres", "", "postgres", 72*time.Hour),
+		testRow(3000, 4242, "node", "Vite", "alice", 5*time.Minute),
+		testRow(8080, 999, "java", "Jenkins", "jenkins", time.Hour),
+	}
+}
+
+func newTestModel(t *testing.T) *Model {
+	t.Helper()
+	m, err := NewModel("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetRows(testRows(), testNow)
+	return m
+}
+
+// ports returns the ports of the visible rows in order
+func ports(m *Model) []int {
+	var result []int
+	for _, r := range m.visible {
+		result = append(result, r.Listener.Port)
+	}
+	return result
+}
+
+// press returns the key press of a printable rune
+func press(r rune) Key { return Key{Type: KeyRune, Rune: r} }
+
+func typeKeys(m *Model, keys ...Key) Effect {
+	var effect Effect
+	for _, k := range keys {
+		effect = m.Update(k)
+	}
+	return effect
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestModel_Sort(t *testing.T) {
+	tests := []struct {
+		keys []Key
+		want []int
+	}{
+		{nil, []int{3000, 5432, 8080}},
+		{[]Key{press('s')}, []int{8080, 3000, 5432}},                                                 // process
+		{[]Key{press('s'), press('s')}, []int{5432, 8080, 3000}},                                     // service, unnamed first
+		{[]Key{press('s'), press('s'), press('s')}, []int{5432, 8080, 3000}},                         // pid
+		{[]Key{press('S')}, []int{8080, 5432, 3000}},                                                 // port, reversed
+		{[]Key{press('s'), press('s'), press('s'), press('s'), press('s')}, []int{5432, 8080, 3000}}, // uptime
+	}
+
+	for _, tt := range tests {
+		m := newTestModel(t)
+		typeKeys(m, tt.keys...)
+		if got := ports(m); !equalInts(got, tt.want) {
+			t.Errorf("after %v sorted by %s: %v, want %v", tt.keys, m.sortBy, got, tt.want)
+		}
+	}
+}
+
+func TestNewModel_UnknownSort(t *testing.T) {
+	if _, err := NewModel("memory", ""); err == nil {
+		t.Error("NewModel() accepted an unknown sort column")
+	}
+}
+
+func TestModel_Filter(t *testing.T) {
+	m := newTestModel(t)
+	typeKeys(m, press('/'), press('V'), press('I'))
+	if got := ports(m); !equalInts(got, []int{3000}) {
+		t.Errorf("filter %q shows %v, want only the Vite row", m.filter, got)
+	}
+	if !strings.Contains(m.View(120, 10).Lines[9], "/VI") {
+		t.Errorf("status line does not show the filter being typed: %q", m.View(120, 10).Lines[9])
+	}
+
+	// Keys are filter text until Enter, so 'q' does not quit
+	if effect := typeKeys(m, Key{Type: KeyBackspace}, Key{Type: KeyBackspace}, press('q')); effect.Kind != EffectNone {
+		t.Errorf("typing q in the filter returned %v", effect.Kind)
+	}
+	if got := ports(m); len(got) != 0 {
+		t.Errorf("filter %q shows %v, want nothing", m.filter, got)
+	}
+	if screen := m.View(120, 10); !strings.Contains(strings.Join(screen.Lines, "\n"), "No listeners match the filter") {
+		t.Errorf("empty result not explained:\n%s", strings.Join(screen.Lines, "\n"))
+	}
+
+	typeKeys(m, Key{Type: KeyBackspace}, press('8'), press('0'), press('8'), Key{Type: KeyEnter})
+	if got := ports(m); !equalInts(got, []int{8080}) || m.mode != modeTable {
+		t.Errorf("filter %q shows %v in mode %v", m.filter, got, m.mode)
+	}
+
+	typeKeys(m, Key{Type: KeyEscape})
+	if got := ports(m); len(got) != 3 || m.filter != "" {
+		t.Errorf("Escape left filter %q showing %v", m.filter, got)
+	}
+}
+
+func TestModel_SelectionSurvivesRescan(t *testing.T) {
+	m := newTestModel(t)
+	typeKeys(m, Key{Type: KeyDown})
+	if row, _ := m.Selected(); row.Listener.Port != 5432 {
+		t.Fatalf("selected %d, want 5432", row.Listener.Port)
+	}
+
+	// A new listener sorts before the selection
+	m.SetRows(append(testRows(), testRow(22, 1, "sshd", "", "root", time.Hour)), testNow)
+	if row, _ := m.Selected(); row.Listener.Port != 5432 {
+		t.Errorf("selection moved to %d after rescan, want 5432", row.Listener.Port)
+	}
+
+	// The selected listener disappears; the selection stays in range
+	m.SetRows(testRows()[1:2], testNow)
+	if row, ok := m.Selected(); !ok || row.Listener.Port != 3000 {
+		t.Errorf("selected %d (%v), want the only row", row.Listener.Port, ok)
+	}
+
+	m.SetRows(nil, testNow)
+	if _, ok := m.Selected(); ok {
+		t.Error("selected a row in an empty table")
+	}
+	if effect := typeKeys(m, press('x'), press('y')); effect.Kind != EffectNone {
+		t.Errorf("kill in an empty table returned %v", effect.Kind)
+	}
+}
+
+func TestModel_Navigation(t *testing.T) {
+	m := newTestModel(t)
+	tests := []struct {
+		key  Key
+		want int
+	}{
+		{Key{Type: KeyUp}, 3000},
+		{press('j'), 5432},
+		{press('j'), 8080},
+		{press('j'), 8080},
+		{press('k'), 5432},
+		{press('g'), 3000},
+		{press('G'), 8080},
+		{Key{Type: KeyPageUp}, 3000},
+		{Key{Type: KeyEnd}, 8080},
+		{Key{Type: KeyHome}, 3000},
+		{Key{Type: KeyPageDown}, 8080},
+	}
+	for i, tt := range tests {
+		m.Update(tt.key)
+		if row, _ := m.Selected(); row.Listener.Port != tt.want {
+			t.Errorf("step %d: selected %d, want %d", i, row.Listener.Port, tt.want)
+		}
+	}
+}
+
+func TestModel_Effects(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     []Key
+		wantKind EffectKind
+		wantText string
+	}{
+		{"quit", []Key{press('q')}, EffectQuit, ""},
+		{"ctrl-c in filter", []Key{press('/'), {Type: KeyCtrlC}}, EffectQuit, ""},
+		{"copy port", []Key{press('c')}, EffectCopy, "3000"},
+		{"copy command", []Key{press('C')}, EffectCopy, "node --serve"},
+		{"open", []Key{press('o')}, EffectOpen, ""},
+		{"refresh", []Key{press('r')}, EffectRefresh, ""},
+		{"kill confirmed", []Key{press('x'), press('y')}, EffectKill, ""},
+		{"kill cancelled", []Key{press('x'), press('n')}, EffectNone, ""},
+		{"kill cancelled by q", []Key{press('x'), press('q')}, EffectNone, ""},
+		{"details then quit", []Key{{Type: KeyEnter}, press('q')}, EffectQuit, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(t)
+			effect := typeKeys(m, tt.keys...)
+			if effect.Kind != tt.wantKind || effect.Text != tt.wantText {
+				t.Errorf("effect = %v %q, want %v %q", effect.Kind, effect.Text, tt.wantKind, tt.wantText)
+			}
+			if effect.Kind == EffectKill || effect.Kind == EffectOpen || effect.Kind == EffectCopy {
+				if effect.Row.Listener.Port != 3000 {
+					t.Errorf("effect for port %d, want the selected 3000", effect.Row.Listener.Port)
+				}
+			}
+		})
+	}
+}
+
+func TestModel_KillConfirmedRow(t *testing.T) {
+	m := newTestModel(t)
+	m.Update(press('x'))
+
+	// A refresh removes the listener while the prompt is open, moving the
+	// selection to another row
+	rows := testRows()
+	m.SetRows([]Row{rows[0], rows[2]}, testNow)
+	if row, _ := m.Selected(); row.Listener.PID == 4242 {
+		t.Fatal("selection did not move")
+	}
+	if !strings.Contains(m.statusLine(), "PID 4242") {
+		t.Errorf("prompt = %q, want it to keep asking about PID 4242", m.statusLine())
+	}
+
+	effect := m.Update(press('y'))
+	if effect.Kind != EffectKill || effect.Row.Listener.PID != 4242 {
+		t.Errorf("effect = %v for PID %d, want a kill of the confirmed PID 4242", effect.Kind, effect.Row.Listener.PID)
+	}
+}
+
+func TestModel_OpenUDP(t *testing.T) {
+	m := newTestModel(t)
+	udp := testRow(5353, 312, "mDNSResponder", "", "root", time.Hour)
+	udp.Listener.Protocol = "UDP"
+	m.SetRows([]Row{udp}, testNow)
+
+	if effect := m.Update(press('o')); effect.Kind != EffectNone {
+		t.Errorf("open on UDP returned %v", effect.Kind)
+	}
+	if !strings.Contains(m.statusLine(), "UDP") {
+		t.Errorf("status = %q, want a UDP explanation", m.statusLine())
+	}
+}
+
+func TestModel_View(t *testing.T) {
+	m := newTestModel(t)
+	m.Update(Key{Type: KeyDown})
+	screen := m.View(120, 8)
+
+	if len(screen.Lines) != 8 {
+		t.Fatalf("got %d lines, want 8:\n%s", len(screen.Lines), strings.Join(screen.Lines, "\n"))
+	}
+	want := []string{
+		"Port Digger · 3 of 3 listeners · sort: port ↑",
+		"PORT  PROTO BIND               PROCESS          SERVICE          PID     USER       UPTIME  COMMAND",
+		"3000  TCP   0.0.0.0            node             Vite             4242    alice      5m 0s   node --serve",
+		"5432  TCP   0.0.0.0            postgres                          812     postgres   3d 0h   postgres --serve",
+		"8080  TCP   0.0.0.0            java             Jenkins          999     jenkins    1h 0m   java --serve",
+	}
+	for i, line := range want {
+		if screen.Lines[i] != line {
+			t.Errorf("line %d =\n%q\nwant\n%q", i, screen.Lines[i], line)
+		}
+	}
+	if screen.Highlight != 3 {
+		t.Errorf("Highlight = %d, want the postgres line 3", screen.Highlight)
+	}
+	if !strings.HasPrefix(screen.Lines[7], "q quit") {
+		t.Errorf("status line = %q, want the key summary", screen.Lines[7])
+	}
+
+	// Narrow terminals cut lines
+	for _, line := range m.View(30, 8).Lines {
+		if n := len([]rune(line)); n > 30 {
+			t.Errorf("line of %d runes in a 30 column terminal: %q", n, line)
+		}
+	}
+}
+
+func TestModel_ViewBeforeScan(t *testing.T) {
+	m, _ := NewModel("", "")
+	if screen := m.View(80, 5); screen.Lines[2] != "  Scanning..." || screen.Highlight != -1 {
+		t.Errorf("screen before the first scan:\n%s", strings.Join(screen.Lines, "\n"))
+	}
+	m.SetRows(nil, testNow)
+	if screen := m.View(80, 5); screen.Lines[2] != "  No listeners" {
+		t.Errorf("screen without listeners:\n%s", strings.Join(screen.Lines, "\n"))
+	}
+}
+
+func TestModel_ViewScrolls(t *testing.T) {
+	m := newTestModel(t)
+	var rows []Row
+	for port := 3000; port < 3020; port++ {
+		rows = append(rows, testRow(port, port, "node", "", "alice", time.Minute))
+	}
+	m.SetRows(rows, testNow)
+	typeKeys(m, press('G'))
+
+	// 3 table rows fit between the title, header and status line
+	screen := m.View(80, 6)
+	if !strings.HasPrefix(screen.Lines[4], "3019") || screen.Highlight != 4 {
+		t.Errorf("last row not scrolled into view:\n%s", strings.Join(screen.Lines, "\n"))
+	}
+	typeKeys(m, press('g'))
+	if screen := m.View(80, 6); !strings.HasPrefix(screen.Lines[2], "3000") || screen.Highlight != 2 {
+		t.Errorf("first row not scrolled into view:\n%s", strings.Join(screen.Lines, "\n"))
+	}
+}
+
+func TestModel_Overlays(t *testing.T) {
+	m := newTestModel(t)
+	m.Update(Key{Type: KeyEnter})
+	text := strings.Join(m.View(100, 20).Lines, "\n")
+	for _, want := range []string{"Port 3000/TCP · 0.0.0.0", "Service: Vite", "Command: node --serve", "User: alice"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("details missing %q:\n%s", want, text)
+		}
+	}
+
+	m.Update(press('j')) // Closes the details without moving
+	if row, _ := m.Selected(); m.mode != modeTable || row.Listener.Port != 3000 {
+		t.Errorf("mode %v, selected %d after closing details", m.mode, row.Listener.Port)
+	}
+
+	m.Update(press('?'))
+	if text := strings.Join(m.View(100, 20).Lines, "\n"); !strings.Contains(text, "Kill the selected process") {
+		t.Errorf("help missing:\n%s", text)
+	}
+
+	m.Update(press('x')) // Closes the help without killing
+	if m.mode != modeTable {
+		t.Errorf("mode %v after closing help", m.mode)
+	}
+	m.Update(press('x'))
+	if got := m.statusLine(); got != "Kill node (PID 4242) on port 3000? [y/N]" {
+		t.Errorf("confirmation = %q", got)
+	}
+}
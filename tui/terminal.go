@@ -0,0 +1,107 @@
+package tui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI sequences used to draw the screen
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l" // Alternate screen, hidden cursor
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	cursorHome     = "\x1b[H"
+	clearToEOL     = "\x1b[K"
+	clearToEOS     = "\x1b[J"
+	reverseVideo   = "\x1b[7m"
+	resetStyle     = "\x1b[0m"
+)
+
+// terminal is the controlling terminal in raw mode
+type terminal struct {
+	tty   *os.File
+	saved string // stty -g state restored on close
+}
+
+// stty runs stty on the terminal; stty reads the terminal settings from stdin
+func stty(tty *os.File, args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = tty
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("stty %s failed: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// openTerminal switches /dev/tty to raw mode and the alternate screen
+func openTerminal() (*terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("no terminal: %w", err)
+	}
+	saved, err := stty(tty, "-g")
+	if err != nil {
+		tty.Close()
+		return nil, err
+	}
+	if _, err := stty(tty, "raw", "-echo"); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	io.WriteString(tty, enterAltScreen)
+	return &terminal{tty: tty, saved: saved}, nil
+}
+
+// close restores the screen and terminal settings
+func (t *terminal) close() {
+	io.WriteString(t.tty, exitAltScreen)
+	stty(t.tty, t.saved)
+	t.tty.Close()
+}
+
+// size returns the terminal width and height, or 80x24 if unknown
+func (t *terminal) size() (int, int) {
+	out, err := stty(t.tty, "size")
+	var rows, cols int
+	if err != nil {
+		return 80, 24
+	}
+	if n, _ := fmt.Sscan(out, &rows, &cols); n != 2 || rows <= 0 || cols <= 0 {
+		return 80, 24
+	}
+	return cols, rows
+}
+
+// renderFrame turns a screen into the bytes that redraw a terminal of the
+// given width in place
+func renderFrame(screen Screen, width int) string {
+	var b strings.Builder
+	b.WriteString(cursorHome)
+	for i, line := range screen.Lines {
+		if i > 0 {
+			// Raw mode does not turn \n into \r\n
+			b.WriteString("\r\n")
+		}
+		if i == screen.Highlight {
+			// Padded so the highlight spans the whole line
+			pad := max(width-utf8.RuneCountInString(line), 0)
+			b.WriteString(reverseVideo + line + strings.Repeat(" ", pad) + resetStyle)
+		} else {
+			b.WriteString(line + clearToEOL)
+		}
+	}
+	b.WriteString(clearToEOS)
+	return b.String()
+}
+
+// osc52 returns the escape sequence that asks the terminal to put text on the
+// clipboard of the machine it runs on, which also works over SSH. tmux needs
+// "set -g set-clipboard on" to pass it through
+func osc52(text string) string {
+	return "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+}
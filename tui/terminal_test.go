@@ -0,0 +1,17 @@
+package tui
+
+import "testing"
+
+func TestRenderFrame(t *testing.T) {
+	got := renderFrame(Screen{Lines: []string{"title", "row", "status"}, Highlight: 1}, 6)
+	want := "\x1b[Htitle\x1b[K\r\n\x1b[7mrow   \x1b[0m\r\nstatus\x1b[K\x1b[J"
+	if got != want {
+		t.Errorf("renderFrame() = %q, want %q", got, want)
+	}
+}
+
+func TestOSC52(t *testing.T) {
+	if got := osc52("3000"); got != "\x1b]52;c;MzAwMA==\a" {
+		t.Errorf("osc52() = %q", got)
+	}
+}